@@ -12,6 +12,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -358,6 +359,60 @@ func (c *Client) Reestablish(ctx context.Context, slug, network string) (res *Es
 	return c.doEstablish(ctx, slug, true, network)
 }
 
+// StatusResponse describes the tunnels the agent runs.
+type StatusResponse struct {
+	Tunnels []TunnelStatus
+}
+
+// TunnelStatus describes a tunnel of the agent and its live statistics.
+type TunnelStatus struct {
+	Org     string
+	Network string
+	// Peer is the name of the WireGuard peer the tunnel uses.
+	Peer      string
+	PeerIP    string
+	Region    string
+	Endpoint  string
+	Transport string
+	// LastHandshake is zero until the tunnel's first handshake completes.
+	LastHandshake time.Time
+	RxBytes       uint64
+	TxBytes       uint64
+	// ActiveDials counts the connections currently proxied through the tunnel.
+	ActiveDials int
+	// Error is set when the tunnel's statistics couldn't be read.
+	Error string `json:",omitempty"`
+}
+
+func (c *Client) Status(ctx context.Context) (res *StatusResponse, err error) {
+	err = c.do(ctx, func(conn net.Conn) (err error) {
+		if err = proto.Write(conn, "status"); err != nil {
+			return
+		}
+
+		var data []byte
+		if data, err = proto.Read(conn); err != nil {
+			return
+		}
+
+		switch {
+		default:
+			err = errInvalidResponse(data)
+		case isOK(data):
+			res = &StatusResponse{}
+			if err = unmarshal(res, data); err != nil {
+				res = nil
+			}
+		case isError(data):
+			err = extractError(data)
+		}
+
+		return
+	})
+
+	return
+}
+
 func (c *Client) Probe(ctx context.Context, slug, network string) error {
 	return c.do(ctx, func(conn net.Conn) (err error) {
 		if err = proto.Write(conn, "probe", slug, network); err != nil {
@@ -738,10 +793,15 @@ func (d *dialer) DialContext(ctx context.Context, network, addr string) (conn ne
 		}
 	}()
 
+	verb := "connect"
+	if network == "udp" {
+		verb = "connectudp"
+	}
+
 	c := make(chan error, 1)
 	go func() {
 		timeout := strconv.FormatInt(int64(d.timeout), 10)
-		if err := proto.Write(conn, "connect", d.slug, addr, timeout, d.network); err != nil {
+		if err := proto.Write(conn, verb, d.slug, addr, timeout, d.network); err != nil {
 			c <- err
 
 			return
@@ -770,9 +830,36 @@ func (d *dialer) DialContext(ctx context.Context, network, addr string) (conn ne
 	case err = <-c:
 	}
 
+	if err == nil && network == "udp" {
+		conn = &datagramConn{Conn: conn}
+	}
+
 	return
 }
 
+// datagramConn is a UDP connection relayed through the agent. Each Read returns a single
+// datagram, and each Write sends one.
+type datagramConn struct {
+	net.Conn
+
+	rmu sync.Mutex
+}
+
+func (c *datagramConn) Read(p []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+
+	return proto.ReadDatagram(c.Conn, p)
+}
+
+func (c *datagramConn) Write(p []byte) (int, error) {
+	if err := proto.WriteDatagram(c.Conn, p); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
 // Pinger wraps a connection to the flyctl agent over which ICMP
 // requests and replies are written. There's a simple protocol
 // for encapsulating requests and responses; drive it with the Pinger
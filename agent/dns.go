@@ -0,0 +1,206 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// PrivateDNSZones are the zones of Fly private networking.
+var PrivateDNSZones = []string{"internal.", "flycast."}
+
+const (
+	dnsQueryTimeout = 5 * time.Second
+	// Answers without records, such as NXDOMAIN, are cached for negativeDNSCacheTTL.
+	negativeDNSCacheTTL = 5 * time.Second
+	maxDNSCacheTTL      = 5 * time.Minute
+	// Expired entries are pruned once the cache holds maxDNSCacheEntries.
+	maxDNSCacheEntries = 4096
+)
+
+// DNSForwarder is a dns.Handler answering queries for Fly private zones by forwarding them
+// to the nameserver of an organization's private network through the agent's tunnel. Other
+// queries are refused.
+type DNSForwarder struct {
+	Dialer Dialer
+	// Zones defaults to PrivateDNSZones.
+	Zones []string
+
+	mu    sync.Mutex
+	cache map[dnsCacheKey]*dnsCacheEntry
+	now   func() time.Time
+}
+
+type dnsCacheKey struct {
+	name   string
+	qtype  uint16
+	qclass uint16
+}
+
+type dnsCacheEntry struct {
+	msg      *dns.Msg
+	cachedAt time.Time
+	expires  time.Time
+}
+
+// ServeDNS implements dns.Handler.
+func (f *DNSForwarder) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	res, err := f.answer(req)
+	if err != nil {
+		res = new(dns.Msg).SetRcode(req, dns.RcodeServerFailure)
+	}
+
+	// Answers forwarded over TCP may not fit the UDP payload size the client can take
+	if _, ok := w.RemoteAddr().(*net.UDPAddr); ok {
+		size := dns.MinMsgSize
+		if opt := req.IsEdns0(); opt != nil {
+			size = max(size, int(opt.UDPSize()))
+		}
+		res.Truncate(size)
+	}
+
+	_ = w.WriteMsg(res)
+}
+
+func (f *DNSForwarder) answer(req *dns.Msg) (*dns.Msg, error) {
+	if len(req.Question) != 1 || !f.inZones(req.Question[0].Name) {
+		return new(dns.Msg).SetRcode(req, dns.RcodeRefused), nil
+	}
+
+	q := req.Question[0]
+	key := dnsCacheKey{name: strings.ToLower(q.Name), qtype: q.Qtype, qclass: q.Qclass}
+	if res := f.cached(key); res != nil {
+		res.Id = req.Id
+
+		return res, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsQueryTimeout)
+	defer cancel()
+
+	res, err := f.exchange(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	f.store(key, res)
+
+	return res, nil
+}
+
+func (f *DNSForwarder) inZones(name string) bool {
+	zones := f.Zones
+	if len(zones) == 0 {
+		zones = PrivateDNSZones
+	}
+
+	for _, zone := range zones {
+		if dns.IsSubDomain(zone, dns.Fqdn(name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exchange forwards req to the private network's nameserver over TCP, the only transport
+// dialing through the agent supports.
+func (f *DNSForwarder) exchange(ctx context.Context, req *dns.Msg) (*dns.Msg, error) {
+	nameserver := net.JoinHostPort(f.Dialer.Config().DNS.String(), "53")
+
+	conn, err := f.Dialer.DialContext(ctx, "tcp", nameserver)
+	if err != nil {
+		return nil, fmt.Errorf("failed dialing nameserver %s: %w", nameserver, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	dnsConn := &dns.Conn{Conn: conn}
+	if err := dnsConn.WriteMsg(req); err != nil {
+		return nil, err
+	}
+
+	return dnsConn.ReadMsg()
+}
+
+func (f *DNSForwarder) clock() time.Time {
+	if f.now != nil {
+		return f.now()
+	}
+
+	return time.Now()
+}
+
+// cached returns a copy of the cached answer to the question, with its TTLs decremented by
+// the time it spent in the cache.
+func (f *DNSForwarder) cached(key dnsCacheKey) *dns.Msg {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry := f.cache[key]
+	if entry == nil {
+		return nil
+	}
+
+	now := f.clock()
+	if !now.Before(entry.expires) {
+		delete(f.cache, key)
+
+		return nil
+	}
+
+	res := entry.msg.Copy()
+	elapsed := uint32(now.Sub(entry.cachedAt) / time.Second)
+	for _, section := range [][]dns.RR{res.Answer, res.Ns, res.Extra} {
+		for _, rr := range section {
+			if hdr := rr.Header(); hdr.Rrtype != dns.TypeOPT {
+				hdr.Ttl -= min(hdr.Ttl, elapsed)
+			}
+		}
+	}
+
+	return res
+}
+
+// store caches successful and NXDOMAIN answers for as long as their shortest TTL.
+func (f *DNSForwarder) store(key dnsCacheKey, res *dns.Msg) {
+	if res.Rcode != dns.RcodeSuccess && res.Rcode != dns.RcodeNameError {
+		return
+	}
+
+	ttl := maxDNSCacheTTL
+	records := append(append([]dns.RR{}, res.Answer...), res.Ns...)
+	for _, rr := range records {
+		ttl = min(ttl, time.Duration(rr.Header().Ttl)*time.Second)
+	}
+	if len(records) == 0 {
+		ttl = negativeDNSCacheTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cache == nil {
+		f.cache = map[dnsCacheKey]*dnsCacheEntry{}
+	}
+
+	now := f.clock()
+	if len(f.cache) >= maxDNSCacheEntries {
+		for key, entry := range f.cache {
+			if !now.Before(entry.expires) {
+				delete(f.cache, key)
+			}
+		}
+	}
+	f.cache[key] = &dnsCacheEntry{msg: res.Copy(), cachedAt: now, expires: now.Add(ttl)}
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/wg"
+)
+
+// nameserverDialer dials a local nameserver whatever address it's asked for.
+type nameserverDialer struct {
+	addr string
+}
+
+func (d *nameserverDialer) State() *wg.WireGuardState { return nil }
+
+func (d *nameserverDialer) Config() *wg.Config {
+	return &wg.Config{DNS: net.ParseIP("fdaa::3")}
+}
+
+func (d *nameserverDialer) DialContext(ctx context.Context, network, _ string) (net.Conn, error) {
+	return (&net.Dialer{}).DialContext(ctx, network, d.addr)
+}
+
+// startNameserver serves TXT records for regions.my-app.internal and many.my-app.internal,
+// too many of them to fit a small UDP response, and counts the queries it answers.
+func startNameserver(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+
+	var queries atomic.Int32
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		queries.Add(1)
+
+		res := new(dns.Msg).SetReply(req)
+		if req.Question[0].Name == "regions.my-app.internal." {
+			rr, err := dns.NewRR(`regions.my-app.internal. 60 IN TXT "ams,ord"`)
+			require.NoError(t, err)
+			res.Answer = append(res.Answer, rr)
+		} else if req.Question[0].Name == "many.my-app.internal." {
+			for i := range 100 {
+				rr, err := dns.NewRR(fmt.Sprintf(`many.my-app.internal. 60 IN TXT "record %d"`, i))
+				require.NoError(t, err)
+				res.Answer = append(res.Answer, rr)
+			}
+		} else {
+			res.Rcode = dns.RcodeNameError
+		}
+		w.WriteMsg(res)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &dns.Server{Listener: listener, Handler: handler}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	return listener.Addr().String(), &queries
+}
+
+func TestDNSForwarder(t *testing.T) {
+	addr, queries := startNameserver(t)
+
+	now := time.Now()
+	f := &DNSForwarder{
+		Dialer: &nameserverDialer{addr: addr},
+		now:    func() time.Time { return now },
+	}
+
+	query := func(name string, qtype uint16) *dns.Msg {
+		res, err := f.answer(new(dns.Msg).SetQuestion(name, qtype))
+		require.NoError(t, err)
+
+		return res
+	}
+
+	res := query("regions.my-app.internal.", dns.TypeTXT)
+	require.Len(t, res.Answer, 1)
+	assert.Equal(t, []string{"ams,ord"}, res.Answer[0].(*dns.TXT).Txt)
+	assert.EqualValues(t, 60, res.Answer[0].Header().Ttl)
+	assert.EqualValues(t, 1, queries.Load())
+
+	// Cached answers count down their TTL
+	now = now.Add(20 * time.Second)
+	res = query("Regions.My-App.internal.", dns.TypeTXT)
+	require.Len(t, res.Answer, 1)
+	assert.EqualValues(t, 40, res.Answer[0].Header().Ttl)
+	assert.EqualValues(t, 1, queries.Load())
+
+	now = now.Add(time.Minute)
+	query("regions.my-app.internal.", dns.TypeTXT)
+	assert.EqualValues(t, 2, queries.Load())
+
+	res = query("nope.flycast.", dns.TypeAAAA)
+	assert.Equal(t, dns.RcodeNameError, res.Rcode)
+	query("nope.flycast.", dns.TypeAAAA)
+	assert.EqualValues(t, 3, queries.Load())
+
+	res = query("example.com.", dns.TypeA)
+	assert.Equal(t, dns.RcodeRefused, res.Rcode)
+	assert.EqualValues(t, 3, queries.Load())
+}
+
+func TestDNSForwarderTruncatesUDPAnswers(t *testing.T) {
+	addr, _ := startNameserver(t)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &dns.Server{PacketConn: conn, Handler: &DNSForwarder{Dialer: &nameserverDialer{addr: addr}}}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	query := func(req *dns.Msg) *dns.Msg {
+		res, _, err := (&dns.Client{Net: "udp", UDPSize: 65535}).Exchange(req, conn.LocalAddr().String())
+		require.NoError(t, err)
+
+		return res
+	}
+
+	res := query(new(dns.Msg).SetQuestion("many.my-app.internal.", dns.TypeTXT))
+	assert.True(t, res.Truncated)
+	assert.Less(t, len(res.Answer), 100)
+	res.Compress = true
+	assert.LessOrEqual(t, res.Len(), dns.MinMsgSize)
+
+	req := new(dns.Msg).SetQuestion("many.my-app.internal.", dns.TypeTXT)
+	req.SetEdns0(4096, false)
+	res = query(req)
+	assert.False(t, res.Truncated)
+	assert.Len(t, res.Answer, 100)
+}
@@ -3,7 +3,9 @@ package proto
 
 import (
 	"encoding/binary"
+	"errors"
 	"io"
+	"math"
 )
 
 func Read(r io.Reader) (data []byte, err error) {
@@ -46,3 +48,44 @@ func Write(w io.Writer, verb string, args ...string) (err error) {
 
 	return
 }
+
+// MaxDatagramSize is the size of the largest datagram WriteDatagram frames.
+const MaxDatagramSize = math.MaxUint16
+
+var ErrDatagramTooLarge = errors.New("datagram too large")
+
+// WriteDatagram writes p prefixed with its length in network byte order, so datagrams
+// keep their boundaries over the agent's stream connections.
+func WriteDatagram(w io.Writer, p []byte) (err error) {
+	if len(p) > MaxDatagramSize {
+		return ErrDatagramTooLarge
+	}
+
+	buf := make([]byte, 2+len(p))
+	binary.BigEndian.PutUint16(buf, uint16(len(p)))
+	copy(buf[2:], p)
+
+	_, err = w.Write(buf)
+
+	return
+}
+
+// ReadDatagram reads a datagram written by WriteDatagram into p and returns its length.
+// Like reads from UDP sockets, datagrams longer than p are truncated.
+func ReadDatagram(r io.Reader, p []byte) (n int, err error) {
+	var b [2]byte
+	if _, err = io.ReadFull(r, b[:]); err != nil {
+		return
+	}
+	l := int(binary.BigEndian.Uint16(b[:]))
+
+	n = min(l, len(p))
+	if _, err = io.ReadFull(r, p[:n]); err != nil {
+		return 0, err
+	}
+	if _, err = io.CopyN(io.Discard, r, int64(l-n)); err != nil {
+		return 0, err
+	}
+
+	return
+}
@@ -0,0 +1,35 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatagrams(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteDatagram(&buf, []byte("hello")))
+	require.NoError(t, WriteDatagram(&buf, []byte("truncated")))
+	require.NoError(t, WriteDatagram(&buf, nil))
+	assert.ErrorIs(t, WriteDatagram(&buf, make([]byte, MaxDatagramSize+1)), ErrDatagramTooLarge)
+
+	p := make([]byte, 5)
+
+	n, err := ReadDatagram(&buf, p)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(p[:n]))
+
+	n, err = ReadDatagram(&buf, p)
+	require.NoError(t, err)
+	assert.Equal(t, "trunc", string(p[:n]))
+
+	n, err = ReadDatagram(&buf, p)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	_, err = ReadDatagram(&buf, p)
+	assert.ErrorIs(t, err, io.EOF)
+}
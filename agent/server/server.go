@@ -1,12 +1,14 @@
 package server
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -66,6 +68,7 @@ func Run(ctx context.Context, opt Options) (err error) {
 		runCtx:                ctx,
 		currentChange:         latestChangeAt,
 		tunnels:               make(map[tunnelKey]*wg.Tunnel),
+		dials:                 make(map[tunnelKey]int),
 		tokens:                toks,
 		cancelTokenMonitoring: cancelMonitor,
 	}).serve(ctx, l)
@@ -128,6 +131,7 @@ type server struct {
 	mu                    sync.Mutex
 	currentChange         time.Time
 	tunnels               map[tunnelKey]*wg.Tunnel
+	dials                 map[tunnelKey]int
 	tokens                *tokens.Tokens
 	cancelTokenMonitoring func()
 }
@@ -246,9 +250,9 @@ func (s *server) buildTunnel(ctx context.Context, org *fly.Organization, reestab
 	}
 
 	// WIP: can't stay this way, need something more clever than this
-	transport := "udp"
+	transport := wg.TransportUDP
 	if env.IsCI() || os.Getenv("WSWG") != "" || s.ConfigWebsockets {
-		transport = "websocket"
+		transport = wg.TransportWebsocket
 
 		if tunnel, err = wg.ConnectWS(ctx, state); err != nil {
 			return
@@ -323,6 +327,63 @@ func (s *server) tunnelFor(slug, network string) *wg.Tunnel {
 	return s.tunnels[tk]
 }
 
+// trackDial counts a connection proxied through the tunnel until the returned function
+// is called.
+func (s *server) trackDial(slug, network string) (done func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tk := tunnelKey{orgSlug: slug, networkName: network}
+	s.dials[tk]++
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.dials[tk]--; s.dials[tk] <= 0 {
+			delete(s.dials, tk)
+		}
+	}
+}
+
+// status reports every tunnel of the agent, sorted by org and network.
+func (s *server) status() *agent.StatusResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := &agent.StatusResponse{Tunnels: []agent.TunnelStatus{}}
+	for tk, tunnel := range s.tunnels {
+		ts := agent.TunnelStatus{
+			Org:         tk.orgSlug,
+			Network:     tk.networkName,
+			ActiveDials: s.dials[tk],
+		}
+		if state := tunnel.State; state != nil {
+			ts.Peer = state.Name
+			ts.PeerIP = state.Peer.Peerip
+			ts.Region = state.Region
+		}
+
+		if stats, err := tunnel.Stats(); err != nil {
+			ts.Error = err.Error()
+		} else {
+			ts.Endpoint = stats.Endpoint
+			ts.Transport = stats.Transport
+			ts.LastHandshake = stats.LastHandshake
+			ts.RxBytes = stats.RxBytes
+			ts.TxBytes = stats.TxBytes
+		}
+
+		res.Tunnels = append(res.Tunnels, ts)
+	}
+
+	slices.SortFunc(res.Tunnels, func(a, b agent.TunnelStatus) int {
+		return cmp.Or(strings.Compare(a.Org, b.Org), strings.Compare(a.Network, b.Network))
+	})
+
+	return res
+}
+
 func (s *server) probeTunnel(ctx context.Context, slug, network string) (err error) {
 	tunnel := s.tunnelFor(slug, network)
 	if tunnel == nil {
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/wg"
+)
+
+func TestStatus(t *testing.T) {
+	s := &server{
+		tunnels: map[tunnelKey]*wg.Tunnel{
+			{orgSlug: "personal", networkName: "staging"}: {State: &wg.WireGuardState{Name: "peer-b", Region: "ams"}},
+			{orgSlug: "personal"}:                         {State: &wg.WireGuardState{Name: "peer-a", Region: "iad", Peer: fly.CreatedWireGuardPeer{Peerip: "fdaa::2"}}},
+		},
+		dials: map[tunnelKey]int{},
+	}
+
+	done := s.trackDial("personal", "")
+	s.trackDial("personal", "")()
+
+	res := s.status()
+	require.Len(t, res.Tunnels, 2)
+
+	tunnel := res.Tunnels[0]
+	assert.Equal(t, "personal", tunnel.Org)
+	assert.Equal(t, "", tunnel.Network)
+	assert.Equal(t, "peer-a", tunnel.Peer)
+	assert.Equal(t, "fdaa::2", tunnel.PeerIP)
+	assert.Equal(t, "iad", tunnel.Region)
+	assert.Equal(t, 1, tunnel.ActiveDials)
+	// These tunnels were never connected, so they have no device to read statistics from
+	assert.Equal(t, "tunnel is closed", tunnel.Error)
+
+	assert.Equal(t, "staging", res.Tunnels[1].Network)
+	assert.Equal(t, 0, res.Tunnels[1].ActiveDials)
+
+	done()
+	assert.Empty(t, s.dials)
+}
@@ -114,6 +114,8 @@ func (s *session) runCommand(ctx context.Context) {
 		handler = (*session).reestablish
 	case "connect":
 		handler = (*session).connect
+	case "connectudp":
+		handler = (*session).connectUDP
 	case "probe":
 		handler = (*session).probe
 	case "instances":
@@ -126,6 +128,8 @@ func (s *session) runCommand(ctx context.Context) {
 		handler = (*session).ping6
 	case "set-token":
 		handler = (*session).setToken
+	case "status":
+		handler = (*session).status
 	default:
 		s.error(errUnsupportedCommand)
 
@@ -368,6 +372,16 @@ var (
 )
 
 func (s *session) connect(ctx context.Context, args ...string) {
+	s.doConnect(ctx, "tcp", args...)
+}
+
+// connectUDP is connect for UDP: datagrams are relayed as frames written with
+// proto.WriteDatagram in both directions.
+func (s *session) connectUDP(ctx context.Context, args ...string) {
+	s.doConnect(ctx, "udp", args...)
+}
+
+func (s *session) doConnect(ctx context.Context, network string, args ...string) {
 	if !s.exactArgs(4, args, errMalformedConnect) {
 		return
 	}
@@ -385,6 +399,7 @@ func (s *session) connect(ctx context.Context, args ...string) {
 
 		return
 	}
+	defer s.srv.trackDial(args[0], args[3])()
 
 	var dialContext context.Context
 	var cancel context.CancelFunc
@@ -395,7 +410,7 @@ func (s *session) connect(ctx context.Context, args ...string) {
 	}
 	defer cancel()
 
-	outconn, err := tunnel.DialContext(dialContext, "tcp", args[1])
+	outconn, err := tunnel.DialContext(dialContext, network, args[1])
 	if err != nil {
 		s.error(err)
 
@@ -422,6 +437,38 @@ func (s *session) connect(ctx context.Context, args ...string) {
 		return errDone
 	})
 
+	if network == "udp" {
+		eg.Go(func() error {
+			buf := make([]byte, proto.MaxDatagramSize)
+			for {
+				n, err := outconn.Read(buf)
+				if err != nil {
+					return err
+				}
+				if err := proto.WriteDatagram(s.conn, buf[:n]); err != nil {
+					return err
+				}
+			}
+		})
+
+		eg.Go(func() error {
+			buf := make([]byte, proto.MaxDatagramSize)
+			for {
+				n, err := proto.ReadDatagram(s.conn, buf)
+				if err != nil {
+					return err
+				}
+				if _, err := outconn.Write(buf[:n]); err != nil {
+					return err
+				}
+			}
+		})
+
+		_ = eg.Wait()
+
+		return
+	}
+
 	eg.Go(func() (err error) {
 		if _, err = io.Copy(s.conn, outconn); err == nil {
 			err = io.EOF
@@ -558,6 +605,16 @@ func (s *session) ping6(ctx context.Context, args ...string) {
 	}
 }
 
+var errMalformedStatus = errors.New("malformed status command")
+
+func (s *session) status(_ context.Context, args ...string) {
+	if !s.noArgs(args, errMalformedStatus) {
+		return
+	}
+
+	_ = s.marshal(s.srv.status())
+}
+
 var errMalformedSetToken = errors.New("malformed set-token command")
 
 // setToken instructs the agent which tokens to use for API calls.
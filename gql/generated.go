@@ -1254,6 +1254,70 @@ type FlyctlConfigCurrentReleaseResponse struct {
 // GetApp returns FlyctlConfigCurrentReleaseResponse.App, and is useful for accessing the field via an interface.
 func (v *FlyctlConfigCurrentReleaseResponse) GetApp() FlyctlConfigCurrentReleaseApp { return v.App }
 
+// FlyctlConfigReleasesApp includes the requested fields of the GraphQL type App.
+type FlyctlConfigReleasesApp struct {
+	// Individual releases for this application, without any config processing
+	ReleasesUnprocessed FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnection `json:"releasesUnprocessed"`
+	// Find a specific release
+	Release FlyctlConfigReleasesAppRelease `json:"release"`
+}
+
+// GetReleasesUnprocessed returns FlyctlConfigReleasesApp.ReleasesUnprocessed, and is useful for accessing the field via an interface.
+func (v *FlyctlConfigReleasesApp) GetReleasesUnprocessed() FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnection {
+	return v.ReleasesUnprocessed
+}
+
+// GetRelease returns FlyctlConfigReleasesApp.Release, and is useful for accessing the field via an interface.
+func (v *FlyctlConfigReleasesApp) GetRelease() FlyctlConfigReleasesAppRelease { return v.Release }
+
+// FlyctlConfigReleasesAppRelease includes the requested fields of the GraphQL type Release.
+type FlyctlConfigReleasesAppRelease struct {
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// GetMetadata returns FlyctlConfigReleasesAppRelease.Metadata, and is useful for accessing the field via an interface.
+func (v *FlyctlConfigReleasesAppRelease) GetMetadata() json.RawMessage { return v.Metadata }
+
+// FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnection includes the requested fields of the GraphQL type ReleaseUnprocessedConnection.
+// The GraphQL type's documentation follows.
+//
+// The connection type for ReleaseUnprocessed.
+type FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnection struct {
+	// A list of nodes.
+	Nodes []FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed `json:"nodes"`
+}
+
+// GetNodes returns FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnection.Nodes, and is useful for accessing the field via an interface.
+func (v *FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnection) GetNodes() []FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed {
+	return v.Nodes
+}
+
+// FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed includes the requested fields of the GraphQL type ReleaseUnprocessed.
+type FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed struct {
+	// The version of the release
+	Version          int         `json:"version"`
+	ConfigDefinition interface{} `json:"configDefinition"`
+}
+
+// GetVersion returns FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.Version, and is useful for accessing the field via an interface.
+func (v *FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetVersion() int {
+	return v.Version
+}
+
+// GetConfigDefinition returns FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.ConfigDefinition, and is useful for accessing the field via an interface.
+func (v *FlyctlConfigReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetConfigDefinition() interface{} {
+	return v.ConfigDefinition
+}
+
+// FlyctlConfigReleasesResponse is returned by FlyctlConfigReleases on success.
+type FlyctlConfigReleasesResponse struct {
+	// Find an app by name
+	App FlyctlConfigReleasesApp `json:"app"`
+}
+
+// GetApp returns FlyctlConfigReleasesResponse.App, and is useful for accessing the field via an interface.
+func (v *FlyctlConfigReleasesResponse) GetApp() FlyctlConfigReleasesApp { return v.App }
+
 // GetAddOnAddOn includes the requested fields of the GraphQL type AddOn.
 type GetAddOnAddOn struct {
 	AddOnData `json:"-"`
@@ -3247,6 +3311,22 @@ type __FlyctlConfigCurrentReleaseInput struct {
 // GetAppName returns __FlyctlConfigCurrentReleaseInput.AppName, and is useful for accessing the field via an interface.
 func (v *__FlyctlConfigCurrentReleaseInput) GetAppName() string { return v.AppName }
 
+// __FlyctlConfigReleasesInput is used internally by genqlient
+type __FlyctlConfigReleasesInput struct {
+	AppName string `json:"appName"`
+	Limit   int    `json:"limit"`
+	Version int    `json:"version"`
+}
+
+// GetAppName returns __FlyctlConfigReleasesInput.AppName, and is useful for accessing the field via an interface.
+func (v *__FlyctlConfigReleasesInput) GetAppName() string { return v.AppName }
+
+// GetLimit returns __FlyctlConfigReleasesInput.Limit, and is useful for accessing the field via an interface.
+func (v *__FlyctlConfigReleasesInput) GetLimit() int { return v.Limit }
+
+// GetVersion returns __FlyctlConfigReleasesInput.Version, and is useful for accessing the field via an interface.
+func (v *__FlyctlConfigReleasesInput) GetVersion() int { return v.Version }
+
 // __GetAddOnInput is used internally by genqlient
 type __GetAddOnInput struct {
 	Name     string `json:"name"`
@@ -3827,6 +3907,52 @@ func FlyctlConfigCurrentRelease(
 	return data_, err_
 }
 
+// The query executed by FlyctlConfigReleases.
+const FlyctlConfigReleases_Operation = `
+query FlyctlConfigReleases ($appName: String!, $limit: Int!, $version: Int!) {
+	app(name: $appName) {
+		releasesUnprocessed(first: $limit) {
+			nodes {
+				version
+				configDefinition
+			}
+		}
+		release(version: $version) {
+			metadata
+		}
+	}
+}
+`
+
+func FlyctlConfigReleases(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	appName string,
+	limit int,
+	version int,
+) (data_ *FlyctlConfigReleasesResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName: "FlyctlConfigReleases",
+		Query:  FlyctlConfigReleases_Operation,
+		Variables: &__FlyctlConfigReleasesInput{
+			AppName: appName,
+			Limit:   limit,
+			Version: version,
+		},
+	}
+
+	data_ = &FlyctlConfigReleasesResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
+
 // The query executed by GetAddOn.
 const GetAddOn_Operation = `
 query GetAddOn ($name: String, $provider: String) {
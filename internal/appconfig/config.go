@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"slices"
+	"time"
 
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/internal/dockerfileurl"
@@ -101,6 +102,9 @@ type Config struct {
 
 	// The default group name to refer to (used with flatten configs)
 	defaultGroupName string
+
+	// The environment whose overlay was merged over the config file, if any
+	environment string
 }
 
 type Metrics struct {
@@ -116,6 +120,124 @@ type Deploy struct {
 	ReleaseCommandTimeout *fly.Duration `toml:"release_command_timeout,omitempty" json:"release_command_timeout,omitempty"`
 	ReleaseCommandCompute *Compute      `toml:"release_command_vm,omitempty" json:"release_command_vm,omitempty"`
 	SeedCommand           string        `toml:"seed_command,omitempty" json:"seed_command,omitempty"`
+	Progressive           *Progressive  `toml:"progressive,omitempty" json:"progressive,omitempty"`
+	Hooks                 []DeployHook  `toml:"hooks,omitempty" json:"hooks,omitempty"`
+}
+
+// Deploy hook phases, in the order they happen during a deploy.
+const (
+	DeployHookPreBuild   = "pre-build"
+	DeployHookPostBuild  = "post-build"
+	DeployHookPreRelease = "pre-release"
+	DeployHookPostDeploy = "post-deploy"
+	DeployHookOnFailure  = "on-failure"
+)
+
+var DeployHookPhases = []string{DeployHookPreBuild, DeployHookPostBuild, DeployHookPreRelease, DeployHookPostDeploy, DeployHookOnFailure}
+
+// What a deploy does when one of its hooks fails.
+const (
+	DeployHookFailureAbort    = "abort"
+	DeployHookFailureContinue = "continue"
+	DeployHookFailureRollback = "rollback"
+)
+
+// DeployHook runs a command at a phase of a deploy, either where flyctl runs or on an
+// ephemeral machine running the app's image.
+type DeployHook struct {
+	Name  string `toml:"name,omitempty" json:"name,omitempty"`
+	Phase string `toml:"phase,omitempty" json:"phase,omitempty"`
+	// Command is run by the local shell, only when the config was read from a local file.
+	Command string `toml:"command,omitempty" json:"command,omitempty"`
+	// MachineCommand is run on an ephemeral machine. There's no image to run it on before the build.
+	MachineCommand string `toml:"machine_command,omitempty" json:"machine_command,omitempty"`
+	// OnFailure is "abort", the default, "continue" or, for post-deploy hooks, "rollback".
+	OnFailure string        `toml:"on_failure,omitempty" json:"on_failure,omitempty"`
+	Timeout   *fly.Duration `toml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// DisplayName returns the hook's name, or its command when it has none.
+func (h DeployHook) DisplayName() string {
+	switch {
+	case h.Name != "":
+		return h.Name
+	case h.Command != "":
+		return h.Command
+	default:
+		return h.MachineCommand
+	}
+}
+
+// FailureAction returns what to do when the hook fails.
+func (h DeployHook) FailureAction() string {
+	if h.OnFailure == "" {
+		return DeployHookFailureAbort
+	}
+
+	return h.OnFailure
+}
+
+// HooksFor returns the hooks of a phase, in the order they're configured.
+func (d *Deploy) HooksFor(phase string) []DeployHook {
+	if d == nil {
+		return nil
+	}
+
+	var hooks []DeployHook
+	for _, h := range d.Hooks {
+		if h.Phase == phase {
+			hooks = append(hooks, h)
+		}
+	}
+
+	return hooks
+}
+
+// DefaultProgressiveSteps are the cumulative percentages of each process group
+// updated by the progressive strategy when [deploy.progressive] sets no steps.
+var DefaultProgressiveSteps = []int{10, 25, 50, 100}
+
+// Progressive configures the "progressive" deployment strategy, which updates a
+// growing fraction of each process group and gates every step on machine health.
+type Progressive struct {
+	// Steps are cumulative percentages of machines to update per process group.
+	Steps []int `toml:"steps,omitempty" json:"steps,omitempty"`
+	// Pause is how long to wait after each step before checking health again.
+	Pause *fly.Duration `toml:"pause,omitempty" json:"pause,omitempty"`
+	// AutoRollback reverts updated machines when a step fails. Defaults to true.
+	AutoRollback *bool `toml:"auto_rollback,omitempty" json:"auto_rollback,omitempty"`
+}
+
+// ProgressiveSteps returns the configured steps, always ending at 100%.
+func (d *Deploy) ProgressiveSteps() []int {
+	if d == nil || d.Progressive == nil || len(d.Progressive.Steps) == 0 {
+		return DefaultProgressiveSteps
+	}
+
+	steps := d.Progressive.Steps
+	if steps[len(steps)-1] != 100 {
+		steps = append(slices.Clone(steps), 100)
+	}
+
+	return steps
+}
+
+// ProgressivePause returns the pause between progressive steps.
+func (d *Deploy) ProgressivePause() time.Duration {
+	if d == nil || d.Progressive == nil || d.Progressive.Pause == nil {
+		return 0
+	}
+
+	return d.Progressive.Pause.Duration
+}
+
+// ProgressiveAutoRollback reports whether a failed progressive step reverts updated machines.
+func (d *Deploy) ProgressiveAutoRollback() bool {
+	if d == nil || d.Progressive == nil || d.Progressive.AutoRollback == nil {
+		return true
+	}
+
+	return *d.Progressive.AutoRollback
 }
 
 type File struct {
@@ -184,6 +306,17 @@ type Build struct {
 	Compose           *BuildCompose     `toml:"compose,omitempty" json:"compose,omitempty"`
 	Compression       string            `toml:"compression,omitempty" json:"compression,omitempty"`
 	CompressionLevel  *int              `toml:"compression_level,omitempty" json:"compression_level,omitempty"`
+	// Processes are the process groups built into images of their own, from their own
+	// Dockerfile or build target.
+	Processes map[string]*ProcessBuild `toml:"processes,omitempty" json:"processes,omitempty"`
+}
+
+// ProcessBuild is how the image of a process group differs from the app's image. Its
+// args are added to the app's build args.
+type ProcessBuild struct {
+	Dockerfile        string            `toml:"dockerfile,omitempty" json:"dockerfile,omitempty"`
+	DockerBuildTarget string            `toml:"build-target,omitempty" json:"build-target,omitempty"`
+	Args              map[string]string `toml:"args,omitempty" json:"args,omitempty"`
 }
 
 type Experimental struct {
@@ -226,6 +359,17 @@ func (c *Config) SetConfigFilePath(configFilePath string) {
 	c.configFilePath = configFilePath
 }
 
+// IsLocal reports whether the config was read from a local file, rather than fetched from
+// the platform, as the configs of releases are, or built in memory.
+func (c *Config) IsLocal() bool {
+	switch c.configFilePath {
+	case "", "--config path unset--", "--flatten--":
+		return false
+	default:
+		return true
+	}
+}
+
 func (c *Config) DetermineIPType(ipType string) string {
 	// If the app is a flycast app, then it requires a private IP
 	if ipType == "private" {
@@ -2,8 +2,10 @@ package appconfig
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/helpers"
 )
@@ -41,6 +43,40 @@ func TestConfigDockerGetters(t *testing.T) {
 	assert.Equal(t, nilCfg.DockerBuildTarget(), "")
 }
 
+func TestDeployProgressiveGetters(t *testing.T) {
+	var nilDeploy *Deploy
+	assert.Equal(t, DefaultProgressiveSteps, nilDeploy.ProgressiveSteps())
+	assert.Equal(t, time.Duration(0), nilDeploy.ProgressivePause())
+	assert.True(t, nilDeploy.ProgressiveAutoRollback())
+
+	d := &Deploy{Progressive: &Progressive{
+		Steps:        []int{20, 60},
+		Pause:        fly.MustParseDuration("1m"),
+		AutoRollback: new(false),
+	}}
+	assert.Equal(t, []int{20, 60, 100}, d.ProgressiveSteps())
+	assert.Equal(t, []int{20, 60}, d.Progressive.Steps)
+	assert.Equal(t, time.Minute, d.ProgressivePause())
+	assert.False(t, d.ProgressiveAutoRollback())
+}
+
+func TestDeployHooksFor(t *testing.T) {
+	var nilDeploy *Deploy
+	assert.Empty(t, nilDeploy.HooksFor(DeployHookPreBuild))
+
+	d := &Deploy{Hooks: []DeployHook{
+		{Name: "assets", Phase: DeployHookPreBuild, Command: "make assets"},
+		{Phase: DeployHookPostDeploy, Command: "notify"},
+		{Phase: DeployHookPreBuild, MachineCommand: "lint", OnFailure: DeployHookFailureContinue},
+	}}
+	hooks := d.HooksFor(DeployHookPreBuild)
+	require.Len(t, hooks, 2)
+	assert.Equal(t, "assets", hooks[0].DisplayName())
+	assert.Equal(t, DeployHookFailureAbort, hooks[0].FailureAction())
+	assert.Equal(t, "lint", hooks[1].DisplayName())
+	assert.Equal(t, DeployHookFailureContinue, hooks[1].FailureAction())
+}
+
 func TestNilBuildStrategy(t *testing.T) {
 	var nilCfg *Config
 	assert.Equal(t, 0, len(nilCfg.BuildStrategies()))
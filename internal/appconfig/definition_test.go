@@ -265,6 +265,24 @@ func TestToDefinition(t *testing.T) {
 				"size":   "performance-2x",
 				"memory": "8g",
 			},
+			"progressive": map[string]any{
+				"steps":         []any{int64(10), int64(50)},
+				"pause":         "30s",
+				"auto_rollback": false,
+			},
+			"hooks": []any{
+				map[string]any{
+					"name":       "purge cache",
+					"phase":      "post-deploy",
+					"command":    "./bin/purge-cache",
+					"on_failure": "continue",
+				},
+				map[string]any{
+					"phase":           "pre-release",
+					"machine_command": "bin/check-migrations",
+					"timeout":         "2m0s",
+				},
+			},
 		},
 		"env": map[string]any{
 			"FOO": "BAR",
@@ -0,0 +1,212 @@
+package appconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// environmentsKey is the config section holding the environment overlays defined inline,
+// as in [environments.staging].
+const environmentsKey = "environments"
+
+// LoadConfigForEnvironment loads the app config at the given path with the overlay of the
+// named environment merged over it. An environment is defined either in an
+// [environments.<name>] section of the config or in an overlay file next to it, named after
+// the config with the environment inserted before the extension: fly.staging.toml for
+// fly.toml.
+//
+// Overlays are deep-merged over the base config: tables are merged key by key, arrays of
+// tables (services, vm, mounts...) element by element, and any other value replaces the
+// base one. With no environment, it's the same as LoadConfig.
+func LoadConfigForEnvironment(path, environment string) (*Config, error) {
+	if environment == "" {
+		return LoadConfig(path)
+	}
+
+	cfgMap, err := readConfigMap(path)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay, err := environmentOverlay(path, cfgMap, environment)
+	if err != nil {
+		return nil, err
+	}
+	delete(cfgMap, environmentsKey)
+	cfgMap = mergeConfigMaps(cfgMap, overlay)
+
+	name, _ := cfgMap["app"].(string)
+	cfg, err := applyPatches(cfgMap)
+	// In case of parsing error fallback to bare compatibility, as LoadConfig does
+	if err != nil {
+		cfg = &Config{v2UnmarshalError: err, AppName: name}
+	}
+
+	cfg.configFilePath = path
+	cfg.environment = environment
+
+	return cfg, nil
+}
+
+// Environment returns the name of the environment whose overlay was merged into the config,
+// if any.
+func (c *Config) Environment() string {
+	return c.environment
+}
+
+// ConfigEnvironments returns the names of the environments defined for the app config at the
+// given path, from its [environments] section and its overlay files.
+func ConfigEnvironments(path string) ([]string, error) {
+	cfgMap, err := readConfigMap(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if inline, ok := cfgMap[environmentsKey].(map[string]any); ok {
+		for name := range inline {
+			names = append(names, name)
+		}
+	}
+
+	dir, file := filepath.Split(path)
+	ext := filepath.Ext(file)
+	prefix := strings.TrimSuffix(file, ext) + "."
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		overlay := entry.Name()
+		if entry.IsDir() || overlay == file || !strings.HasPrefix(overlay, prefix) || !strings.HasSuffix(overlay, ext) {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(overlay, prefix), ext)
+		// fly.workspace.toml lists the apps of a workspace, it isn't an overlay
+		if name == "" || strings.Contains(name, ".") || name == "workspace" {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return slices.Compact(names), nil
+}
+
+// environmentOverlay returns the overlay of the named environment, either inline in the
+// config or from its overlay file.
+func environmentOverlay(path string, cfgMap map[string]any, environment string) (map[string]any, error) {
+	var inline map[string]any
+	if section, ok := cfgMap[environmentsKey]; ok {
+		environments, ok := section.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("the [%s] section of %s must be a table of environments", environmentsKey, path)
+		}
+		if section, ok := environments[environment]; ok {
+			if inline, ok = section.(map[string]any); !ok {
+				return nil, fmt.Errorf("environment %s of %s must be a table", environment, path)
+			}
+		}
+	}
+
+	ext := filepath.Ext(path)
+	overlayPath := strings.TrimSuffix(path, ext) + "." + environment + ext
+	fromFile, err := readConfigMap(overlayPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if inline == nil {
+			return nil, fmt.Errorf("environment %s isn't defined: add an [%s.%s] section to %s or create %s", environment, environmentsKey, environment, path, overlayPath)
+		}
+
+		return inline, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed loading the overlay of environment %s: %w", environment, err)
+	case inline != nil:
+		return nil, fmt.Errorf("environment %s is defined both in %s and %s, keep one of them", environment, path, overlayPath)
+	}
+
+	if _, ok := fromFile[environmentsKey]; ok {
+		return nil, fmt.Errorf("overlay %s can't define environments of its own", overlayPath)
+	}
+
+	return fromFile, nil
+}
+
+// readConfigMap reads the config at path as a raw map, before any patch is applied.
+func readConfigMap(path string) (map[string]any, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgMap := map[string]any{}
+	switch {
+	case strings.HasSuffix(path, ".json"):
+		err = json.Unmarshal(buf, &cfgMap)
+	case strings.HasSuffix(path, ".yaml"):
+		if err = yaml.Unmarshal(buf, &cfgMap); err == nil {
+			stringifyYAMLMapKeys(cfgMap)
+		}
+	default:
+		err = toml.Unmarshal(buf, &cfgMap)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return cfgMap, nil
+}
+
+// mergeConfigMaps deep-merges overlay over base and returns the result.
+func mergeConfigMaps(base, overlay map[string]any) map[string]any {
+	for key, value := range overlay {
+		base[key] = mergeConfigValues(base[key], value)
+	}
+
+	return base
+}
+
+func mergeConfigValues(base, overlay any) any {
+	switch overlay := overlay.(type) {
+	case map[string]any:
+		if base, ok := base.(map[string]any); ok {
+			return mergeConfigMaps(base, overlay)
+		}
+	case []any:
+		base, ok := base.([]any)
+		if !ok || !isArrayOfTables(base) || !isArrayOfTables(overlay) {
+			break
+		}
+		merged := slices.Clone(base)
+		for i, value := range overlay {
+			if i < len(merged) {
+				merged[i] = mergeConfigValues(merged[i], value)
+			} else {
+				merged = append(merged, value)
+			}
+		}
+
+		return merged
+	}
+
+	return overlay
+}
+
+func isArrayOfTables(values []any) bool {
+	for _, value := range values {
+		if _, ok := value.(map[string]any); !ok {
+			return false
+		}
+	}
+
+	return len(values) > 0
+}
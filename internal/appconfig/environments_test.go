@@ -0,0 +1,105 @@
+package appconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+)
+
+const environmentsBase = `
+app = "acme"
+primary_region = "iad"
+
+[env]
+  LOG_LEVEL = "info"
+  PORT = "8080"
+
+[[vm]]
+  size = "shared-cpu-1x"
+  memory = "512mb"
+
+[[services]]
+  internal_port = 8080
+  protocol = "tcp"
+
+  [[services.ports]]
+    port = 443
+    handlers = ["tls", "http"]
+
+[environments.staging]
+  app = "acme-staging"
+  primary_region = "ams"
+
+  [environments.staging.env]
+    LOG_LEVEL = "debug"
+
+  [[environments.staging.vm]]
+    size = "shared-cpu-2x"
+`
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	return path
+}
+
+func TestLoadConfigForEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, dir, "fly.toml", environmentsBase)
+	writeConfigFile(t, dir, "fly.production.toml", `
+app = "acme-production"
+
+[[services]]
+  internal_port = 9090
+`)
+
+	base, err := LoadConfigForEnvironment(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", base.AppName)
+	assert.Equal(t, "", base.Environment())
+
+	staging, err := LoadConfigForEnvironment(path, "staging")
+	require.NoError(t, err)
+	assert.Equal(t, "staging", staging.Environment())
+	assert.Equal(t, path, staging.ConfigFilePath())
+	assert.Equal(t, "acme-staging", staging.AppName)
+	assert.Equal(t, "ams", staging.PrimaryRegion)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "debug", "PORT": "8080"}, staging.Env)
+	require.Len(t, staging.Compute, 1)
+	assert.Equal(t, "shared-cpu-2x", staging.Compute[0].Size)
+	assert.Equal(t, "512mb", staging.Compute[0].Memory)
+
+	production, err := LoadConfigForEnvironment(path, "production")
+	require.NoError(t, err)
+	assert.Equal(t, "acme-production", production.AppName)
+	assert.Equal(t, "iad", production.PrimaryRegion)
+	require.Len(t, production.Services, 1)
+	assert.Equal(t, 9090, production.Services[0].InternalPort)
+	assert.Equal(t, []fly.MachinePort{{Port: fly.Pointer(443), Handlers: []string{"tls", "http"}}}, production.Services[0].Ports)
+
+	_, err = LoadConfigForEnvironment(path, "qa")
+	assert.ErrorContains(t, err, "environment qa isn't defined")
+
+	writeConfigFile(t, dir, "fly.staging.toml", `primary_region = "lhr"`)
+	_, err = LoadConfigForEnvironment(path, "staging")
+	assert.ErrorContains(t, err, "environment staging is defined both in")
+}
+
+func TestConfigEnvironments(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, dir, "fly.toml", environmentsBase)
+	writeConfigFile(t, dir, "fly.production.toml", `app = "acme-production"`)
+	writeConfigFile(t, dir, "fly.workspace.toml", `[[apps]]`)
+	writeConfigFile(t, dir, "fly.production.json", `{}`)
+	writeConfigFile(t, dir, "other.qa.toml", ``)
+
+	environments, err := ConfigEnvironments(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"production", "staging"}, environments)
+}
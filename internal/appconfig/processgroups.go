@@ -2,6 +2,7 @@ package appconfig
 
 import (
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 
@@ -173,6 +174,13 @@ func (c *Config) Flatten(groupName string) (*Config, error) {
 		dst.Restart[i].Processes = []string{groupName}
 	}
 
+	// [build.processes]
+	if dst.Build != nil {
+		dst.Build.Processes = lo.PickBy(dst.Build.Processes, func(k string, _ *ProcessBuild) bool {
+			return dst.flattenGroupMatches(groupName, k)
+		})
+	}
+
 	// [[vm]]
 	compute := dst.ComputeForGroup(groupName)
 
@@ -213,6 +221,44 @@ func (c *Config) ComputeForGroup(groupName string) *Compute {
 	return compute
 }
 
+// ProcessBuildGroups returns the sorted names of the process groups with images of
+// their own.
+func (c *Config) ProcessBuildGroups() []string {
+	if c == nil || c.Build == nil {
+		return nil
+	}
+
+	return slices.Sorted(maps.Keys(c.Build.Processes))
+}
+
+// ForProcessBuild returns a copy of the config that builds the image of the process
+// group: its Dockerfile and build target replace the app's, and its args are added to
+// the app's.
+func (c *Config) ForProcessBuild(groupName string) *Config {
+	pb := c.Build.Processes[groupName]
+
+	dst := helpers.Clone(c)
+	dst.configFilePath = c.configFilePath
+	dst.Build.Processes = nil
+	if pb == nil {
+		return dst
+	}
+	if pb.Dockerfile != "" {
+		dst.Build.Dockerfile = pb.Dockerfile
+	}
+	if pb.DockerBuildTarget != "" {
+		dst.Build.DockerBuildTarget = pb.DockerBuildTarget
+	}
+	if len(pb.Args) > 0 {
+		if dst.Build.Args == nil {
+			dst.Build.Args = make(map[string]string)
+		}
+		maps.Copy(dst.Build.Args, pb.Args)
+	}
+
+	return dst
+}
+
 func (c *Config) InitCmd(groupName string) ([]string, error) {
 	if groupName == "" {
 		groupName = c.DefaultProcessName()
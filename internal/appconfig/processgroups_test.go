@@ -73,3 +73,34 @@ func TestProcessNames(t *testing.T) {
 		})
 	}
 }
+
+func TestForProcessBuild(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/build-processes.toml")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"worker"}, cfg.ProcessBuildGroups())
+	assert.Equal(t, map[string]*ProcessBuild{"worker": {
+		Dockerfile:        "Dockerfile.worker",
+		DockerBuildTarget: "worker",
+		Args:              map[string]string{"CHROME": "1"},
+	}}, cfg.Build.Processes)
+
+	worker := cfg.ForProcessBuild("worker")
+	assert.Equal(t, "Dockerfile.worker", worker.Dockerfile())
+	assert.Equal(t, "worker", worker.DockerBuildTarget())
+	assert.Equal(t, map[string]string{"NODE_VERSION": "22", "CHROME": "1"}, worker.Build.Args)
+	assert.Empty(t, worker.ProcessBuildGroups())
+	assert.Equal(t, cfg.ConfigFilePath(), worker.ConfigFilePath())
+
+	// the app's config is left as is
+	assert.Equal(t, "Dockerfile", cfg.Dockerfile())
+	assert.Equal(t, map[string]string{"NODE_VERSION": "22"}, cfg.Build.Args)
+
+	web, err := cfg.Flatten("web")
+	require.NoError(t, err)
+	assert.Empty(t, web.ProcessBuildGroups())
+
+	flatWorker, err := cfg.Flatten("worker")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"worker"}, flatWorker.ProcessBuildGroups())
+}
@@ -2,6 +2,7 @@ package appconfig
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	fly "github.com/superfly/fly-go"
@@ -65,7 +66,81 @@ func getAppV2ConfigFromReleases(ctx context.Context, apiClient flyutil.Client, a
 		return nil, err
 	}
 
-	configDefinition := resp.App.CurrentReleaseUnprocessed.ConfigDefinition
+	return configFromReleaseDefinition(resp.App.CurrentReleaseUnprocessed.ConfigDefinition)
+}
+
+// RecentReleases is how many of the latest releases FromRelease searches.
+const RecentReleases = 25
+
+// ReleaseSecrets is the part of a release's metadata recording the secrets version its
+// machines were deployed with.
+type ReleaseSecrets struct {
+	SecretsVersion *uint64 `json:"secrets_version,omitempty"`
+}
+
+// FromRelease returns the app config a release was deployed with, and the secrets version
+// of its machines when the release recorded one. Only recent releases are searched.
+func FromRelease(ctx context.Context, appName string, version int) (*Config, *uint64, error) {
+	_ = `# @genqlient
+	query FlyctlConfigReleases($appName: String!, $limit: Int!, $version: Int!) {
+		app(name:$appName) {
+			releasesUnprocessed(first: $limit) {
+				nodes {
+					version
+					configDefinition
+				}
+			}
+			release(version: $version) {
+				# @genqlient(bind: "encoding/json.RawMessage")
+				metadata
+			}
+		}
+	}
+	`
+	apiClient := flyutil.ClientFromContext(ctx)
+	resp, err := gql.FlyctlConfigReleases(ctx, apiClient.GenqClient(), appName, RecentReleases, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, release := range resp.App.ReleasesUnprocessed.Nodes {
+		if release.Version != version {
+			continue
+		}
+
+		cfg, err := configFromReleaseDefinition(release.ConfigDefinition)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cfg == nil {
+			return nil, nil, fmt.Errorf("release v%d of %s has no config", version, appName)
+		}
+		if err := cfg.SetMachinesPlatform(); err != nil {
+			return nil, nil, err
+		}
+		cfg.AppName = appName
+
+		secrets, err := releaseSecrets(resp.App.Release.Metadata)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid metadata on release v%d of %s: %w", version, appName, err)
+		}
+
+		return cfg, secrets.SecretsVersion, nil
+	}
+
+	return nil, nil, fmt.Errorf("could not find release v%d of %s", version, appName)
+}
+
+func releaseSecrets(metadata json.RawMessage) (secrets ReleaseSecrets, err error) {
+	if len(metadata) == 0 || string(metadata) == "null" {
+		return secrets, nil
+	}
+	err = json.Unmarshal(metadata, &secrets)
+
+	return secrets, err
+}
+
+func configFromReleaseDefinition(configDefinition any) (*Config, error) {
 	if configDefinition == nil {
 		return nil, nil
 	}
@@ -56,24 +56,7 @@ func LoadConfig(path string) (cfg *Config, err error) {
 
 // LoadConfigAsMap loads the config as a map, which is useful for strict validation.
 func LoadConfigAsMap(path string) (rawConfig map[string]any, err error) {
-	buf, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	// First unmarshal to get raw config map
-	rawConfig = map[string]any{}
-	if strings.HasSuffix(path, ".json") {
-		err = json.Unmarshal(buf, &rawConfig)
-	} else if strings.HasSuffix(path, ".yaml") {
-		err = yaml.Unmarshal(buf, &rawConfig)
-		if err == nil {
-			stringifyYAMLMapKeys(rawConfig)
-		}
-	} else {
-		err = toml.Unmarshal(buf, &rawConfig)
-	}
-	if err != nil {
+	if rawConfig, err = readConfigMap(path); err != nil {
 		return nil, err
 	}
 
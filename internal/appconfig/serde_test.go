@@ -481,6 +481,24 @@ func TestLoadTOMLAppConfigReferenceFormat(t *testing.T) {
 				Size:   "performance-2x",
 				Memory: "8g",
 			},
+			Progressive: &Progressive{
+				Steps:        []int{10, 50},
+				Pause:        fly.MustParseDuration("30s"),
+				AutoRollback: new(false),
+			},
+			Hooks: []DeployHook{
+				{
+					Name:      "purge cache",
+					Phase:     "post-deploy",
+					Command:   "./bin/purge-cache",
+					OnFailure: "continue",
+				},
+				{
+					Phase:          "pre-release",
+					MachineCommand: "bin/check-migrations",
+					Timeout:        fly.MustParseDuration("2m"),
+				},
+			},
 		},
 
 		Env: map[string]string{
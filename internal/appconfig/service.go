@@ -3,6 +3,10 @@ package appconfig
 import (
 	"context"
 	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
 
 	"github.com/samber/lo"
 	fly "github.com/superfly/fly-go"
@@ -219,3 +223,47 @@ func httpCheckFromMachineCheck(ctx context.Context, mc fly.MachineServiceCheck)
 		HTTPHeaders:       headers,
 	}
 }
+
+// UnreachableTCPPorts returns the internal ports of the TCP services of c that none of the
+// given processes of a Machine listen on from outside the Machine, where fly-proxy can't
+// reach them. c is the config of the Machine's process group. known is false when the
+// processes report no listen sockets at all: nothing may be listening yet, or the Machine
+// runs an older init that doesn't report them.
+func (c *Config) UnreachableTCPPorts(processes fly.MachinePsResponse) (ports []int, known bool) {
+	tcpServices := make(map[int]struct{})
+	for _, s := range c.AllServices() {
+		if s.Protocol == "tcp" {
+			tcpServices[s.InternalPort] = struct{}{}
+		}
+	}
+
+	for _, proc := range processes {
+		for _, ls := range proc.ListenSockets {
+			known = true
+
+			host, portStr, err := net.SplitHostPort(ls.Address)
+			if err != nil {
+				continue
+			}
+			port, err := strconv.Atoi(portStr)
+			if err != nil {
+				continue
+			}
+
+			// We don't know VM's internal ipv4 which is also a valid address to bind to.
+			// Let's assume that whoever binds to a non-loopback address knows what they are doing.
+			// If we expose this address to flyctl later, we can revisit this logic.
+			if !net.ParseIP(host).IsLoopback() {
+				delete(tcpServices, port)
+			}
+		}
+	}
+
+	if !known {
+		return nil, false
+	}
+
+	ports = slices.Sorted(maps.Keys(tcpServices))
+
+	return ports, true
+}
@@ -0,0 +1,38 @@
+package appconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	fly "github.com/superfly/fly-go"
+)
+
+func TestUnreachableTCPPorts(t *testing.T) {
+	cfg := &Config{
+		HTTPService: &HTTPService{InternalPort: 8080},
+		Services: []Service{
+			{Protocol: "tcp", InternalPort: 5432},
+			{Protocol: "udp", InternalPort: 53},
+		},
+	}
+
+	ports, known := cfg.UnreachableTCPPorts(nil)
+	assert.False(t, known)
+	assert.Nil(t, ports)
+
+	ports, known = cfg.UnreachableTCPPorts(fly.MachinePsResponse{
+		{ListenSockets: []fly.ListenSocket{
+			{Proto: "tcp", Address: "127.0.0.1:8080"},
+			{Proto: "tcp", Address: "[::]:5432"},
+		}},
+	})
+	assert.True(t, known)
+	assert.Equal(t, []int{8080}, ports)
+
+	ports, known = cfg.UnreachableTCPPorts(fly.MachinePsResponse{
+		{ListenSockets: []fly.ListenSocket{{Proto: "tcp", Address: "0.0.0.0:8080"}}},
+		{ListenSockets: []fly.ListenSocket{{Proto: "tcp", Address: "0.0.0.0:5432"}}},
+	})
+	assert.True(t, known)
+	assert.Empty(t, ports)
+}
@@ -26,6 +26,15 @@ func StrictValidate(rawConfig map[string]any) *StrictValidateResult {
 
 	// Check each key in the raw config
 	for key, value := range rawConfig {
+		// Environment overlays are partial configs of their own
+		if environments, ok := value.(map[string]any); ok && key == environmentsKey {
+			for name, overlay := range environments {
+				validateNestedSection(key+"."+name, overlay, reflect.TypeFor[Config](), result)
+			}
+
+			continue
+		}
+
 		fieldInfo, recognized := recognizedFields[key]
 		if !recognized {
 			result.UnrecognizedSections = append(result.UnrecognizedSections, key)
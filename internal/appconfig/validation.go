@@ -19,7 +19,7 @@ import (
 
 var (
 	ErrInvalidApplicationConfig = errors.New("invalid app configuration")
-	MachinesDeployStrategies    = []string{"canary", "rolling", "immediate", "bluegreen"}
+	MachinesDeployStrategies    = []string{"canary", "rolling", "immediate", "bluegreen", "progressive"}
 )
 
 func (c *Config) Validate(ctx context.Context) (err error, extra_info string) {
@@ -33,6 +33,7 @@ func (c *Config) Validate(ctx context.Context) (err error, extra_info string) {
 		c.validateChecksSection,
 		c.validateServicesSection,
 		c.validateProcessesSection,
+		c.validateProcessBuilds,
 		c.validateMachineConversion,
 		c.validateConsoleCommand,
 		c.validateMounts,
@@ -40,7 +41,11 @@ func (c *Config) Validate(ctx context.Context) (err error, extra_info string) {
 		c.validateCompression,
 	}
 
-	extra_info = fmt.Sprintf("Validating %s\n", c.ConfigFilePath())
+	if c.environment != "" {
+		extra_info = fmt.Sprintf("Validating %s with environment %s\n", c.ConfigFilePath(), c.environment)
+	} else {
+		extra_info = fmt.Sprintf("Validating %s\n", c.ConfigFilePath())
+	}
 
 	for _, vFunc := range validators {
 		info, vErr := vFunc()
@@ -121,6 +126,46 @@ func (c *Config) validateDeploySection() (extraInfo string, err error) {
 		}
 	}
 
+	if p := c.Deploy.Progressive; p != nil {
+		prev := 0
+		for _, step := range p.Steps {
+			if step <= prev || step > 100 {
+				extraInfo += fmt.Sprintf("progressive deploy steps must be increasing percentages between 1 and 100, got %v\n", p.Steps)
+				err = ErrInvalidApplicationConfig
+
+				break
+			}
+			prev = step
+		}
+	}
+
+	for _, h := range c.Deploy.Hooks {
+		name := h.DisplayName()
+		if !slices.Contains(DeployHookPhases, h.Phase) {
+			extraInfo += fmt.Sprintf("deploy hook '%s' has an unsupported phase '%s'; supported phases are: %s\n", name, h.Phase, strings.Join(DeployHookPhases, ", "))
+			err = ErrInvalidApplicationConfig
+		}
+		if (h.Command == "") == (h.MachineCommand == "") {
+			extraInfo += fmt.Sprintf("deploy hook '%s' must set exactly one of command or machine_command\n", name)
+			err = ErrInvalidApplicationConfig
+		}
+		if h.MachineCommand != "" && h.Phase == DeployHookPreBuild {
+			extraInfo += fmt.Sprintf("deploy hook '%s' can't run on a machine before the image is built, use command instead\n", name)
+			err = ErrInvalidApplicationConfig
+		}
+		switch h.FailureAction() {
+		case DeployHookFailureAbort, DeployHookFailureContinue:
+		case DeployHookFailureRollback:
+			if h.Phase != DeployHookPostDeploy {
+				extraInfo += fmt.Sprintf("deploy hook '%s' can only roll back the deploy from the %s phase\n", name, DeployHookPostDeploy)
+				err = ErrInvalidApplicationConfig
+			}
+		default:
+			extraInfo += fmt.Sprintf("deploy hook '%s' has an unsupported on_failure '%s'; supported values are: %s, %s, %s\n", name, h.OnFailure, DeployHookFailureAbort, DeployHookFailureContinue, DeployHookFailureRollback)
+			err = ErrInvalidApplicationConfig
+		}
+	}
+
 	return
 }
 
@@ -247,6 +292,28 @@ func (c *Config) validateProcessesSection() (extraInfo string, err error) {
 	return extraInfo, err
 }
 
+func (c *Config) validateProcessBuilds() (extraInfo string, err error) {
+	groups := c.ProcessBuildGroups()
+	if len(groups) == 0 {
+		return
+	}
+
+	if c.Build.Image != "" || c.Build.Builder != "" || len(c.Build.Buildpacks) > 0 || c.Build.Builtin != "" {
+		extraInfo += "Process groups can only have images of their own when the app is built from a Dockerfile; check [build.processes] section\n"
+		err = ErrInvalidApplicationConfig
+	}
+
+	processNames := c.ProcessNames()
+	for _, name := range groups {
+		if !slices.Contains(processNames, name) {
+			extraInfo += fmt.Sprintf("Image built for '%s', which isn't a process group; check [build.processes] section\n", name)
+			err = ErrInvalidApplicationConfig
+		}
+	}
+
+	return extraInfo, err
+}
+
 func (c *Config) validateMachineConversion() (extraInfo string, err error) {
 	for _, name := range c.ProcessNames() {
 		if _, vErr := c.ToMachineConfig(name, nil); err != nil {
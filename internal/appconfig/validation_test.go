@@ -81,6 +81,55 @@ func TestConfig_ValidateGroups(t *testing.T) {
 	require.NoErrorf(t, err, x)
 }
 
+func TestConfig_ValidateProgressiveSteps(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Deploy = &Deploy{
+		Strategy:    "progressive",
+		Progressive: &Progressive{Steps: []int{10, 50, 100}},
+	}
+
+	ctx := _getValidationContext(t)
+	err, x := cfg.Validate(ctx)
+	require.NoError(t, err, x)
+
+	cfg.Deploy.Progressive.Steps = []int{50, 25, 100}
+	err, x = cfg.Validate(ctx)
+	require.Error(t, err, x)
+	require.Contains(t, x, "progressive deploy steps must be increasing percentages")
+
+	cfg.Deploy.Progressive.Steps = []int{10, 150}
+	err, x = cfg.Validate(ctx)
+	require.Error(t, err, x)
+}
+
+func TestConfig_ValidateDeployHooks(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Deploy = &Deploy{
+		Hooks: []DeployHook{
+			{Phase: DeployHookPreBuild, Command: "make assets"},
+			{Phase: DeployHookPostDeploy, MachineCommand: "bin/smoke", OnFailure: DeployHookFailureRollback},
+		},
+	}
+
+	ctx := _getValidationContext(t)
+	err, x := cfg.Validate(ctx)
+	require.NoError(t, err, x)
+
+	for _, hook := range []DeployHook{
+		{Phase: "post-release", Command: "true"},
+		{Phase: DeployHookPreRelease},
+		{Phase: DeployHookPreRelease, Command: "true", MachineCommand: "true"},
+		{Phase: DeployHookPreBuild, MachineCommand: "true"},
+		{Phase: DeployHookPreRelease, Command: "true", OnFailure: DeployHookFailureRollback},
+		{Phase: DeployHookPreRelease, Command: "true", OnFailure: "explode"},
+	} {
+		cfg.Deploy.Hooks = []DeployHook{hook}
+		err, x = cfg.Validate(ctx)
+		require.Error(t, err, "%+v", hook)
+		require.Contains(t, x, "deploy hook")
+	}
+}
+
 func TestConfig_ValidateMounts(t *testing.T) {
 	cfg, err := LoadConfig("./testdata/validate-mounts.toml")
 	require.NoError(t, err)
@@ -110,3 +159,26 @@ func TestConfig_ValidateServices(t *testing.T) {
 	err, x = cfg.ValidateGroups(ctx, []string{"success"})
 	require.NoErrorf(t, err, x)
 }
+
+func TestConfig_ValidateProcessBuilds(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/build-processes.toml")
+	require.NoError(t, err)
+
+	ctx := _getValidationContext(t)
+	err, x := cfg.Validate(ctx)
+	require.NoError(t, err, x)
+
+	err, x = cfg.ValidateGroups(ctx, []string{"web"})
+	require.NoError(t, err, x)
+
+	cfg.Build.Processes["cron"] = &ProcessBuild{DockerBuildTarget: "cron"}
+	err, x = cfg.Validate(ctx)
+	require.Error(t, err, x)
+	require.Contains(t, x, "Image built for 'cron', which isn't a process group")
+
+	delete(cfg.Build.Processes, "cron")
+	cfg.Build.Image = "nginx"
+	err, x = cfg.Validate(ctx)
+	require.Error(t, err, x)
+	require.Contains(t, x, "only have images of their own when the app is built from a Dockerfile")
+}
@@ -0,0 +1,164 @@
+package imgsrc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/spf13/viper"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/dockerfileurl"
+)
+
+// BuildCacheLabel is the image label recording the build cache key of an image built
+// from a Dockerfile.
+const BuildCacheLabel = "fly.build.digest"
+
+// BuildCacheKey returns a digest of everything a Dockerfile build of opts depends on:
+// the build context, the Dockerfile, the build args and secrets, and the target. It returns an
+// empty key for builds that can't be cached this way: builtins, buildpacks and
+// remote Dockerfiles.
+func BuildCacheKey(opts ImageOptions) (string, error) {
+	if opts.BuiltIn != "" || opts.Builder != "" || dockerfileurl.IsURL(opts.DockerfilePath) {
+		return "", nil
+	}
+
+	dockerfile := opts.DockerfilePath
+	if dockerfile == "" {
+		if dockerfile = ResolveDockerfile(opts.WorkingDir); dockerfile == "" {
+			return "", nil
+		}
+	}
+
+	contents, err := os.ReadFile(dockerfile)
+	if err != nil {
+		return "", err
+	}
+
+	contextDigest, err := BuildContextDigest(opts.WorkingDir, dockerfile, opts.IgnorefilePath)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "context\x00%s\x00", contextDigest)
+	fmt.Fprintf(h, "dockerfile\x00%d\x00", len(contents))
+	h.Write(contents)
+	for _, k := range slices.Sorted(maps.Keys(opts.BuildArgs)) {
+		fmt.Fprintf(h, "arg\x00%s=%s\x00", k, opts.BuildArgs[k])
+	}
+	fmt.Fprintf(h, "target\x00%s\x00", opts.Target)
+	// secret values are part of the key, never of the image, but a changed one may change
+	// what the build produces
+	for _, k := range slices.Sorted(maps.Keys(opts.BuildSecrets)) {
+		fmt.Fprintf(h, "secret\x00%s\x00%d\x00", k, len(opts.BuildSecrets[k]))
+		h.Write([]byte(opts.BuildSecrets[k]))
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// BuildCacheTag returns the registry tag an image built with the given cache key is
+// pushed under: registry.fly.io/appname:build-$digest
+func BuildCacheTag(appName, key string) string {
+	registry := viper.GetString(flyctl.ConfigRegistryHost)
+
+	return fmt.Sprintf("%s/%s:build-%s", registry, appName, strings.TrimPrefix(key, "sha256:"))
+}
+
+// FindCachedBuild looks up the image the app last built with the given cache key in
+// the registry. It returns nil, and no error, if there's none.
+func FindCachedBuild(ctx context.Context, appName, key string) (*DeploymentImage, error) {
+	tag := BuildCacheTag(appName, key)
+	ref, err := name.ParseReference(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := remote.Get(ref, registryOptions(ctx)...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	// the tag is only a hint, the label is what ties the image to its build
+	if cfg.Config.Labels[BuildCacheLabel] != key {
+		return nil, nil
+	}
+
+	id, err := img.ConfigName()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	size := manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+	}
+
+	return &DeploymentImage{
+		ID:     id.String(),
+		Tag:    tag,
+		Digest: desc.Digest.String(),
+		Size:   size,
+		Labels: cfg.Config.Labels,
+	}, nil
+}
+
+// TagCachedBuild tags img, pushed to the registry, so FindCachedBuild finds it for
+// later builds with the same cache key.
+func TagCachedBuild(ctx context.Context, img *DeploymentImage, appName, key string) error {
+	src, err := name.ParseReference(img.Tag)
+	if err != nil {
+		return err
+	}
+
+	dst, err := name.NewTag(BuildCacheTag(appName, key))
+	if err != nil {
+		return err
+	}
+
+	opts := registryOptions(ctx)
+	desc, err := remote.Get(src, opts...)
+	if err != nil {
+		return err
+	}
+
+	return remote.Tag(dst, desc, opts...)
+}
+
+func registryOptions(ctx context.Context) []remote.Option {
+	return []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuth(&authn.Basic{Username: "x", Password: config.Tokens(ctx).Docker()}),
+	}
+}
@@ -0,0 +1,119 @@
+package imgsrc
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/fly-go/tokens"
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/config"
+)
+
+func TestBuildCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0o644))
+	writeSizedFile(t, dir, "app/main.go", 20)
+
+	opts := ImageOptions{WorkingDir: dir, BuildArgs: map[string]string{"A": "1", "B": "2"}}
+	key, err := BuildCacheKey(opts)
+	require.NoError(t, err)
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, key)
+
+	same, err := BuildCacheKey(ImageOptions{WorkingDir: dir, DockerfilePath: filepath.Join(dir, "Dockerfile"), BuildArgs: map[string]string{"B": "2", "A": "1"}})
+	require.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	for name, changed := range map[string]ImageOptions{
+		"build args": {WorkingDir: dir, BuildArgs: map[string]string{"A": "1"}},
+		"target":     {WorkingDir: dir, BuildArgs: opts.BuildArgs, Target: "release"},
+		"secrets":    {WorkingDir: dir, BuildArgs: opts.BuildArgs, BuildSecrets: map[string]string{"TOKEN": "1"}},
+	} {
+		other, err := BuildCacheKey(changed)
+		require.NoError(t, err)
+		assert.NotEqual(t, key, other, name)
+	}
+
+	withSecret, err := BuildCacheKey(ImageOptions{WorkingDir: dir, BuildArgs: opts.BuildArgs, BuildSecrets: map[string]string{"TOKEN": "1"}})
+	require.NoError(t, err)
+	rotated, err := BuildCacheKey(ImageOptions{WorkingDir: dir, BuildArgs: opts.BuildArgs, BuildSecrets: map[string]string{"TOKEN": "2"}})
+	require.NoError(t, err)
+	assert.NotEqual(t, withSecret, rotated, "secret value")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0o644))
+	other, err := BuildCacheKey(opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other, "Dockerfile")
+
+	for _, uncached := range []ImageOptions{
+		{WorkingDir: t.TempDir()},
+		{WorkingDir: dir, BuiltIn: "node"},
+		{WorkingDir: dir, Builder: "paketobuildpacks/builder:base"},
+		{WorkingDir: dir, DockerfilePath: "https://example.com/Dockerfile"},
+	} {
+		key, err := BuildCacheKey(uncached)
+		require.NoError(t, err)
+		assert.Empty(t, key)
+	}
+}
+
+func TestCachedBuild(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	previous := viper.GetString(flyctl.ConfigRegistryHost)
+	viper.Set(flyctl.ConfigRegistryHost, u.Host)
+	defer viper.Set(flyctl.ConfigRegistryHost, previous)
+
+	ctx := config.NewContext(context.Background(), &config.Config{Tokens: &tokens.Tokens{}})
+	key := "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	img, err := FindCachedBuild(ctx, "myapp", key)
+	require.NoError(t, err)
+	assert.Nil(t, img)
+
+	built, err := random.Image(64, 2)
+	require.NoError(t, err)
+	cfg, err := built.ConfigFile()
+	require.NoError(t, err)
+	cfg.Config.Labels = map[string]string{BuildCacheLabel: key}
+	built, err = mutate.ConfigFile(built, cfg)
+	require.NoError(t, err)
+
+	tag := u.Host + "/myapp:deployment-1"
+	ref, err := name.ParseReference(tag)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, built))
+
+	require.NoError(t, TagCachedBuild(ctx, &DeploymentImage{Tag: tag}, "myapp", key))
+
+	img, err = FindCachedBuild(ctx, "myapp", key)
+	require.NoError(t, err)
+	require.NotNil(t, img)
+	assert.Equal(t, BuildCacheTag("myapp", key), img.Tag)
+	assert.Equal(t, key, img.Labels[BuildCacheLabel])
+	digest, err := built.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, digest.String(), img.Digest)
+	assert.Positive(t, img.Size)
+
+	// an image tagged for another key isn't reused
+	otherKey := "sha256:" + "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+	require.NoError(t, TagCachedBuild(ctx, &DeploymentImage{Tag: tag}, "myapp", otherKey))
+	img, err = FindCachedBuild(ctx, "myapp", otherKey)
+	require.NoError(t, err)
+	assert.Nil(t, img)
+}
@@ -0,0 +1,89 @@
+package imgsrc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/moby/patternmatcher"
+)
+
+// BuildContextDigest returns a digest of the files in workingDir that would be sent as
+// the build context, applying the same .dockerignore rules as the archiver. It covers
+// file paths, modes and contents, so any change that could change the built image
+// changes the digest.
+func BuildContextDigest(workingDir, dockerfile, ignoreFile string) (string, error) {
+	relDockerfile := ""
+	if dockerfile != "" && isPathInRoot(dockerfile, workingDir) {
+		if p, err := filepath.Rel(workingDir, dockerfile); err == nil {
+			relDockerfile = filepath.ToSlash(p)
+		}
+	}
+
+	excludes, err := readDockerignore(workingDir, ignoreFile, relDockerfile)
+	if err != nil {
+		return "", err
+	}
+
+	pm, err := patternmatcher.New(excludes)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	// WalkDir visits entries in lexical order, which keeps the digest stable
+	err = filepath.WalkDir(workingDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(workingDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		slashRel := filepath.ToSlash(rel)
+
+		if excluded, _ := pm.MatchesOrParentMatches(slashRel); excluded {
+			if d.IsDir() && canSkipExcludedDir(pm, rel) {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00", slashRel, info.Mode())
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00", target)
+		case d.Type().IsRegular():
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := io.Copy(h, f); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read the build context in %s: %w", workingDir, err)
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
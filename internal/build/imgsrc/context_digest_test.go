@@ -0,0 +1,38 @@
+package imgsrc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildContextDigest(t *testing.T) {
+	dir := t.TempDir()
+	writeSizedFile(t, dir, "Dockerfile", 10)
+	writeSizedFile(t, dir, "app/main.go", 20)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".dockerignore"), []byte("tmp\n"), 0o644))
+
+	digest, err := BuildContextDigest(dir, "", "")
+	require.NoError(t, err)
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, digest)
+
+	// Ignored files don't change the digest
+	writeSizedFile(t, dir, "tmp/scratch", 30)
+	same, err := BuildContextDigest(dir, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, digest, same)
+
+	// Changing, adding or renaming a file does
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app/main.go"), []byte("package main"), 0o644))
+	changed, err := BuildContextDigest(dir, "", "")
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, changed)
+
+	require.NoError(t, os.Rename(filepath.Join(dir, "app/main.go"), filepath.Join(dir, "app/other.go")))
+	renamed, err := BuildContextDigest(dir, "", "")
+	require.NoError(t, err)
+	assert.NotEqual(t, changed, renamed)
+}
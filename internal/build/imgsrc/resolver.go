@@ -27,6 +27,7 @@ import (
 	"github.com/superfly/flyctl/internal/flapsutil"
 	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/flyctl/internal/sentry"
+	"github.com/superfly/flyctl/internal/statuslogger"
 	"github.com/superfly/flyctl/internal/tracing"
 	"github.com/superfly/flyctl/internal/uiex"
 	"github.com/superfly/flyctl/internal/uiexutil"
@@ -129,6 +130,9 @@ type DeploymentImage struct {
 	BuildID   int64
 	BuilderID string
 	Labels    map[string]string
+	// ProcessImages are the tags of the images built for the process groups with images
+	// of their own, by group.
+	ProcessImages map[string]string
 }
 
 func (di *DeploymentImage) String() string {
@@ -323,12 +327,14 @@ func (r *Resolver) BuildImage(ctx context.Context, streams *iostreams.IOStreams,
 		terminal.Debugf("Trying '%s' strategy\n", s.Name())
 		bld.ResetTimings()
 		bld.BuildAndPushStart()
+		statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventBuildStarted, Strategy: s.Name()})
 		var note string
 		img, note, err = runImageBuilder(ctx, s, r.dockerFactory, streams, opts, bld, materializer)
 		terminal.Debugf("result image:%+v error:%v\n", img, err)
 		if err != nil {
 			bld.BuildAndPushFinish()
 			bld.FinishStrategy(s, true /* failed */, err, note)
+			bld.emitFinished(ctx, s, "failure", err)
 			r.finishBuild(ctx, bld, true /* failed */, err.Error(), nil)
 
 			return nil, err
@@ -336,6 +342,7 @@ func (r *Resolver) BuildImage(ctx context.Context, streams *iostreams.IOStreams,
 		if img != nil {
 			bld.BuildAndPushFinish()
 			bld.FinishStrategy(s, false /* success */, nil, note)
+			bld.emitFinished(ctx, s, "success", nil)
 			buildResult, err := r.finishBuild(ctx, bld, false /* completed */, "", img)
 			if err == nil && buildResult != nil {
 				// we should only set the image's buildID if we push the build info to web
@@ -347,6 +354,7 @@ func (r *Resolver) BuildImage(ctx context.Context, streams *iostreams.IOStreams,
 		}
 		bld.BuildAndPushFinish()
 		bld.FinishStrategy(s, true /* failed */, nil, note)
+		bld.emitFinished(ctx, s, "skipped", nil)
 	}
 
 	r.finishBuild(ctx, bld, true /* failed */, "no strategies resulted in an image", nil)
@@ -590,6 +598,28 @@ func (b *build) FinishStrategy(strategy imageBuilder, failed bool, err error, no
 	b.finishStrategyCommon(strategy.Name(), failed, err, note)
 }
 
+// emitFinished reports the outcome and timings of a build strategy attempt to the deploy event stream.
+func (b *build) emitFinished(ctx context.Context, strategy imageBuilder, status string, err error) {
+	ev := statuslogger.Event{
+		Type:       statuslogger.EventBuildFinished,
+		Strategy:   strategy.Name(),
+		Status:     status,
+		DurationMs: b.Timings.BuildAndPushMs,
+		Timings: map[string]int64{
+			"build_and_push_ms": b.Timings.BuildAndPushMs,
+			"builder_init_ms":   b.Timings.BuilderInitMs,
+			"context_build_ms":  b.Timings.ContextBuildMs,
+			"image_build_ms":    b.Timings.ImageBuildMs,
+			"build_ms":          b.Timings.BuildMs,
+			"push_ms":           b.Timings.PushMs,
+		},
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	statuslogger.Emit(ctx, ev)
+}
+
 func (b *build) FinishImageStrategy(strategy imageResolver, failed bool, err error, note string) {
 	b.finishStrategyCommon(strategy.Name(), failed, err, note)
 }
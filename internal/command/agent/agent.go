@@ -29,6 +29,8 @@ func New() (cmd *cobra.Command) {
 	cmd.AddCommand(
 		newRun(),
 		newPing(),
+		newStatus(),
+		newDNS(),
 		newStart(),
 		newStop(),
 		newRestart(),
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/iostreams"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+)
+
+func newDNS() (cmd *cobra.Command) {
+	const (
+		short = "Serve Fly private DNS names locally"
+		long  = `Run a DNS server that answers queries for .internal and .flycast names, such as
+my-app.internal, top1.nearest.of.my-app.internal or regions.my-app.internal TXT
+records, by forwarding them to the nameserver of an organization's private
+network through the agent's WireGuard tunnel. Answers are cached for their TTL.
+Other queries are refused, so system resolvers can send only the Fly zones here:
+with systemd-resolved, or in /etc/resolver/internal and /etc/resolver/flycast
+on macOS.
+`
+	)
+
+	cmd = command.New("dns", short, long, runDNS,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.String{
+			Name:        "listen",
+			Description: "Address to serve DNS on, over both UDP and TCP",
+			Default:     "127.0.0.1:5353",
+		},
+		flag.String{
+			Name:        "network",
+			Description: "Custom private network of the organization to resolve names in",
+		},
+	)
+
+	return
+}
+
+func runDNS(ctx context.Context) (err error) {
+	io := iostreams.FromContext(ctx)
+
+	orgSlug := flag.GetOrg(ctx)
+	if orgSlug == "" {
+		org, err := prompt.Org(ctx)
+		if err != nil {
+			return err
+		}
+		orgSlug = org.Slug
+	}
+	network := flag.GetString(ctx, "network")
+
+	var client *agent.Client
+	if client, err = establish(ctx); err != nil {
+		return
+	}
+
+	dialer, err := client.ConnectToTunnel(ctx, orgSlug, network, false)
+	if err != nil {
+		return err
+	}
+
+	addr := flag.GetString(ctx, "listen")
+	forwarder := &agent.DNSForwarder{Dialer: dialer}
+
+	// Bind both listeners before serving, so a busy address is reported right away
+	packetConn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		packetConn.Close()
+
+		return err
+	}
+
+	servers := []*dns.Server{
+		{PacketConn: packetConn, Handler: forwarder},
+		{Listener: listener, Handler: forwarder},
+	}
+
+	fmt.Fprintf(io.Out, "Serving %s names of %s on %s\n", strings.Join(agent.PrivateDNSZones, " and "), orgSlug, addr)
+
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, server := range servers {
+		eg.Go(server.ActivateAndServe)
+	}
+	eg.Go(func() error {
+		<-ctx.Done()
+
+		var errs []error
+		for _, server := range servers {
+			errs = append(errs, server.Shutdown())
+		}
+
+		return errors.Join(errs...)
+	})
+
+	return eg.Wait()
+}
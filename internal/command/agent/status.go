@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/iostreams"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+)
+
+func newStatus() (cmd *cobra.Command) {
+	const (
+		short = "Show the Fly agent's tunnels"
+		long  = `Show the WireGuard tunnels the Fly agent runs, one per organization and
+network, with the peer and endpoint they use, their transport, how long ago their
+last handshake completed, the bytes they received and sent, and how many
+connections they currently proxy.
+`
+	)
+
+	cmd = command.New("status", short, long, runStatus)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd, flag.JSONOutput())
+
+	return
+}
+
+func runStatus(ctx context.Context) (err error) {
+	var client *agent.Client
+	if client, err = dial(ctx); err != nil {
+		return
+	}
+
+	var res *agent.StatusResponse
+	if res, err = client.Status(ctx); err != nil {
+		err = fmt.Errorf("failed fetching agent status: %w", err)
+
+		return
+	}
+
+	out := iostreams.FromContext(ctx).Out
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, res)
+	}
+
+	if len(res.Tunnels) == 0 {
+		fmt.Fprintln(out, "The agent has no tunnels")
+
+		return
+	}
+
+	rows := make([][]string, 0, len(res.Tunnels))
+	for _, tunnel := range res.Tunnels {
+		rows = append(rows, tunnelRow(tunnel, time.Now()))
+	}
+
+	return render.Table(out, "", rows, "Org", "Network", "Peer", "Region", "Endpoint", "Transport", "Handshake", "Received", "Sent", "Dials")
+}
+
+func tunnelRow(tunnel agent.TunnelStatus, now time.Time) []string {
+	handshake := "never"
+	switch {
+	case tunnel.Error != "":
+		handshake = tunnel.Error
+	case !tunnel.LastHandshake.IsZero():
+		handshake = now.Sub(tunnel.LastHandshake).Round(time.Second).String() + " ago"
+	}
+
+	return []string{
+		tunnel.Org,
+		tunnel.Network,
+		tunnel.Peer,
+		tunnel.Region,
+		tunnel.Endpoint,
+		tunnel.Transport,
+		handshake,
+		humanize.IBytes(tunnel.RxBytes),
+		humanize.IBytes(tunnel.TxBytes),
+		strconv.Itoa(tunnel.ActiveDials),
+	}
+}
@@ -709,7 +709,7 @@ func LoadAppConfigIfPresent(ctx context.Context) (context.Context, error) {
 	logger := logger.FromContext(ctx)
 	configPaths := appConfigFilePaths(ctx)
 	for _, path := range configPaths {
-		switch cfg, err := appconfig.LoadConfig(path); {
+		switch cfg, err := appconfig.LoadConfigForEnvironment(path, flag.GetEnvironment(ctx)); {
 		case err == nil:
 			logger.Debugf("app config loaded from %s", path)
 			if err := cfg.SetMachinesPlatform(); err != nil {
@@ -15,7 +15,8 @@ func newShow() (cmd *cobra.Command) {
 	const (
 		short = "Show an app's configuration"
 		long  = `Show an application's configuration. The configuration is presented by default
-in JSON format. The configuration data is retrieved from the Fly service.`
+in JSON format. The configuration data is retrieved from the Fly service, or from
+the local fly.toml merged with the overlay of an environment with --environment.`
 	)
 	cmd = command.New("show", short, long, runShow,
 		command.RequireSession,
@@ -24,10 +25,10 @@ in JSON format. The configuration data is retrieved from the Fly service.`
 	)
 	cmd.Args = cobra.NoArgs
 	cmd.Aliases = []string{"display"}
-	flag.Add(cmd, flag.App(), flag.AppConfig(),
+	flag.Add(cmd, flag.App(), flag.AppConfig(), flag.Environment(),
 		flag.Bool{
 			Name:        "local",
-			Description: "Parse and show local fly.toml file instead of fetching from the Fly service",
+			Description: "Parse and show local fly.toml file instead of fetching from the Fly service, implied by --environment",
 		},
 		flag.Bool{
 			Name:        "yaml",
@@ -48,7 +49,8 @@ func runShow(ctx context.Context) error {
 
 	var cfg *appconfig.Config
 
-	if !flag.GetBool(ctx, "local") {
+	// Environments only exist locally, showing one shows the local config merged with its overlay
+	if !flag.GetBool(ctx, "local") && flag.GetEnvironment(ctx) == "" {
 		var err error
 		cfg, err = appconfig.FromRemoteApp(ctx, appName)
 		if err != nil {
@@ -16,14 +16,15 @@ func newValidate() (cmd *cobra.Command) {
 	const (
 		short = "Validate an app's config file"
 		long  = `Validates an application's config file against the Fly platform to
-ensure it is correct and meaningful to the platform.`
+ensure it is correct and meaningful to the platform. Every environment defined
+for the config is validated too, unless one is selected with --environment.`
 	)
 	cmd = command.New("validate", short, long, runValidate,
 		command.RequireSession,
 		command.RequireAppName,
 	)
 	cmd.Args = cobra.NoArgs
-	flag.Add(cmd, flag.App(), flag.AppConfig(), flag.Bool{
+	flag.Add(cmd, flag.App(), flag.AppConfig(), flag.Environment(), flag.Bool{
 		Name:        "strict",
 		Shorthand:   "s",
 		Description: "Enable strict validation to check for unrecognized sections and keys",
@@ -40,6 +41,7 @@ func runValidate(ctx context.Context) error {
 
 	// if not found locally, try to get it from the remote app
 	var err error
+	local := cfg != nil
 	if cfg == nil {
 		appName := appconfig.NameFromContext(ctx)
 		if appName == "" {
@@ -62,11 +64,26 @@ func runValidate(ctx context.Context) error {
 	}
 
 	// Run standard validation
-	if err = cfg.SetMachinesPlatform(); err != nil {
-		return err
+	err = validateConfig(ctx, cfg)
+
+	// Then validate the config merged with each of its environments, unless one was selected
+	if local && flag.GetEnvironment(ctx) == "" {
+		environments, envErr := appconfig.ConfigEnvironments(cfg.ConfigFilePath())
+		if envErr != nil {
+			return envErr
+		}
+		for _, environment := range environments {
+			envCfg, envErr := appconfig.LoadConfigForEnvironment(cfg.ConfigFilePath(), environment)
+			if envErr != nil {
+				fmt.Fprintf(io.Out, "Environment %s: %v\n\n", environment, envErr)
+			} else {
+				envErr = validateConfig(ctx, envCfg)
+			}
+			if envErr != nil && err == nil {
+				err = fmt.Errorf("environment %s is not valid", environment)
+			}
+		}
 	}
-	err, extraInfo := cfg.Validate(ctx)
-	fmt.Fprintln(io.Out, extraInfo)
 
 	// Run strict validation if enabled
 	if strictMode {
@@ -86,3 +103,15 @@ func runValidate(ctx context.Context) error {
 
 	return err
 }
+
+func validateConfig(ctx context.Context, cfg *appconfig.Config) error {
+	io := iostreams.FromContext(ctx)
+
+	if err := cfg.SetMachinesPlatform(); err != nil {
+		return err
+	}
+	err, extraInfo := cfg.Validate(ctx)
+	fmt.Fprintln(io.Out, extraInfo)
+
+	return err
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/logrusorgru/aurora"
@@ -22,6 +25,7 @@ import (
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/ctrlc"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flag/flagnames"
 	"github.com/superfly/flyctl/internal/flag/validation"
 	"github.com/superfly/flyctl/internal/flapsutil"
 	"github.com/superfly/flyctl/internal/launchdarkly"
@@ -29,9 +33,11 @@ import (
 	"github.com/superfly/flyctl/internal/render"
 	"github.com/superfly/flyctl/internal/sentry"
 	"github.com/superfly/flyctl/internal/tracing"
+	"github.com/superfly/flyctl/internal/workspace"
 	"github.com/superfly/flyctl/iostreams"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 )
 
 var defaultMaxConcurrent = 8
@@ -54,6 +60,7 @@ var CommonFlags = flag.Set{
 	flag.BuildTarget(),
 	flag.BuildContextWarnSize(),
 	flag.NoCache(),
+	flag.ForceBuild(),
 	flag.Depot(),
 	flag.DepotScope(),
 	flag.Nixpacks(),
@@ -154,7 +161,7 @@ var CommonFlags = flag.Set{
 	},
 	flag.StringSlice{
 		Name:        "process-groups",
-		Description: "Deploy to machines only in these process groups",
+		Description: "Deploy to machines only in these process groups, building only the images they run",
 	},
 	flag.StringArray{
 		Name:        "label",
@@ -213,7 +220,7 @@ func New() *Command {
 	cmd.Command = command.New("deploy [WORKING_DIRECTORY]", short, long, cmd.run,
 		command.RequireSession,
 		command.ChangeWorkingDirectoryToFirstArgIfPresent,
-		command.RequireAppName,
+		requireAppNameUnlessWorkspace,
 	)
 	cmd.Args = cobra.MaximumNArgs(1)
 
@@ -221,6 +228,7 @@ func New() *Command {
 		CommonFlags,
 		flag.App(),
 		flag.AppConfig(),
+		flag.Environment(),
 		// Not in CommonFlags because it's not relevant to a first deploy
 		flag.Bool{
 			Name:        "update-only",
@@ -242,11 +250,68 @@ func New() *Command {
 			Description: "Path to a deploy manifest file to use for deployment.",
 			Hidden:      true,
 		},
+		flag.Bool{
+			Name:        "dry-run",
+			Description: "Print the machines, process groups and volumes the deployment would change, without building or changing anything",
+			Default:     false,
+		},
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "resume",
+			Description: "Resume the app's last interrupted deploy from its journal, either to 'finish' it or to 'rollback' the machines it already updated",
+			NoOptDefVal: resumeFinish,
+		},
+		flag.String{
+			Name:        "output",
+			Description: "Format of the deployment progress, 'text' or 'ndjson'. With 'ndjson', stdout carries a stream of JSON events and the usual output goes to stderr. FLY_EMIT_RELEASE_JSON then emits a 'release' event instead of its stdout line",
+			Default:     outputText,
+		},
+		flag.String{
+			Name:        "output-file",
+			Description: "Write the 'ndjson' event stream to this file instead of stdout",
+		},
+		flag.String{
+			Name:        "workspace",
+			Description: fmt.Sprintf("Deploy the apps listed in a workspace file, %s by default, in dependency order", workspace.DefaultFileName),
+			NoOptDefVal: workspace.DefaultFileName,
+		},
 	)
 
 	return cmd
 }
 
+// requireAppNameUnlessWorkspace requires an app name, except for workspace deploys which
+// take theirs from the workspace file.
+func requireAppNameUnlessWorkspace(ctx context.Context) (context.Context, error) {
+	if flag.GetString(ctx, "workspace") == "" {
+		return command.RequireAppName(ctx)
+	}
+
+	for _, name := range []string{flagnames.App, flagnames.AppConfigFilePath, "image", "from-manifest", "resume"} {
+		if flag.IsSpecified(ctx, name) {
+			return nil, errWorkspaceFlags
+		}
+	}
+
+	return ctx, nil
+}
+
+// validateDryRun rejects --dry-run along with the flags that deploy without planning:
+// resumed deploys and deploys from or exporting a manifest.
+func validateDryRun(ctx context.Context) error {
+	if !flag.GetBool(ctx, "dry-run") {
+		return nil
+	}
+
+	for _, name := range []string{"resume", "from-manifest", "export-manifest"} {
+		if flag.GetString(ctx, name) != "" {
+			return fmt.Errorf("--dry-run can't be used with --%s", name)
+		}
+	}
+
+	return nil
+}
+
 func (cmd *Command) run(ctx context.Context) (err error) {
 	io := iostreams.FromContext(ctx)
 	appName := appconfig.NameFromContext(ctx)
@@ -287,6 +352,26 @@ func (cmd *Command) run(ctx context.Context) (err error) {
 		return err
 	}
 
+	if err := validateDryRun(ctx); err != nil {
+		return err
+	}
+
+	ctx, finishEvents, err := withEventStream(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		finishEvents(err)
+	}()
+
+	if path := flag.GetString(ctx, "workspace"); path != "" {
+		return deployWorkspace(ctx, path)
+	}
+
+	if mode := flag.GetString(ctx, "resume"); mode != "" {
+		return resumeDeploy(ctx, appName, mode)
+	}
+
 	var manifestPath = flag.GetString(ctx, "from-manifest")
 
 	switch {
@@ -332,8 +417,6 @@ func (cmd *Command) run(ctx context.Context) (err error) {
 }
 
 func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, userID int, forceYes bool) (err error) {
-	span := trace.SpanFromContext(ctx)
-
 	io := iostreams.FromContext(ctx)
 	appName := appconfig.NameFromContext(ctx)
 	flapsClient := flapsutil.ClientFromContext(ctx)
@@ -342,16 +425,8 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, userID i
 		return err
 	}
 
-	// Start the feature flag client, if we haven't already
-	if launchdarkly.ClientFromContext(ctx) == nil {
-		ffClient, err := launchdarkly.NewClient(ctx, launchdarkly.UserInfo{
-			OrganizationID: fmt.Sprint(app.Organization.InternalNumericID),
-			UserID:         userID,
-		})
-		if err != nil {
-			return fmt.Errorf("could not create feature flag client: %w", err)
-		}
-		ctx = launchdarkly.NewContextWithClient(ctx, ffClient)
+	if ctx, err = withFeatureFlags(ctx, app, userID); err != nil {
+		return err
 	}
 
 	for env := range appConfig.Env {
@@ -361,29 +436,37 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, userID i
 		}
 	}
 
-	httpFailover := flag.GetHTTPSFailover(ctx)
-	usingWireguard := flag.GetWireguard(ctx)
-	recreateBuilder := flag.GetRecreateBuilder(ctx)
-
-	// Fetch an image ref or build from source to get the final image reference to deploy
-	dockerfileMaterializer := imgsrc.NewDockerfileMaterializer()
-	img, err := determineImage(ctx, app, appConfig, usingWireguard, recreateBuilder, dockerfileMaterializer)
-	if err != nil {
-		noBuilder := strings.Contains(err.Error(), "Could not find App")
-		recreateBuilder = recreateBuilder || noBuilder
-		if noBuilder || (usingWireguard && httpFailover) {
-			span.SetAttributes(attribute.String("builder.failover_error", err.Error()))
-			span.AddEvent("using http failover")
-			img, err = determineImage(ctx, app, appConfig, false, recreateBuilder, dockerfileMaterializer)
+	// A dry run doesn't build, it plans against the image that is already known if any
+	if flag.GetBool(ctx, "dry-run") {
+		ref, err := fetchImageRef(ctx, appConfig)
+		if err != nil {
+			return err
 		}
-	}
-	if cleanupErr := dockerfileMaterializer.Close(); cleanupErr != nil {
-		err = errors.Join(err, cleanupErr)
+		if ref == "" {
+			ref = dryRunImage
+		}
+
+		return deployToMachines(ctx, appConfig, app, &imgsrc.DeploymentImage{Tag: ref})
 	}
 
-	if err != nil {
-		return fmt.Errorf("failed to fetch an image or build from source: %w", err)
+	var imageRef string
+	defer func() {
+		if err == nil {
+			return
+		}
+		if hookErr := runDeployHooks(ctx, &deployHookRun{cfg: appConfig, phase: appconfig.DeployHookOnFailure, image: imageRef, cause: err}); hookErr != nil {
+			fmt.Fprintf(io.ErrOut, "%s %v\n", aurora.Yellow("WARN"), hookErr)
+		}
+	}()
+
+	// Workspace deploys build their apps' images ahead of deploying them
+	img := prebuiltImageFromContext(ctx)
+	if img == nil {
+		if img, err = buildImage(ctx, app, appConfig); err != nil {
+			return err
+		}
 	}
+	imageRef = img.Tag
 
 	if flag.GetBuildOnly(ctx) {
 		return nil
@@ -478,6 +561,197 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, userID i
 	return err
 }
 
+// withFeatureFlags starts the feature flag client for the app's organization, if we haven't already.
+func withFeatureFlags(ctx context.Context, app *flaps.App, userID int) (context.Context, error) {
+	if launchdarkly.ClientFromContext(ctx) != nil {
+		return ctx, nil
+	}
+
+	ffClient, err := launchdarkly.NewClient(ctx, launchdarkly.UserInfo{
+		OrganizationID: fmt.Sprint(app.Organization.InternalNumericID),
+		UserID:         userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create feature flag client: %w", err)
+	}
+
+	return launchdarkly.NewContextWithClient(ctx, ffClient), nil
+}
+
+// buildImage fetches an image ref or builds from source to get the final image reference
+// to deploy, running the pre-build and post-build hooks around it. The images of the
+// process groups with images of their own are built alongside it.
+func buildImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Config) (*imgsrc.DeploymentImage, error) {
+	if err := runDeployHooks(ctx, &deployHookRun{cfg: appConfig, phase: appconfig.DeployHookPreBuild}); err != nil {
+		return nil, err
+	}
+
+	groups, buildApp, err := processImageBuilds(ctx, appConfig)
+	if err != nil {
+		return nil, err
+	}
+	// the configs are copied up front, the app's build mutates its own
+	groupConfigs := make([]*appconfig.Config, len(groups))
+	for i, group := range groups {
+		groupConfigs[i] = appConfig.ForProcessBuild(group)
+	}
+
+	// the app's image comes first, as an empty group
+	var (
+		targets      []string
+		configs      []*appconfig.Config
+		images       []*imgsrc.DeploymentImage
+		builderState = &imageBuilder{
+			wireguard:    flag.GetWireguard(ctx),
+			recreate:     flag.GetRecreateBuilder(ctx),
+			httpFailover: flag.GetHTTPSFailover(ctx),
+		}
+	)
+	if buildApp {
+		targets, configs = append(targets, ""), append(configs, appConfig)
+	}
+	targets, configs = append(targets, groups...), append(configs, groupConfigs...)
+	images = make([]*imgsrc.DeploymentImage, len(targets))
+
+	buildTarget := func(ctx context.Context, i int) (err error) {
+		if images[i], err = buildOneImage(ctx, app, configs[i], targets[i], builderState); err != nil && targets[i] != "" {
+			return fmt.Errorf("%s process group: %w", targets[i], err)
+		}
+
+		return err
+	}
+
+	// The first build settles the builder, creating it or failing over to HTTP as needed,
+	// before the other ones share it
+	if len(targets) > 0 {
+		if err := buildTarget(ctx, 0); err != nil {
+			return nil, fmt.Errorf("failed to fetch an image or build from source: %w", err)
+		}
+	}
+	if len(targets) > 1 {
+		ios := iostreams.FromContext(ctx)
+		var outMu sync.Mutex
+		builds, buildCtx := errgroup.WithContext(ctx)
+		for i := 1; i < len(targets); i++ {
+			builds.Go(func() error {
+				// concurrent builds write whole lines, each labelled with its group
+				label := targets[i]
+				prefixed := func(w io.Writer) *hookOutput {
+					return &hookOutput{line: func(line string) {
+						outMu.Lock()
+						defer outMu.Unlock()
+						fmt.Fprintf(w, "[%s] %s\n", label, line)
+					}}
+				}
+				out, errOut := prefixed(ios.Out), prefixed(ios.ErrOut)
+				defer out.Flush()
+				defer errOut.Flush()
+
+				buildIO := *ios
+				buildIO.Out, buildIO.ErrOut = out, errOut
+
+				return buildTarget(iostreams.NewContext(buildCtx, &buildIO), i)
+			})
+		}
+		if err := builds.Wait(); err != nil {
+			return nil, fmt.Errorf("failed to fetch an image or build from source: %w", err)
+		}
+	}
+
+	img := &imgsrc.DeploymentImage{}
+	if buildApp {
+		img, images = images[0], images[1:]
+	}
+	groupImages := images
+
+	if len(groups) > 0 {
+		img.ProcessImages = make(map[string]string, len(groups))
+		for i, group := range groups {
+			img.ProcessImages[group] = groupImages[i].Tag
+		}
+	}
+
+	if err := runDeployHooks(ctx, &deployHookRun{cfg: appConfig, phase: appconfig.DeployHookPostBuild, image: img.Tag}); err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// processImageBuilds returns the process groups to build images of their own for, and
+// whether the app's image is needed too. --process-groups limits the builds to the
+// images the deployed groups run, and a prebuilt image replaces them all. When the app's
+// image isn't built, the deploy takes it from the machines running it.
+func processImageBuilds(ctx context.Context, appConfig *appconfig.Config) (groups []string, buildApp bool, err error) {
+	if ref, err := fetchImageRef(ctx, appConfig); err != nil || ref != "" {
+		return nil, true, err
+	}
+
+	selected := flag.GetNonEmptyStringSlice(ctx, "process-groups")
+	if len(selected) == 0 {
+		return appConfig.ProcessBuildGroups(), true, nil
+	}
+
+	for _, group := range appConfig.ProcessBuildGroups() {
+		if slices.Contains(selected, group) {
+			groups = append(groups, group)
+		}
+	}
+	buildApp = slices.ContainsFunc(selected, func(group string) bool {
+		return !slices.Contains(groups, group)
+	})
+
+	return groups, buildApp, nil
+}
+
+// imageBuilder is the builder the images of a deploy share: how it's reached, and whether
+// it must be recreated.
+type imageBuilder struct {
+	mu           sync.Mutex
+	wireguard    bool
+	recreate     bool
+	httpFailover bool
+	// generation counts failovers, so that builds failing together fail over once
+	generation int
+}
+
+// buildOneImage fetches or builds a single image, failing over to building without
+// WireGuard, or with a new builder, when the builder can't be reached. Failovers are
+// serialized, and the first build to fail over does it for all of them.
+func buildOneImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Config, processGroup string, builder *imageBuilder) (*imgsrc.DeploymentImage, error) {
+	span := trace.SpanFromContext(ctx)
+
+	builder.mu.Lock()
+	usingWireguard, recreateBuilder, generation := builder.wireguard, builder.recreate, builder.generation
+	// the builder is recreated once, by the first build
+	builder.recreate = false
+	builder.mu.Unlock()
+
+	dockerfileMaterializer := imgsrc.NewDockerfileMaterializer()
+	img, err := determineImage(ctx, app, appConfig, processGroup, usingWireguard, recreateBuilder, dockerfileMaterializer)
+	if err != nil {
+		noBuilder := strings.Contains(err.Error(), "Could not find App")
+		if noBuilder || (usingWireguard && builder.httpFailover) {
+			builder.mu.Lock()
+			recreate := false
+			if builder.generation == generation {
+				builder.generation++
+				builder.wireguard = false
+				recreate = noBuilder || recreateBuilder
+			}
+			span.SetAttributes(attribute.String("builder.failover_error", err.Error()))
+			span.AddEvent("using http failover")
+			img, err = determineImage(ctx, app, appConfig, processGroup, false, recreate, dockerfileMaterializer)
+			builder.mu.Unlock()
+		}
+	}
+	if cleanupErr := dockerfileMaterializer.Close(); cleanupErr != nil {
+		err = errors.Join(err, cleanupErr)
+	}
+
+	return img, err
+}
+
 func parseDurationFlag(ctx context.Context, flagName string) (*time.Duration, error) {
 	if !flag.IsSpecified(ctx, flagName) {
 		return nil, nil
@@ -525,19 +799,22 @@ func deployToMachines(
 	var status metrics.DeployStatusPayload
 	status.Operator, status.AgentName = metrics.OperatorFromSignals(clientsignals.DetectOnce())
 
-	metrics.Started(ctx, "deploy")
-	// TODO: remove this once there is nothing upstream using it
-	metrics.Started(ctx, "deploy_machines")
-
-	defer func() {
-		if err != nil {
-			status.Error = err.Error()
-		}
-		status.TraceID = span.SpanContext().TraceID().String()
-		status.Duration = time.Since(startTime)
-		metrics.DeployStatus(ctx, status)
-		metrics.Status(ctx, "deploy_machines", err == nil)
-	}()
+	// Dry runs don't deploy anything, don't count them as deploys
+	if !flag.GetBool(ctx, "dry-run") {
+		metrics.Started(ctx, "deploy")
+		// TODO: remove this once there is nothing upstream using it
+		metrics.Started(ctx, "deploy_machines")
+
+		defer func() {
+			if err != nil {
+				status.Error = err.Error()
+			}
+			status.TraceID = span.SpanContext().TraceID().String()
+			status.Duration = time.Since(startTime)
+			metrics.DeployStatus(ctx, status)
+			metrics.Status(ctx, "deploy_machines", err == nil)
+		}()
+	}
 
 	releaseCmdTimeout, err := parseDurationFlag(ctx, "release-command-timeout")
 	if err != nil {
@@ -656,6 +933,7 @@ func deployToMachines(
 	args := MachineDeploymentArgs{
 		App:                   app,
 		DeploymentImage:       img.Tag,
+		ProcessImages:         img.ProcessImages,
 		Strategy:              flag.GetString(ctx, "strategy"),
 		EnvFromFlags:          flag.GetStringArray(ctx, "env"),
 		PrimaryRegionFlag:     status.PrimaryRegion,
@@ -707,6 +985,24 @@ func deployToMachines(
 		return nil
 	}
 
+	if flag.GetBool(ctx, "dry-run") {
+		args.DryRun = true
+		md, err := NewMachineDeployment(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		plan, err := md.Plan(ctx)
+		if err != nil {
+			return err
+		}
+
+		return renderPlan(io.Out, io.ColorScheme(), plan, config.FromContext(ctx).JSONOutput)
+	}
+
+	// Journal the deploy so it can be resumed if it gets interrupted
+	args.Journal = newDeployJournal(journalPath(ctx, app.Name), NewManifest(app.Name, cfg, args))
+
 	md, err := NewMachineDeployment(ctx, args)
 	if err != nil {
 		sentry.CaptureExceptionWithFlapsAppInfo(ctx, err, "deploy", app)
@@ -55,7 +55,7 @@ func multipleDockerfile(ctx context.Context, appConfig *appconfig.Config) error
 
 // determineImage picks the deployment strategy, builds the image and returns a
 // DeploymentImage struct
-func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Config, useWG, recreateBuilder bool, dockerfileMaterializer *imgsrc.DockerfileMaterializer) (img *imgsrc.DeploymentImage, err error) {
+func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Config, processGroup string, useWG, recreateBuilder bool, dockerfileMaterializer *imgsrc.DockerfileMaterializer) (img *imgsrc.DeploymentImage, err error) {
 	ctx, span := tracing.GetTracer().Start(ctx, "determine_image")
 	defer span.End()
 
@@ -103,9 +103,12 @@ func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Co
 
 	span.SetAttributes(attribute.String("daemon_type", daemonType.String()))
 
-	if err := multipleDockerfile(ctx, appConfig); err != nil {
-		span.AddEvent("found multiple dockerfiles")
-		terminal.Warnf("%s", err.Error())
+	// process groups building their own images pick their Dockerfile on purpose
+	if processGroup == "" {
+		if err := multipleDockerfile(ctx, appConfig); err != nil {
+			span.AddEvent("found multiple dockerfiles")
+			terminal.Warnf("%s", err.Error())
+		}
 	}
 
 	org, err := uiexClient.GetOrganization(ctx, app.Organization.Slug)
@@ -145,7 +148,7 @@ func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Co
 			WorkingDir: state.WorkingDirectory(ctx),
 			Publish:    flag.GetBool(ctx, "push") || !flag.GetBuildOnly(ctx),
 			ImageRef:   imageRef,
-			ImageLabel: flag.GetString(ctx, "image-label"),
+			ImageLabel: imageLabel(ctx, processGroup),
 		}
 
 		span.SetAttributes(opts.ToSpanAttributes()...)
@@ -181,7 +184,7 @@ func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Co
 		AppName:              appConfig.AppName,
 		WorkingDir:           state.WorkingDirectory(ctx),
 		Publish:              flag.GetBool(ctx, "push") || !flag.GetBuildOnly(ctx),
-		ImageLabel:           flag.GetString(ctx, "image-label"),
+		ImageLabel:           imageLabel(ctx, processGroup),
 		NoCache:              flag.GetBool(ctx, "no-cache"),
 		BuiltIn:              build.Builtin,
 		BuiltInSettings:      build.Settings,
@@ -257,6 +260,22 @@ func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Co
 
 	span.SetAttributes(opts.ToSpanAttributes()...)
 
+	// an image already built from the same source is reused rather than rebuilt
+	cacheKey := buildCacheKey(ctx, &opts)
+	if cacheKey != "" && !opts.NoCache && !flag.GetBool(ctx, "force-build") {
+		if img, err = imgsrc.FindCachedBuild(ctx, opts.AppName, cacheKey); err != nil {
+			terminal.Warnf("Failed to look up an image built from the same source, building it: %v\n", err)
+			err = nil
+		} else if img != nil {
+			span.AddEvent("using cached build")
+			tb.Printf("Source unchanged since the image was built, skipping the build (use --force-build to rebuild)\n")
+			tb.Printf("image: %s\n", img.Tag)
+			tb.Printf("image size: %s\n", humanize.Bytes(uint64(img.Size)))
+
+			return
+		}
+	}
+
 	// finally, build the image
 	heartbeat, err := resolver.StartHeartbeat(ctx)
 	if err != nil {
@@ -282,11 +301,54 @@ func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Co
 	if err == nil {
 		tb.Printf("image: %s\n", img.Tag)
 		tb.Printf("image size: %s\n", humanize.Bytes(uint64(img.Size)))
+
+		if cacheKey != "" {
+			if err := imgsrc.TagCachedBuild(ctx, img, opts.AppName, cacheKey); err != nil {
+				terminal.Warnf("Failed to tag the image for reuse by later deploys: %v\n", err)
+			}
+		}
 	}
 
 	return
 }
 
+// buildCacheKey returns the key published images built with opts are reused by, and
+// labels them with it. It returns an empty key if the image isn't published, or the
+// build can't be reused.
+func buildCacheKey(ctx context.Context, opts *imgsrc.ImageOptions) string {
+	if !opts.Publish {
+		return ""
+	}
+
+	key, err := imgsrc.BuildCacheKey(*opts)
+	if err != nil {
+		terminal.Warnf("Failed to compute the build cache key, the image won't be reused: %v\n", err)
+
+		return ""
+	}
+	if key == "" {
+		return ""
+	}
+
+	if opts.Label == nil {
+		opts.Label = make(map[string]string)
+	}
+	opts.Label[imgsrc.BuildCacheLabel] = key
+
+	return key
+}
+
+// imageLabel returns the --image-label of the image, suffixed with the process group for
+// the images of process groups so they don't share a tag with the app's.
+func imageLabel(ctx context.Context, processGroup string) string {
+	label := flag.GetString(ctx, "image-label")
+	if label != "" && processGroup != "" {
+		label += "-" + processGroup
+	}
+
+	return label
+}
+
 // resolveDockerfilePath returns HTTP(S) URLs from app config unchanged and
 // makes local Dockerfile paths absolute.
 func resolveDockerfilePath(ctx context.Context, appConfig *appconfig.Config) (path string, err error) {
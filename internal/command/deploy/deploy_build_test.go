@@ -6,9 +6,12 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/flag/flagctx"
 	"github.com/superfly/flyctl/internal/state"
 )
 
@@ -97,3 +100,65 @@ func TestResolveDockerfilePath(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0o644))
+
+	opts := &imgsrc.ImageOptions{WorkingDir: dir, Label: map[string]string{"team": "web"}}
+	assert.Empty(t, buildCacheKey(context.Background(), opts), "unpublished images aren't reused")
+	assert.NotContains(t, opts.Label, imgsrc.BuildCacheLabel)
+
+	opts.Publish = true
+	key := buildCacheKey(context.Background(), opts)
+	require.NotEmpty(t, key)
+	assert.Equal(t, map[string]string{"team": "web", imgsrc.BuildCacheLabel: key}, opts.Label)
+
+	opts = &imgsrc.ImageOptions{WorkingDir: dir, Publish: true, BuiltIn: "node"}
+	assert.Empty(t, buildCacheKey(context.Background(), opts))
+	assert.Nil(t, opts.Label)
+}
+
+func TestProcessImageBuilds(t *testing.T) {
+	cfg := &appconfig.Config{
+		Processes: map[string]string{"web": "npm start", "worker": "npm run worker", "cron": "npm run cron"},
+		Build: &appconfig.Build{Processes: map[string]*appconfig.ProcessBuild{
+			"worker": {DockerBuildTarget: "worker"},
+			"cron":   {DockerBuildTarget: "cron"},
+		}},
+	}
+
+	newCtx := func(args ...string) context.Context {
+		flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flagSet.String("image", "", "")
+		flagSet.String("image-label", "", "")
+		flagSet.StringSlice("process-groups", nil, "")
+		require.NoError(t, flagSet.Parse(args))
+
+		return flagctx.NewContext(context.Background(), flagSet)
+	}
+
+	groups, buildApp, err := processImageBuilds(newCtx(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cron", "worker"}, groups)
+	assert.True(t, buildApp)
+
+	groups, buildApp, err = processImageBuilds(newCtx("--process-groups", "worker"), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"worker"}, groups)
+	assert.False(t, buildApp, "the app's image isn't needed by the worker")
+
+	groups, buildApp, err = processImageBuilds(newCtx("--process-groups", "web,cron"), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cron"}, groups)
+	assert.True(t, buildApp)
+
+	groups, buildApp, err = processImageBuilds(newCtx("--image", "nginx"), cfg)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+	assert.True(t, buildApp)
+
+	assert.Empty(t, imageLabel(newCtx(), "worker"))
+	assert.Equal(t, "v1", imageLabel(newCtx("--image-label", "v1"), ""))
+	assert.Equal(t, "v1-worker", imageLabel(newCtx("--image-label", "v1"), "worker"))
+}
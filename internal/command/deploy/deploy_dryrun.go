@@ -0,0 +1,291 @@
+package deploy
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/tracing"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// dryRunImage is the image shown in a dry run plan when the image would be built from source.
+const dryRunImage = "<image built from source>"
+
+const (
+	PlanActionCreate    = "create"
+	PlanActionDestroy   = "destroy"
+	PlanActionUpdate    = "update"
+	PlanActionReplace   = "replace"
+	PlanActionRestart   = "restart"
+	PlanActionUnchanged = "unchanged"
+)
+
+// DeploymentPlan describes what a deploy would do to an app's machines and volumes.
+type DeploymentPlan struct {
+	App   string `json:"app"`
+	Image string `json:"image"`
+	// ProcessImages are the images of the process groups that don't run Image
+	ProcessImages  map[string]string `json:"process_images,omitempty"`
+	Strategy       string            `json:"strategy"`
+	FirstDeploy    bool              `json:"first_deploy"`
+	ReleaseCommand string            `json:"release_command,omitempty"`
+	Volumes        []PlannedVolume   `json:"volumes"`
+	Groups         []PlannedGroup    `json:"groups"`
+	Machines       []PlannedMachine  `json:"machines"`
+}
+
+// PlannedVolume is a volume created by a first deploy.
+type PlannedVolume struct {
+	Name         string `json:"name"`
+	ProcessGroup string `json:"process_group"`
+	Region       string `json:"region"`
+	SizeGB       int    `json:"size_gb"`
+}
+
+// PlannedGroup is a process group that gains or loses all of its machines.
+type PlannedGroup struct {
+	Name     string `json:"name"`
+	Action   string `json:"action"`
+	Machines int    `json:"machines"`
+	Standbys int    `json:"standbys,omitempty"`
+	Image    string `json:"image,omitempty"`
+}
+
+// PlannedMachine is an existing machine and what the deploy does to it. Diff is empty
+// when only the release metadata of the machine changes.
+type PlannedMachine struct {
+	ID           string `json:"id"`
+	ProcessGroup string `json:"process_group"`
+	Region       string `json:"region"`
+	Action       string `json:"action"`
+	Diff         string `json:"diff,omitempty"`
+}
+
+// Plan computes the deployment plan against the app's current machines without
+// changing anything.
+func (md *machineDeployment) Plan(ctx context.Context) (*DeploymentPlan, error) {
+	_, span := tracing.GetTracer().Start(ctx, "deployment_plan")
+	defer span.End()
+
+	plan := &DeploymentPlan{
+		App:         md.app.Name,
+		Image:       md.img,
+		Strategy:    md.strategy,
+		FirstDeploy: md.isFirstDeploy,
+		Volumes:     []PlannedVolume{},
+		Groups:      []PlannedGroup{},
+		Machines:    []PlannedMachine{},
+	}
+
+	for _, group := range md.appConfig.ProcessNames() {
+		if img := md.imageFor(group); img != md.img {
+			if plan.ProcessImages == nil {
+				plan.ProcessImages = map[string]string{}
+			}
+			plan.ProcessImages[group] = img
+		}
+	}
+
+	if md.restartOnly {
+		for _, lm := range md.machineSet.GetMachines() {
+			plan.Machines = append(plan.Machines, plannedMachine(lm.Machine(), PlanActionRestart, ""))
+		}
+
+		return plan, nil
+	}
+
+	if !md.skipReleaseCommand && md.appConfig.Deploy != nil {
+		plan.ReleaseCommand = md.appConfig.Deploy.ReleaseCommand
+	}
+
+	if md.isFirstDeploy {
+		volumes, err := md.volumesForFirstDeploy()
+		if err != nil {
+			tracing.RecordError(span, err, "failed to plan volumes")
+
+			return nil, err
+		}
+		for _, v := range volumes {
+			plan.Volumes = append(plan.Volumes, PlannedVolume{
+				Name:         v.request.Name,
+				ProcessGroup: v.group,
+				Region:       v.request.Region,
+				SizeGB:       *v.request.SizeGb,
+			})
+		}
+	}
+
+	diff := md.resolveProcessGroupChanges()
+
+	for _, name := range slices.Sorted(maps.Keys(diff.groupsToRemove)) {
+		plan.Groups = append(plan.Groups, PlannedGroup{Name: name, Action: PlanActionDestroy, Machines: diff.groupsToRemove[name]})
+	}
+	if !md.updateOnly {
+		for _, name := range slices.Sorted(maps.Keys(diff.groupsNeedingMachines)) {
+			machines, standbys, err := md.machinesForNewGroup(name)
+			if err != nil {
+				tracing.RecordError(span, err, "failed to plan process group")
+
+				return nil, err
+			}
+			plan.Groups = append(plan.Groups, PlannedGroup{Name: name, Action: PlanActionCreate, Machines: machines, Standbys: standbys, Image: md.imageFor(name)})
+		}
+	}
+
+	removed := map[string]bool{}
+	for _, lm := range diff.machinesToRemove {
+		removed[lm.Machine().ID] = true
+		plan.Machines = append(plan.Machines, plannedMachine(lm.Machine(), PlanActionDestroy, ""))
+	}
+
+	pairings, err := md.planMachinePairings(removed)
+	if err != nil {
+		tracing.RecordError(span, err, "failed to plan machine updates")
+
+		return nil, err
+	}
+	for _, p := range pairings {
+		action := PlanActionUpdate
+		switch {
+		case p.newMachine.State == "replacing":
+			action = PlanActionReplace
+		case compareConfigs(ctx, p.oldMachine.Config, p.newMachine.Config):
+			action = PlanActionUnchanged
+		}
+		plan.Machines = append(plan.Machines, plannedMachine(p.oldMachine, action, diffConfigs(p.oldMachine.Config, withReleaseDataOf(p.newMachine.Config, p.oldMachine.Config))))
+	}
+
+	slices.SortStableFunc(plan.Machines, func(a, b PlannedMachine) int {
+		return cmp.Or(cmp.Compare(a.ProcessGroup, b.ProcessGroup), cmp.Compare(a.ID, b.ID))
+	})
+
+	return plan, nil
+}
+
+// planMachinePairings pairs every machine that survives the deploy with the machine
+// it would become, the same way updateExistingMachinesWRecovery does.
+func (md *machineDeployment) planMachinePairings(skip map[string]bool) ([]machinePairing, error) {
+	var pairings []machinePairing
+	for _, lm := range md.machineSet.GetMachines() {
+		oldMachine := lm.Machine()
+		if skip[oldMachine.ID] {
+			continue
+		}
+
+		li, err := md.launchInputForUpdate(oldMachine)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update machine configuration for %s: %w", lm.FormattedMachineId(), err)
+		}
+
+		pairings = append(pairings, machinePairingForUpdate(oldMachine, li))
+	}
+
+	return pairings, nil
+}
+
+// withReleaseDataOf returns a copy of config carrying the release metadata of other, so
+// that diffs only show the changes coming from the app config and image.
+func withReleaseDataOf(config, other *fly.MachineConfig) *fly.MachineConfig {
+	config = machine.CloneConfig(config)
+	for _, key := range []string{fly.MachineConfigMetadataKeyFlyReleaseId, fly.MachineConfigMetadataKeyFlyReleaseVersion} {
+		if v, ok := other.Metadata[key]; ok {
+			config.Metadata[key] = v
+		} else {
+			delete(config.Metadata, key)
+		}
+	}
+
+	return config
+}
+
+func plannedMachine(m *fly.Machine, action, diff string) PlannedMachine {
+	return PlannedMachine{
+		ID:           m.ID,
+		ProcessGroup: m.ProcessGroup(),
+		Region:       m.Region,
+		Action:       action,
+		Diff:         diff,
+	}
+}
+
+// renderPlan prints the plan as JSON or as a human readable summary.
+func renderPlan(w io.Writer, colorize *iostreams.ColorScheme, plan *DeploymentPlan, asJSON bool) error {
+	if asJSON {
+		return render.JSON(w, plan)
+	}
+
+	fmt.Fprintf(w, "Deployment plan for app %s using the %s strategy\n", colorize.Bold(plan.App), plan.Strategy)
+	fmt.Fprintf(w, "Image: %s\n", plan.Image)
+	for _, group := range slices.Sorted(maps.Keys(plan.ProcessImages)) {
+		fmt.Fprintf(w, "Image for process group '%s': %s\n", group, plan.ProcessImages[group])
+	}
+	if plan.ReleaseCommand != "" {
+		fmt.Fprintf(w, "Release command: %s\n", plan.ReleaseCommand)
+	}
+
+	if len(plan.Volumes) > 0 {
+		fmt.Fprintln(w, "\nVolumes:")
+		for _, v := range plan.Volumes {
+			fmt.Fprintf(w, " %s create a %d GB volume named '%s' in %s for process group '%s'\n", colorize.Green("+"), v.SizeGB, v.Name, v.Region, v.ProcessGroup)
+		}
+	}
+
+	if len(plan.Groups) > 0 {
+		fmt.Fprintln(w, "\nProcess groups:")
+		for _, g := range plan.Groups {
+			switch g.Action {
+			case PlanActionCreate:
+				description := fmt.Sprintf("%d \"%s\" machines", g.Machines, g.Name)
+				if g.Machines == 1 {
+					description = fmt.Sprintf("1 \"%s\" machine", g.Name)
+				}
+				if g.Standbys > 0 {
+					description += fmt.Sprintf(" and %d standby", g.Standbys)
+				}
+				fmt.Fprintf(w, " %s create %s\n", colorize.Green("+"), description)
+			case PlanActionDestroy:
+				fmt.Fprintf(w, " %s destroy %d \"%s\" machines\n", colorize.Red("-"), g.Machines, g.Name)
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "\nMachines:")
+	if len(plan.Machines) == 0 {
+		fmt.Fprintln(w, " no existing machines")
+	}
+	for _, m := range plan.Machines {
+		var symbol string
+		switch m.Action {
+		case PlanActionDestroy:
+			symbol = colorize.Red("-")
+		case PlanActionReplace:
+			symbol = colorize.Yellow("!")
+		case PlanActionUpdate, PlanActionRestart:
+			symbol = colorize.Yellow("~")
+		default:
+			symbol = " "
+		}
+		fmt.Fprintf(w, " %s %s %s [%s] in %s\n", symbol, m.Action, colorize.Bold(m.ID), m.ProcessGroup, m.Region)
+
+		switch {
+		case m.Diff != "":
+			for line := range strings.SplitSeq(strings.TrimRight(m.Diff, "\n"), "\n") {
+				fmt.Fprintf(w, "     %s\n", line)
+			}
+		case m.Action == PlanActionUpdate || m.Action == PlanActionReplace:
+			fmt.Fprintln(w, "     only the release metadata changes")
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s\n", colorize.Gray("This was a dry run, nothing was changed."))
+
+	return nil
+}
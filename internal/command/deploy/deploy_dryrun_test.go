@@ -0,0 +1,139 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/flag/flagctx"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestPlan(t *testing.T) {
+	makeTerminalLoggerQuiet(t)
+
+	md, err := stabMachineDeployment(&appconfig.Config{
+		AppName:       "my-cool-app",
+		PrimaryRegion: "scl",
+		Processes: map[string]string{
+			"app":    "run-app",
+			"worker": "run-worker",
+		},
+		Deploy: &appconfig.Deploy{ReleaseCommand: "migrate"},
+	})
+	require.NoError(t, err)
+	md.strategy = "rolling"
+	md.increasedAvailability = true
+
+	ios, _, _, _ := iostreams.Test()
+
+	current := func(id, group string) *fly.Machine {
+		md.img = "super/balloon"
+		li, err := md.launchInputForLaunch("", nil, nil)
+		require.NoError(t, err)
+		li.Config.Metadata[fly.MachineConfigMetadataKeyFlyProcessGroup] = group
+		li.Config.Metadata[fly.MachineConfigMetadataKeyFlyReleaseId] = "old_release"
+
+		return &fly.Machine{
+			ID:         id,
+			Region:     "scl",
+			Config:     li.Config,
+			HostStatus: fly.HostStatusOk,
+			State:      fly.MachineStateStarted,
+		}
+	}
+
+	unreachable := current("m2", "app")
+	unreachable.HostStatus = fly.HostStatusUnreachable
+	md.machineSet = machine.NewMachineSet(nil, ios, "my-cool-app", []*fly.Machine{
+		current("m1", "app"),
+		unreachable,
+		current("m3", "cron"),
+	}, false)
+	md.img = "super/globe"
+	md.processImages = map[string]string{"worker": "super/chrome"}
+
+	plan, err := md.Plan(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "migrate", plan.ReleaseCommand)
+	assert.Equal(t, "super/globe", plan.Image)
+	assert.Equal(t, map[string]string{"worker": "super/chrome"}, plan.ProcessImages)
+	assert.Empty(t, plan.Volumes)
+	assert.Equal(t, []PlannedGroup{
+		{Name: "cron", Action: PlanActionDestroy, Machines: 1},
+		{Name: "worker", Action: PlanActionCreate, Machines: 1, Standbys: 1, Image: "super/chrome"},
+	}, plan.Groups)
+
+	require.Len(t, plan.Machines, 3)
+	assert.Equal(t, "m1", plan.Machines[0].ID)
+	assert.Equal(t, PlanActionUpdate, plan.Machines[0].Action)
+	assert.Contains(t, plan.Machines[0].Diff, "super/globe")
+	assert.NotContains(t, plan.Machines[0].Diff, "old_release")
+	assert.Equal(t, "m2", plan.Machines[1].ID)
+	assert.Equal(t, PlanActionReplace, plan.Machines[1].Action)
+	assert.Equal(t, "m3", plan.Machines[2].ID)
+	assert.Equal(t, PlanActionDestroy, plan.Machines[2].Action)
+	assert.Empty(t, plan.Machines[2].Diff)
+
+	var out bytes.Buffer
+	require.NoError(t, renderPlan(&out, ios.ColorScheme(), plan, true))
+	var decoded DeploymentPlan
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, *plan, decoded)
+
+	out.Reset()
+	require.NoError(t, renderPlan(&out, ios.ColorScheme(), plan, false))
+	assert.Contains(t, out.String(), "Image for process group 'worker': super/chrome")
+	assert.Contains(t, out.String(), "create 1 \"worker\" machine and 1 standby")
+	assert.Contains(t, out.String(), "destroy 1 \"cron\" machines")
+	assert.Contains(t, out.String(), "replace m2 [app] in scl")
+	assert.Contains(t, out.String(), "This was a dry run")
+}
+
+func TestPlanFirstDeployVolumes(t *testing.T) {
+	makeTerminalLoggerQuiet(t)
+
+	md, err := stabMachineDeployment(&appconfig.Config{
+		AppName:       "my-cool-app",
+		PrimaryRegion: "scl",
+		Mounts:        []appconfig.Mount{{Source: "data", Destination: "/data"}},
+	})
+	require.NoError(t, err)
+	md.isFirstDeploy = true
+	md.volumes = map[string][]fly.Volume{}
+
+	plan, err := md.Plan(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, plan.FirstDeploy)
+	assert.Equal(t, []PlannedVolume{{Name: "data", ProcessGroup: "app", Region: "scl", SizeGB: DefaultVolumeInitialSizeGB}}, plan.Volumes)
+	assert.Equal(t, []PlannedGroup{{Name: "app", Action: PlanActionCreate, Machines: 1, Image: "super/balloon"}}, plan.Groups)
+	assert.Empty(t, plan.Machines)
+}
+
+func TestValidateDryRun(t *testing.T) {
+	newCtx := func(args ...string) context.Context {
+		flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flagSet.Bool("dry-run", false, "")
+		flagSet.String("resume", "", "")
+		flagSet.String("from-manifest", "", "")
+		flagSet.String("export-manifest", "", "")
+		require.NoError(t, flagSet.Parse(args))
+
+		return flagctx.NewContext(context.Background(), flagSet)
+	}
+
+	assert.NoError(t, validateDryRun(newCtx("--dry-run")))
+	assert.NoError(t, validateDryRun(newCtx("--resume", "finish")))
+	assert.EqualError(t, validateDryRun(newCtx("--dry-run", "--resume", "finish")), "--dry-run can't be used with --resume")
+	assert.EqualError(t, validateDryRun(newCtx("--dry-run", "--from-manifest", "manifest.json")), "--dry-run can't be used with --from-manifest")
+	assert.EqualError(t, validateDryRun(newCtx("--dry-run", "--export-manifest", "manifest.json")), "--dry-run can't be used with --export-manifest")
+}
@@ -0,0 +1,79 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/statuslogger"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+const (
+	outputText   = "text"
+	outputNDJSON = "ndjson"
+)
+
+// withEventStream sets up the deploy event stream requested with --output. In ndjson mode,
+// events go to stdout or --output-file and the human-readable output, logs included, moves
+// to stderr so that stdout only carries events. The returned function ends the stream with the
+// outcome of the deploy.
+func withEventStream(ctx context.Context) (context.Context, func(err error), error) {
+	var (
+		output = flag.GetString(ctx, "output")
+		path   = flag.GetString(ctx, "output-file")
+	)
+
+	switch output {
+	case "", outputText:
+		if path != "" {
+			return nil, nil, fmt.Errorf("--output-file requires --output %s", outputNDJSON)
+		}
+
+		return ctx, func(error) {}, nil
+	case outputNDJSON:
+	default:
+		return nil, nil, fmt.Errorf("invalid value %q for --output, must be '%s' or '%s'", output, outputText, outputNDJSON)
+	}
+
+	streams := iostreams.FromContext(ctx)
+
+	var (
+		w       io.Writer = streams.Out
+		closeFn           = func() error { return nil }
+	)
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create event stream file: %w", err)
+		}
+		w, closeFn = f, f.Close
+	} else {
+		human := *streams
+		human.Out = streams.ErrOut
+		ctx = iostreams.NewContext(ctx, &human)
+
+		// the terminal logger writes to stdout too
+		restore := terminal.RedirectTo(streams.ErrOut, streams.IsStderrTTY())
+		closeFn = func() error {
+			restore()
+
+			return nil
+		}
+	}
+
+	sink := statuslogger.NewEventSink(w)
+	ctx = statuslogger.NewEventSinkContext(ctx, sink)
+
+	return ctx, func(err error) {
+		ev := statuslogger.Event{Type: statuslogger.EventDeployFinished, Status: "success"}
+		if err != nil {
+			ev.Status, ev.Error = "failure", err.Error()
+		}
+		sink.Emit(ev)
+		closeFn()
+	}, nil
+}
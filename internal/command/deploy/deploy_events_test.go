@@ -0,0 +1,50 @@
+package deploy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/flag/flagctx"
+	"github.com/superfly/flyctl/internal/statuslogger"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+func TestWithEventStreamKeepsStdoutForEvents(t *testing.T) {
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.String("output", "", "")
+	flagSet.String("output-file", "", "")
+	require.NoError(t, flagSet.Parse([]string{"--output", outputNDJSON}))
+
+	ios, _, out, errOut := iostreams.Test()
+	ctx := iostreams.NewContext(flagctx.NewContext(context.Background(), flagSet), ios)
+
+	ctx, finish, err := withEventStream(ctx)
+	require.NoError(t, err)
+
+	fmt.Fprintln(iostreams.FromContext(ctx).Out, "Deploying my-app")
+	terminal.Warnf("journal not saved\n")
+	statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventRollback, Decision: "skip"})
+	finish(nil)
+
+	scanner := bufio.NewScanner(out)
+	var lines int
+	for scanner.Scan() {
+		lines++
+		assert.True(t, json.Valid(scanner.Bytes()), "not an event: %s", scanner.Text())
+	}
+	assert.Equal(t, 2, lines)
+	assert.Contains(t, errOut.String(), "Deploying my-app")
+	assert.Contains(t, errOut.String(), "journal not saved")
+
+	// the terminal logger is back on stdout once the stream ends
+	errOut.Reset()
+	terminal.Warnf("after the deploy\n")
+	assert.NotContains(t, errOut.String(), "after the deploy")
+}
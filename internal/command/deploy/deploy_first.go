@@ -129,21 +129,54 @@ func (md *machineDeployment) provisionVolumesOnFirstDeploy(ctx context.Context)
 		return nil
 	}
 
-	// md.setVolumes already queried for existent unattached volumes, do not create more
+	volumes, err := md.volumesForFirstDeploy()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range volumes {
+		fmt.Fprintf(
+			md.io.Out,
+			"Creating a %d GB volume named '%s' for process group '%s'. "+
+				"Use 'fly vol extend' to increase its size\n",
+			*v.request.SizeGb, v.request.Name, v.group,
+		)
+
+		vol, err := md.flapsClient.CreateVolume(ctx, md.app.Name, v.request)
+		if err != nil {
+			return err
+		}
+
+		md.volumes[v.request.Name] = append(md.volumes[v.request.Name], *vol)
+	}
+
+	return nil
+}
+
+type firstDeployVolume struct {
+	group   string
+	request fly.CreateVolumeRequest
+}
+
+// volumesForFirstDeploy returns the volumes a first deploy needs to create on top of
+// the unattached volumes md.setVolumes already found.
+func (md *machineDeployment) volumesForFirstDeploy() ([]firstDeployVolume, error) {
 	existentVolumes := lo.MapValues(md.volumes, func(vs []fly.Volume, _ string) int {
 		return len(vs)
 	})
 
+	var volumes []firstDeployVolume
+
 	// The logic here is to provision one volume per process group that needs it only on the primary region
 	for _, groupName := range md.appConfig.ProcessNames() {
 		groupConfig, err := md.appConfig.Flatten(groupName)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		mConfig, err := md.appConfig.ToMachineConfig(groupName, nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		guest := md.machineGuest
 		if mConfig.Guest != nil {
@@ -170,32 +203,21 @@ func (md *machineDeployment) provisionVolumesOnFirstDeploy(ctx context.Context)
 				initialSize = DefaultVolumeInitialSizeGB
 			}
 
-			fmt.Fprintf(
-				md.io.Out,
-				"Creating a %d GB volume named '%s' for process group '%s'. "+
-					"Use 'fly vol extend' to increase its size\n",
-				initialSize, m.Source, groupName,
-			)
-
-			input := fly.CreateVolumeRequest{
-				Name:                m.Source,
-				Region:              groupConfig.PrimaryRegion,
-				SizeGb:              new(initialSize),
-				Encrypted:           new(true),
-				ComputeRequirements: guest,
-				ComputeImage:        md.img,
-				SnapshotRetention:   m.SnapshotRetention,
-				AutoBackupEnabled:   m.ScheduledSnapshots,
-			}
-
-			vol, err := md.flapsClient.CreateVolume(ctx, md.app.Name, input)
-			if err != nil {
-				return err
-			}
-
-			md.volumes[m.Source] = append(md.volumes[m.Source], *vol)
+			volumes = append(volumes, firstDeployVolume{
+				group: groupName,
+				request: fly.CreateVolumeRequest{
+					Name:                m.Source,
+					Region:              groupConfig.PrimaryRegion,
+					SizeGb:              new(initialSize),
+					Encrypted:           new(true),
+					ComputeRequirements: guest,
+					ComputeImage:        md.imageFor(groupName),
+					SnapshotRetention:   m.SnapshotRetention,
+					AutoBackupEnabled:   m.ScheduledSnapshots,
+				},
+			})
 		}
 	}
 
-	return nil
+	return volumes, nil
 }
@@ -0,0 +1,306 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/appsecrets"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/statuslogger"
+	"github.com/superfly/flyctl/internal/tracing"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultMachineHookTimeout bounds machine hooks that don't set a timeout, the exec API needs one.
+const defaultMachineHookTimeout = 5 * time.Minute
+
+// hookError is returned when a deploy hook fails and the deploy must not go on.
+type hookError struct {
+	hook appconfig.DeployHook
+	err  error
+}
+
+func (e *hookError) Error() string {
+	return fmt.Sprintf("%s hook '%s' failed: %s", e.hook.Phase, e.hook.DisplayName(), e.err)
+}
+
+func (e *hookError) Unwrap() error {
+	return e.err
+}
+
+// errDeployRolledBack marks deploys whose machines were already rolled back, there's nothing left to resume.
+var errDeployRolledBack = errors.New("the deploy was rolled back")
+
+// runPostDeployHooks runs the post-deploy hooks. When a failing hook asks for it, the machines
+// in the deploy journal are rolled back to the config they had before the deploy. Machines
+// the deploy created are left alone.
+func (md *machineDeployment) runPostDeployHooks(ctx context.Context) error {
+	err := runDeployHooks(ctx, &deployHookRun{cfg: md.appConfig, phase: appconfig.DeployHookPostDeploy, image: md.img})
+
+	var hookErr *hookError
+	if !errors.As(err, &hookErr) || hookErr.hook.FailureAction() != appconfig.DeployHookFailureRollback {
+		return err
+	}
+
+	// Deploys from a manifest don't keep a journal, there's nothing to roll back to
+	if md.journal == nil {
+		statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventRollback, Strategy: md.strategy, Decision: "skip", Error: err.Error()})
+
+		return fmt.Errorf("%w; can't roll back, this deploy has no journal", err)
+	}
+
+	statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventRollback, Strategy: md.strategy, Decision: "rollback", Error: err.Error()})
+	fmt.Fprintf(md.io.ErrOut, "%s\nRolling back the deploy\n", err)
+
+	entries, rollbackErr := md.reconcileJournal(ctx, true)
+	if rollbackErr == nil && len(entries) == 0 {
+		// First deploys and deploys of new process groups only create machines, which the
+		// journal doesn't keep
+		return fmt.Errorf("%w; nothing was rolled back, the deploy didn't update any existing machine", err)
+	}
+	if rollbackErr == nil {
+		// Rolling back is about getting back to a known state, don't bother with fancier strategies
+		md.strategy = "rolling"
+		md.machineSet = machine.NewMachineSet(md.flapsClient, md.io, md.app.Name, lo.Map(entries, func(e *machineUpdateEntry, _ int) *fly.Machine {
+			return e.leasableMachine.Machine()
+		}), true)
+		rollbackErr = md.updateExistingMachines(ctx, entries)
+	}
+	if rollbackErr != nil {
+		return fmt.Errorf("%w; rollback also failed: %w", err, rollbackErr)
+	}
+
+	return fmt.Errorf("%w, %w", err, errDeployRolledBack)
+}
+
+// deployHookRun is what a phase's hooks get to know about the deploy.
+type deployHookRun struct {
+	cfg   *appconfig.Config
+	phase string
+	// image is the image machine hooks run on, empty before it's built.
+	image string
+	// cause is the error that failed the deploy, for on-failure hooks.
+	cause error
+}
+
+func (r *deployHookRun) env() map[string]string {
+	env := map[string]string{
+		"FLY_APP_NAME":     r.cfg.AppName,
+		"FLY_DEPLOY_PHASE": r.phase,
+	}
+	if r.image != "" {
+		env["FLY_IMAGE_REF"] = r.image
+	}
+	if r.cause != nil {
+		env["FLY_DEPLOY_ERROR"] = r.cause.Error()
+	}
+
+	return env
+}
+
+// runDeployHooks runs the hooks of a phase in order. A failing hook stops the deploy
+// unless it's configured to continue.
+func runDeployHooks(ctx context.Context, run *deployHookRun) error {
+	hooks := run.cfg.Deploy.HooksFor(run.phase)
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	ctx, span := tracing.GetTracer().Start(ctx, "deploy_hooks", trace.WithAttributes(
+		attribute.String("phase", run.phase),
+		attribute.Int("hooks", len(hooks)),
+	))
+	defer span.End()
+
+	io := iostreams.FromContext(ctx)
+	colorize := io.ColorScheme()
+
+	for _, h := range hooks {
+		// Configs fetched from the platform, such as a release's, may have been written by
+		// anyone with access to the app: their commands don't run on this machine
+		if h.MachineCommand == "" && !run.cfg.IsLocal() {
+			fmt.Fprintf(io.ErrOut, "Skipping %s hook %s, local commands only run from a local config file\n", run.phase, colorize.Bold(h.DisplayName()))
+			statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventHook, Hook: h.DisplayName(), Phase: run.phase, Status: "skipped"})
+
+			continue
+		}
+
+		fmt.Fprintf(io.Out, "Running %s hook %s\n", run.phase, colorize.Bold(h.DisplayName()))
+		statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventHook, Hook: h.DisplayName(), Phase: run.phase, Status: "running"})
+
+		start := time.Now()
+		err := runDeployHook(ctx, run, h)
+
+		ev := statuslogger.Event{Type: statuslogger.EventHook, Hook: h.DisplayName(), Phase: run.phase, Status: "success", DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			ev.Status, ev.Error = "failure", err.Error()
+		}
+		statuslogger.Emit(ctx, ev)
+
+		switch {
+		case err == nil:
+		case h.FailureAction() == appconfig.DeployHookFailureContinue:
+			terminal.Warnf("%s hook '%s' failed, continuing: %v\n", run.phase, h.DisplayName(), err)
+		default:
+			err = &hookError{hook: h, err: err}
+			tracing.RecordError(span, err, "deploy hook failed")
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runDeployHook(ctx context.Context, run *deployHookRun, h appconfig.DeployHook) error {
+	io := iostreams.FromContext(ctx)
+	out := &hookOutput{line: func(line string) {
+		fmt.Fprintf(io.Out, "  %s\n", line)
+		statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventHookOutput, Hook: h.DisplayName(), Phase: run.phase, Message: line})
+	}}
+	defer out.Flush()
+
+	if h.MachineCommand != "" {
+		return runMachineHook(ctx, run, h, out)
+	}
+
+	return runLocalHook(ctx, run, h, out)
+}
+
+func runLocalHook(ctx context.Context, run *deployHookRun, h appconfig.DeployHook, out *hookOutput) error {
+	if h.Timeout != nil && h.Timeout.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout.Duration)
+		defer cancel()
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", h.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", h.Command)
+	}
+	cmd.Env = os.Environ()
+	for k, v := range run.env() {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s", h.Timeout.Duration)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func runMachineHook(ctx context.Context, run *deployHookRun, h appconfig.DeployHook, out *hookOutput) error {
+	if run.image == "" {
+		return errors.New("there is no image to run it on")
+	}
+
+	mConfig, err := run.cfg.ToConsoleMachineConfig()
+	if err != nil {
+		return fmt.Errorf("failed to generate the hook machine configuration: %w", err)
+	}
+	mConfig.Image = run.image
+	maps.Copy(mConfig.Env, run.env())
+
+	minvers, err := appsecrets.GetMinvers(run.cfg.AppName)
+	if err != nil {
+		return err
+	}
+
+	m, cleanup, err := machine.LaunchEphemeral(ctx, run.cfg.AppName, &machine.EphemeralInput{
+		LaunchInput: fly.LaunchMachineInput{
+			Config:            mConfig,
+			Region:            run.cfg.PrimaryRegion,
+			MinSecretsVersion: minvers,
+		},
+		What: fmt.Sprintf("to run the %s hook", run.phase),
+	})
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	timeout := defaultMachineHookTimeout
+	if h.Timeout != nil && h.Timeout.Duration > 0 {
+		timeout = h.Timeout.Duration
+	}
+
+	flapsClient := flapsutil.ClientFromContext(ctx)
+	res, err := flapsClient.Exec(ctx, run.cfg.AppName, m.ID, &fly.MachineExecRequest{
+		Cmd:     h.MachineCommand,
+		Timeout: int(timeout.Seconds()),
+	})
+	if err != nil {
+		return err
+	}
+
+	out.Write([]byte(res.StdOut))
+	out.Flush()
+	out.Write([]byte(res.StdErr))
+
+	if res.ExitCode != 0 {
+		return fmt.Errorf("exited with code %d on machine %s", res.ExitCode, m.ID)
+	}
+
+	return nil
+}
+
+// hookOutput splits what a hook writes into lines.
+type hookOutput struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	line func(string)
+}
+
+func (o *hookOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.buf.Write(p)
+	for {
+		data := o.buf.Bytes()
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		o.line(strings.TrimRight(string(data[:i]), "\r"))
+		o.buf.Next(i + 1)
+	}
+
+	return len(p), nil
+}
+
+// Flush sends what's left after the last newline as a line of its own.
+func (o *hookOutput) Flush() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.buf.Len() > 0 {
+		o.line(strings.TrimRight(o.buf.String(), "\r"))
+		o.buf.Reset()
+	}
+}
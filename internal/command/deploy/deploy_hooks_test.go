@@ -0,0 +1,141 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/mock"
+	"github.com/superfly/flyctl/internal/statuslogger"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestRunDeployHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test are POSIX shell commands")
+	}
+
+	ios, _, out, _ := iostreams.Test()
+	var events bytes.Buffer
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = statuslogger.NewEventSinkContext(ctx, statuslogger.NewEventSink(&events))
+
+	cfg := appconfig.NewConfig()
+	cfg.AppName = "my-app"
+	cfg.SetConfigFilePath("fly.toml")
+	cfg.Deploy = &appconfig.Deploy{Hooks: []appconfig.DeployHook{
+		{Name: "env", Phase: appconfig.DeployHookOnFailure, Command: `echo "$FLY_APP_NAME $FLY_DEPLOY_PHASE $FLY_IMAGE_REF"; printf "$FLY_DEPLOY_ERROR" >&2`},
+		{Phase: appconfig.DeployHookOnFailure, Command: "exit 3", OnFailure: appconfig.DeployHookFailureContinue},
+		{Phase: appconfig.DeployHookPostDeploy, Command: "echo skipped"},
+	}}
+
+	err := runDeployHooks(ctx, &deployHookRun{cfg: cfg, phase: appconfig.DeployHookOnFailure, image: "registry.fly.io/my-app:1", cause: errors.New("boom")})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Running on-failure hook env")
+	assert.Contains(t, out.String(), "  my-app on-failure registry.fly.io/my-app:1\n  boom\n")
+	assert.NotContains(t, out.String(), "skipped")
+	assert.Contains(t, events.String(), `"type":"hook_output","message":"boom","hook":"env","phase":"on-failure"`)
+	assert.Contains(t, events.String(), `"status":"failure","error":"exit status 3"`)
+
+	cfg.Deploy.Hooks = []appconfig.DeployHook{
+		{Phase: appconfig.DeployHookPreBuild, Command: "false"},
+		{Phase: appconfig.DeployHookPreBuild, Command: "echo never"},
+	}
+	err = runDeployHooks(ctx, &deployHookRun{cfg: cfg, phase: appconfig.DeployHookPreBuild})
+	var hookErr *hookError
+	require.ErrorAs(t, err, &hookErr)
+	assert.Equal(t, "false", hookErr.hook.Command)
+	assert.EqualError(t, err, "pre-build hook 'false' failed: exit status 1")
+	assert.NotContains(t, out.String(), "never")
+
+	cfg.Deploy.Hooks = []appconfig.DeployHook{{Phase: appconfig.DeployHookPostBuild, MachineCommand: "true"}}
+	err = runDeployHooks(ctx, &deployHookRun{cfg: cfg, phase: appconfig.DeployHookPostBuild})
+	assert.ErrorContains(t, err, "there is no image to run it on")
+
+	// configs from the platform don't run commands locally
+	remote := appconfig.NewConfig()
+	remote.Deploy = &appconfig.Deploy{Hooks: []appconfig.DeployHook{{Phase: appconfig.DeployHookPreBuild, Command: "echo pwned"}}}
+	out.Reset()
+	require.NoError(t, runDeployHooks(ctx, &deployHookRun{cfg: remote, phase: appconfig.DeployHookPreBuild}))
+	assert.NotContains(t, out.String(), "pwned")
+	assert.Contains(t, events.String(), `"type":"hook","status":"skipped","hook":"echo pwned","phase":"pre-build"`)
+}
+
+func TestRunPostDeployHooksWithoutJournal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test are POSIX shell commands")
+	}
+
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+
+	cfg := appconfig.NewConfig()
+	cfg.AppName = "my-app"
+	cfg.SetConfigFilePath("fly.toml")
+	cfg.Deploy = &appconfig.Deploy{Hooks: []appconfig.DeployHook{
+		{Phase: appconfig.DeployHookPostDeploy, Command: "false", OnFailure: appconfig.DeployHookFailureRollback},
+	}}
+	md := &machineDeployment{io: ios, colorize: ios.ColorScheme(), appConfig: cfg}
+
+	err := md.runPostDeployHooks(ctx)
+	var hookErr *hookError
+	require.ErrorAs(t, err, &hookErr)
+	assert.NotErrorIs(t, err, errDeployRolledBack)
+	assert.ErrorContains(t, err, "can't roll back, this deploy has no journal")
+}
+
+func TestRunPostDeployHooksWithNothingToRollBack(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test are POSIX shell commands")
+	}
+
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+
+	cfg := appconfig.NewConfig()
+	cfg.AppName = "my-app"
+	cfg.SetConfigFilePath("fly.toml")
+	cfg.Deploy = &appconfig.Deploy{Hooks: []appconfig.DeployHook{
+		{Phase: appconfig.DeployHookPostDeploy, Command: "false", OnFailure: appconfig.DeployHookFailureRollback},
+	}}
+	// a first deploy only creates machines, the journal has none
+	md := &machineDeployment{
+		app:       &flaps.App{Name: "my-app"},
+		io:        ios,
+		colorize:  ios.ColorScheme(),
+		appConfig: cfg,
+		journal:   newDeployJournal(filepath.Join(t.TempDir(), "my-app.json"), &DeployManifest{AppName: "my-app"}),
+		flapsClient: &mock.FlapsClient{
+			ListFunc: func(ctx context.Context, appName, state string) ([]*fly.Machine, error) {
+				return []*fly.Machine{{ID: "m1"}}, nil
+			},
+		},
+	}
+
+	err := md.runPostDeployHooks(ctx)
+	var hookErr *hookError
+	require.ErrorAs(t, err, &hookErr)
+	assert.NotErrorIs(t, err, errDeployRolledBack)
+	assert.ErrorContains(t, err, "nothing was rolled back")
+}
+
+func TestHookOutput(t *testing.T) {
+	var lines []string
+	out := &hookOutput{line: func(line string) { lines = append(lines, line) }}
+
+	out.Write([]byte("one\r\ntw"))
+	out.Write([]byte("o\nthree"))
+	assert.Equal(t, []string{"one", "two"}, lines)
+
+	out.Flush()
+	out.Flush()
+	assert.Equal(t, []string{"one", "two", "three"}, lines)
+}
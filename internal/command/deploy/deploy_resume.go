@@ -0,0 +1,209 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/samber/lo"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/buildinfo"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/sentry"
+	"github.com/superfly/flyctl/internal/tracing"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	resumeFinish   = "finish"
+	resumeRollback = "rollback"
+)
+
+// resumeDeploy finishes or rolls back the app's last unfinished deploy from its journal.
+func resumeDeploy(ctx context.Context, appName, mode string) error {
+	var (
+		io = iostreams.FromContext(ctx)
+	)
+
+	if mode != resumeFinish && mode != resumeRollback {
+		return fmt.Errorf("invalid value %q for --resume, must be '%s' or '%s'", mode, resumeFinish, resumeRollback)
+	}
+
+	path := journalPath(ctx, appName)
+	journal, err := journalFromFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("there is no unfinished deploy of %s to resume", appName)
+	case err != nil:
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Resuming %s deploy of release v%d started at %s\n", journal.Manifest.AppName, journal.ReleaseVersion, journal.StartedAt.Format("2006-01-02 15:04:05"))
+
+	flapsClient := flapsutil.ClientFromContext(ctx)
+	app, err := flapsClient.GetApp(ctx, journal.Manifest.AppName)
+	if err != nil {
+		sentry.CaptureException(err)
+
+		return err
+	}
+
+	ctx = appconfig.WithConfig(ctx, journal.Manifest.Config)
+
+	args := argsFromManifest(journal.Manifest, app)
+	args.Journal = journal
+
+	md, err := NewMachineDeployment(ctx, args)
+	if err != nil {
+		sentry.CaptureExceptionWithFlapsAppInfo(ctx, err, "deploy", app)
+
+		return err
+	}
+
+	err = md.Resume(ctx, mode == resumeRollback)
+	if err != nil {
+		sentry.CaptureExceptionWithFlapsAppInfo(ctx, err, "deploy", app)
+	}
+
+	return err
+}
+
+// Resume reconciles the journal with the app's live machines, then updates the ones that
+// don't have their target config yet. The target is the deployed config, or the config
+// machines had before the deploy when rolling back.
+func (md *machineDeployment) Resume(ctx context.Context, rollback bool) (err error) {
+	ctx, span := tracing.GetTracer().Start(ctx, "resume_deploy", trace.WithAttributes(
+		attribute.Bool("rollback", rollback),
+	))
+	defer span.End()
+
+	if !md.journal.resuming() {
+		return fmt.Errorf("BUG: resuming a deploy without a journal")
+	}
+
+	entries, err := md.reconcileJournal(ctx, rollback)
+	if err != nil {
+		tracing.RecordError(span, err, "failed to reconcile journal")
+
+		return err
+	}
+
+	span.SetAttributes(attribute.Int("machines", len(entries)))
+
+	if len(entries) == 0 {
+		fmt.Fprintln(md.io.Out, "All machines already have their target configuration")
+	} else {
+		if rollback {
+			// Rolling back is about getting back to a known state, don't bother with fancier strategies
+			md.strategy = "rolling"
+		}
+
+		md.machineSet = machine.NewMachineSet(md.flapsClient, md.io, md.app.Name, lo.Map(entries, func(e *machineUpdateEntry, _ int) *fly.Machine {
+			return e.leasableMachine.Machine()
+		}), true)
+
+		if err := md.updateExistingMachines(ctx, entries); err != nil {
+			tracing.RecordError(span, err, "failed to resume deploy")
+			fmt.Fprintf(md.io.ErrOut, "The deploy journal was kept, run '%s' again to retry\n", md.colorize.Bold("fly deploy --resume"))
+
+			return err
+		}
+	}
+
+	status := "complete"
+	metadata := &fly.ReleaseMetadata{
+		PostDeploymentInfo: fly.PostDeploymentInfo{
+			FlyctlVersion: buildinfo.Info().Version.String(),
+		},
+	}
+	if rollback {
+		status = "failed"
+		metadata.PostDeploymentInfo.Error = "deploy was interrupted and rolled back"
+	}
+	if err := md.updateReleaseInBackend(ctx, status, metadata); err != nil {
+		terminal.Warnf("failed to set final release status after resuming the deploy: %v\n", err)
+	}
+
+	md.journal.remove()
+
+	if rollback {
+		fmt.Fprintf(md.io.Out, "Rolled back %d machines to their previous configuration\n", len(entries))
+	} else {
+		fmt.Fprintf(md.io.Out, "Finished the deploy of %d remaining machines\n", len(entries))
+	}
+
+	return nil
+}
+
+// reconcileJournal compares every journaled machine with its live state. Leases left
+// behind by the interrupted deploy are released, machines destroyed halfway through a
+// replacement are launched again, and the machines still needing an update are returned.
+func (md *machineDeployment) reconcileJournal(ctx context.Context, rollback bool) ([]*machineUpdateEntry, error) {
+	machines, err := md.flapsClient.List(ctx, md.app.Name, "")
+	if err != nil {
+		return nil, err
+	}
+	live := lo.SliceToMap(machines, func(m *fly.Machine) (string, *fly.Machine) {
+		return m.ID, m
+	})
+
+	var entries []*machineUpdateEntry
+	for _, jm := range md.journal.sortedMachines() {
+		target := jm.NewConfig
+		if rollback {
+			target = jm.OriginalConfig
+		}
+		if target == nil {
+			continue
+		}
+		original := &fly.Machine{ID: jm.ID, State: jm.OriginalState, Config: jm.OriginalConfig}
+
+		id := jm.ID
+		if jm.ReplacedBy != "" {
+			id = jm.ReplacedBy
+		}
+
+		if jm.LeaseNonce != "" {
+			// The lease may have expired already, there's nothing to do then
+			if err := md.flapsClient.ReleaseLease(ctx, md.app.Name, id, jm.LeaseNonce); err == nil {
+				fmt.Fprintf(md.io.Out, "Released the lease left on machine %s\n", md.colorize.Bold(id))
+			}
+		}
+
+		m, ok := live[id]
+		switch {
+		case !ok:
+			launched, err := md.flapsClient.Launch(ctx, md.app.Name, fly.LaunchMachineInput{
+				Region:     jm.Region,
+				Config:     machine.CloneConfig(target),
+				SkipLaunch: shouldSkipLaunch(original, target),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to launch a machine to replace %s: %w", id, err)
+			}
+			fmt.Fprintf(md.io.Out, "Launched machine %s to replace %s, which was destroyed during the deploy\n", md.colorize.Bold(launched.ID), id)
+			md.journal.transition(jm.ID, JournalMachineUpdated, "", launched.ID)
+		case compareConfigs(ctx, m.Config, target):
+			md.journal.transition(jm.ID, JournalMachineUpdated, "", "")
+		default:
+			m.LeaseNonce = ""
+			entries = append(entries, &machineUpdateEntry{
+				leasableMachine: machine.NewLeasableMachine(md.flapsClient, md.io, md.app.Name, m, false),
+				launchInput: &fly.LaunchMachineInput{
+					ID:         m.ID,
+					Region:     m.Region,
+					Config:     machine.CloneConfig(target),
+					SkipLaunch: shouldSkipLaunch(original, target),
+				},
+			})
+		}
+	}
+
+	return entries, nil
+}
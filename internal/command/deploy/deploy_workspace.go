@@ -0,0 +1,323 @@
+package deploy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/sourcegraph/conc/pool"
+	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/dockerfileurl"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/internal/tracing"
+	"github.com/superfly/flyctl/internal/workspace"
+	"github.com/superfly/flyctl/iostreams"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// workspaceBuildConcurrency is how many workspace apps are built at once.
+const workspaceBuildConcurrency = 4
+
+const (
+	workspaceAppDeployed  = "deployed"
+	workspaceAppUnchanged = "unchanged"
+	workspaceAppFailed    = "failed"
+	workspaceAppSkipped   = "skipped"
+)
+
+type workspaceAppResult struct {
+	fingerprint string
+	image       *imgsrc.DeploymentImage
+	status      string
+	err         error
+	took        time.Duration
+}
+
+type prebuiltImageKey struct{}
+
+// withPrebuiltImage makes DeployWithConfig deploy img instead of building one.
+func withPrebuiltImage(ctx context.Context, img *imgsrc.DeploymentImage) context.Context {
+	return context.WithValue(ctx, prebuiltImageKey{}, img)
+}
+
+func prebuiltImageFromContext(ctx context.Context) *imgsrc.DeploymentImage {
+	img, _ := ctx.Value(prebuiltImageKey{}).(*imgsrc.DeploymentImage)
+
+	return img
+}
+
+// deployWorkspace deploys the apps of a workspace file. Apps whose config and build context
+// didn't change since their last deploy from the workspace are skipped, the other ones are
+// built concurrently and then deployed one at a time after their dependencies.
+func deployWorkspace(ctx context.Context, path string) error {
+	io := iostreams.FromContext(ctx)
+
+	ctx, span := tracing.GetTracer().Start(ctx, "deploy_workspace")
+	defer span.End()
+
+	w, err := workspace.Load(path, flag.GetEnvironment(ctx))
+	if err != nil {
+		return err
+	}
+	stages, err := w.Stages()
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.Int("apps", len(w.Apps)))
+
+	var (
+		dryRun    = flag.GetBool(ctx, "dry-run")
+		buildOnly = flag.GetBuildOnly(ctx)
+		results   = map[string]*workspaceAppResult{}
+		toBuild   []*workspace.App
+	)
+	for _, app := range w.Apps {
+		r := &workspaceAppResult{}
+		results[app.Name] = r
+
+		if r.fingerprint, err = workspaceFingerprint(ctx, app); err != nil {
+			r.status, r.err = workspaceAppFailed, err
+
+			continue
+		}
+		if previous, _ := os.ReadFile(fingerprintPath(ctx, app.Name)); string(previous) == r.fingerprint {
+			r.status = workspaceAppUnchanged
+
+			continue
+		}
+		toBuild = append(toBuild, app)
+	}
+
+	if !dryRun && len(toBuild) > 0 {
+		fmt.Fprintf(io.Out, "Building %d workspace apps\n", len(toBuild))
+
+		builds := pool.New().WithMaxGoroutines(workspaceBuildConcurrency)
+		for _, app := range toBuild {
+			r := results[app.Name]
+			builds.Go(func() {
+				start := time.Now()
+				defer func() { r.took += time.Since(start) }()
+
+				appCtx, flapsApp, cfg, err := workspaceAppContext(ctx, app)
+				if err != nil {
+					r.status, r.err = workspaceAppFailed, err
+
+					return
+				}
+				if r.image, err = buildImage(appCtx, flapsApp, cfg); err != nil {
+					r.status, r.err = workspaceAppFailed, fmt.Errorf("failed to build: %w", err)
+
+					if hookErr := runDeployHooks(appCtx, &deployHookRun{cfg: cfg, phase: appconfig.DeployHookOnFailure, cause: err}); hookErr != nil {
+						fmt.Fprintf(io.ErrOut, "%s %v\n", aurora.Yellow("WARN"), hookErr)
+					}
+				}
+			})
+		}
+		builds.Wait()
+	}
+
+	for _, stage := range stages {
+		for _, app := range stage {
+			r := results[app.Name]
+			if r.status != "" {
+				continue
+			}
+			if dep, blocked := workspaceBlockedBy(app, results); blocked {
+				r.status, r.err = workspaceAppSkipped, fmt.Errorf("its dependency %s wasn't deployed", dep)
+
+				continue
+			}
+
+			fmt.Fprintf(io.Out, "\n==> Deploying %s\n", io.ColorScheme().Bold(app.Name))
+
+			start := time.Now()
+			err := deployWorkspaceApp(ctx, app, r.image)
+			r.took += time.Since(start)
+			if err != nil {
+				r.status, r.err = workspaceAppFailed, err
+
+				continue
+			}
+
+			r.status = workspaceAppDeployed
+			if !dryRun && !buildOnly {
+				saveFingerprint(ctx, app.Name, r.fingerprint)
+			}
+		}
+	}
+
+	return reportWorkspaceDeploy(ctx, span, w, stages, results)
+}
+
+// workspaceAppContext derives the context a workspace app is deployed from, as if its
+// deploy was started from its build context with its config.
+func workspaceAppContext(ctx context.Context, app *workspace.App) (context.Context, *flaps.App, *appconfig.Config, error) {
+	ctx = appconfig.WithName(ctx, app.Name)
+	ctx = appconfig.WithConfig(ctx, app.Config())
+	ctx = state.WithWorkingDirectory(ctx, app.Context)
+
+	cfg, err := determineAppConfig(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ctx = appconfig.WithConfig(ctx, cfg)
+
+	flapsApp, err := flapsutil.ClientFromContext(ctx).GetApp(ctx, app.Name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ctx, err = withFeatureFlags(ctx, flapsApp, 0)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return ctx, flapsApp, cfg, nil
+}
+
+func deployWorkspaceApp(ctx context.Context, app *workspace.App, img *imgsrc.DeploymentImage) error {
+	ctx, _, cfg, err := workspaceAppContext(ctx, app)
+	if err != nil {
+		return err
+	}
+	if img != nil {
+		ctx = withPrebuiltImage(ctx, img)
+	}
+
+	return DeployWithConfig(ctx, cfg, 0, flag.GetYes(ctx))
+}
+
+// workspaceBlockedBy returns the first dependency of app that wasn't deployed or unchanged.
+func workspaceBlockedBy(app *workspace.App, results map[string]*workspaceAppResult) (string, bool) {
+	for _, dep := range app.DependsOn {
+		if s := results[dep].status; s != workspaceAppDeployed && s != workspaceAppUnchanged {
+			return dep, true
+		}
+	}
+
+	return "", false
+}
+
+// workspaceFingerprint digests what a deploy of the app depends on locally: its config, as
+// merged for the selected environment, its Dockerfile and build context, and the --env and
+// --build-arg values the deploy passes on.
+func workspaceFingerprint(ctx context.Context, app *workspace.App) (string, error) {
+	cfg := app.Config()
+	config, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	dockerfile, err := resolveDockerfilePath(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	ignorefile, err := resolveIgnorefilePath(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	contextDigest, err := imgsrc.BuildContextDigest(app.Context, dockerfile, ignorefile)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "environment\x00%s\x00", cfg.Environment())
+	fmt.Fprintf(h, "config\x00%d\x00", len(config))
+	h.Write(config)
+	h.Write([]byte(contextDigest))
+	// the Dockerfile may live outside the build context
+	fmt.Fprintf(h, "dockerfile\x00%s\x00", dockerfile)
+	if dockerfile != "" && !dockerfileurl.IsURL(dockerfile) {
+		contents, err := os.ReadFile(dockerfile)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%d\x00", len(contents))
+		h.Write(contents)
+	}
+	for _, name := range []string{"env", "build-arg"} {
+		for _, value := range flag.GetStringArray(ctx, name) {
+			fmt.Fprintf(h, "%s\x00%s\x00", name, value)
+		}
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fingerprintPath is where the fingerprint of the app's last deploy from a workspace is kept.
+func fingerprintPath(ctx context.Context, appName string) string {
+	return filepath.Join(state.ConfigDirectory(ctx), "deploys", appName+".fingerprint")
+}
+
+// saveFingerprint records the fingerprint of a deployed app. Failing to do so only means the
+// app will be deployed again next time, so it's a warning.
+func saveFingerprint(ctx context.Context, appName, fingerprint string) {
+	path := fingerprintPath(ctx, appName)
+
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err == nil {
+		err = os.WriteFile(path, []byte(fingerprint), 0o600)
+	}
+	if err != nil {
+		fmt.Fprintf(iostreams.FromContext(ctx).ErrOut, "Failed to record the deploy of %s, it will be deployed again: %v\n", appName, err)
+	}
+}
+
+func reportWorkspaceDeploy(ctx context.Context, span trace.Span, w *workspace.Workspace, stages [][]*workspace.App, results map[string]*workspaceAppResult) error {
+	io := iostreams.FromContext(ctx)
+
+	var (
+		rows   [][]string
+		failed []string
+	)
+	for _, stage := range stages {
+		for _, app := range stage {
+			r := results[app.Name]
+
+			image, took, note := "", "", ""
+			if r.image != nil {
+				image = r.image.Tag
+			}
+			if r.took > 0 {
+				took = r.took.Round(time.Second).String()
+			}
+			if r.err != nil {
+				note = r.err.Error()
+				failed = append(failed, app.Name)
+			}
+			rows = append(rows, []string{app.Name, r.status, image, took, note})
+		}
+	}
+
+	fmt.Fprintln(io.Out)
+	if err := render.Table(io.Out, "Workspace "+w.Path(), rows, "App", "Status", "Image", "Took", "Error"); err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		err := fmt.Errorf("%d of %d workspace apps weren't deployed: %s", len(failed), len(rows), strings.Join(failed, ", "))
+		tracing.RecordError(span, err, "workspace deploy failed")
+
+		return err
+	}
+
+	return nil
+}
+
+// errWorkspaceFlags is returned when flags that pick a single app are used with --workspace.
+var errWorkspaceFlags = errors.New("--workspace deploys the apps of the workspace file, it can't be used with --app, --config, --image or --from-manifest")
@@ -0,0 +1,96 @@
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/flag/flagctx"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/internal/workspace"
+)
+
+func TestWorkspaceFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.toml"), []byte("app = \"acme-api\"\n[build]\n  dockerfile = \"Dockerfile\"\n[env]\n  LEVEL = \"debug\"\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.staging.toml"), []byte("[env]\n  LEVEL = \"info\"\n"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "src"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main"), 0o644))
+	path := filepath.Join(dir, workspace.DefaultFileName)
+	require.NoError(t, os.WriteFile(path, []byte("[[apps]]\n  config = \"fly.toml\"\n  context = \"src\"\n"), 0o644))
+
+	fingerprintWithFlags := func(environment string, args ...string) string {
+		t.Helper()
+		flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flagSet.String("dockerfile", "", "")
+		flagSet.String("ignorefile", "", "")
+		flagSet.StringArrayP("env", "e", nil, "")
+		flagSet.StringArray("build-arg", nil, "")
+		require.NoError(t, flagSet.Parse(args))
+
+		w, err := workspace.Load(path, environment)
+		require.NoError(t, err)
+		fingerprint, err := workspaceFingerprint(flagctx.NewContext(context.Background(), flagSet), w.Apps[0])
+		require.NoError(t, err)
+
+		return fingerprint
+	}
+	fingerprint := func(environment string) string {
+		t.Helper()
+
+		return fingerprintWithFlags(environment)
+	}
+
+	base := fingerprint("")
+	ctx := state.WithConfigDirectory(context.Background(), t.TempDir())
+	saveFingerprint(ctx, "acme-api", base)
+	saved, err := os.ReadFile(fingerprintPath(ctx, "acme-api"))
+	require.NoError(t, err)
+	assert.Equal(t, base, string(saved))
+
+	// Each environment deploys its own merged config
+	staging := fingerprint("staging")
+	assert.NotEqual(t, base, staging)
+
+	// Changing an overlay, the base config or the build context changes the fingerprint
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.staging.toml"), []byte("[env]\n  LEVEL = \"warn\"\n"), 0o644))
+	changedOverlay := fingerprint("staging")
+	assert.NotEqual(t, staging, changedOverlay)
+	assert.Equal(t, base, fingerprint(""))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main\n"), 0o644))
+	changed := fingerprint("")
+	assert.NotEqual(t, base, changed)
+
+	// so do the Dockerfile outside of the build context and the values given on the command line
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0o644))
+	assert.NotEqual(t, changed, fingerprint(""))
+	changed = fingerprint("")
+
+	assert.NotEqual(t, changed, fingerprintWithFlags("", "-e", "LEVEL=trace"))
+	assert.NotEqual(t, changed, fingerprintWithFlags("", "--build-arg", "VERSION=2"))
+	assert.Equal(t, changed, fingerprintWithFlags(""))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.toml"), []byte("app = \"acme-api\"\n[build]\n  dockerfile = \"Dockerfile\"\n[env]\n  LEVEL = \"error\"\n"), 0o644))
+	assert.NotEqual(t, changed, fingerprint(""))
+}
+
+func TestWorkspaceBlockedBy(t *testing.T) {
+	results := map[string]*workspaceAppResult{
+		"api":    {status: workspaceAppDeployed},
+		"db":     {status: workspaceAppUnchanged},
+		"worker": {status: workspaceAppFailed},
+	}
+
+	_, blocked := workspaceBlockedBy(&workspace.App{Name: "web", DependsOn: []string{"api", "db"}}, results)
+	assert.False(t, blocked)
+
+	dep, blocked := workspaceBlockedBy(&workspace.App{Name: "web", DependsOn: []string{"api", "worker"}}, results)
+	assert.True(t, blocked)
+	assert.Equal(t, "worker", dep)
+}
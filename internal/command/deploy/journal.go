@@ -0,0 +1,251 @@
+package deploy
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/terminal"
+)
+
+const (
+	JournalMachinePending  = "pending"
+	JournalMachineUpdating = "updating"
+	JournalMachineUpdated  = "updated"
+	JournalMachineFailed   = "failed"
+)
+
+// DeployJournal records the progress of a deploy on disk so an interrupted deploy can
+// be finished or rolled back with `fly deploy --resume`. It holds the manifest the deploy
+// was started with and the transitions of every machine pairing being updated.
+type DeployJournal struct {
+	Manifest       *DeployManifest            `json:"manifest"`
+	ReleaseID      string                     `json:"release_id,omitempty"`
+	ReleaseVersion int                        `json:"release_version,omitempty"`
+	StartedAt      time.Time                  `json:"started_at"`
+	Machines       map[string]*JournalMachine `json:"machines"`
+
+	mu      sync.Mutex
+	path    string
+	resumed bool
+}
+
+// JournalMachine is the journaled state of a machine pairing. ReplacedBy is set once a
+// machine that required replacement has been relaunched under a new ID.
+type JournalMachine struct {
+	ID             string             `json:"id"`
+	ProcessGroup   string             `json:"process_group"`
+	Region         string             `json:"region"`
+	State          string             `json:"state"`
+	LeaseNonce     string             `json:"lease_nonce,omitempty"`
+	ReplacedBy     string             `json:"replaced_by,omitempty"`
+	OriginalState  string             `json:"original_state,omitempty"`
+	OriginalConfig *fly.MachineConfig `json:"original_config,omitempty"`
+	NewConfig      *fly.MachineConfig `json:"new_config"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// journalPath is where the journal of the app's last unfinished deploy is kept.
+func journalPath(ctx context.Context, appName string) string {
+	return filepath.Join(state.ConfigDirectory(ctx), "deploys", appName+".json")
+}
+
+func newDeployJournal(path string, manifest *DeployManifest) *DeployJournal {
+	return &DeployJournal{
+		Manifest:  manifest,
+		StartedAt: time.Now(),
+		Machines:  map[string]*JournalMachine{},
+		path:      path,
+	}
+}
+
+func journalFromFile(path string) (*DeployJournal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &DeployJournal{}
+	if err := json.Unmarshal(data, journal); err != nil {
+		return nil, fmt.Errorf("failed to parse deploy journal %s: %w", path, err)
+	}
+	if journal.Manifest == nil {
+		return nil, fmt.Errorf("deploy journal %s has no manifest", path)
+	}
+	if journal.Machines == nil {
+		journal.Machines = map[string]*JournalMachine{}
+	}
+	journal.path = path
+	journal.resumed = true
+
+	return journal, nil
+}
+
+// resuming is true for journals read back from disk to resume a deploy.
+func (j *DeployJournal) resuming() bool {
+	return j != nil && j.resumed
+}
+
+// begin records the pairings about to be updated. Machines already in the journal keep
+// the config they had before the deploy began.
+func (j *DeployJournal) begin(releaseID string, releaseVersion int, pairings []machinePairing) {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.ReleaseID = releaseID
+	j.ReleaseVersion = releaseVersion
+	for _, p := range pairings {
+		if p.oldMachine == nil || p.newMachine == nil {
+			continue
+		}
+		if jm := j.lookup(p.oldMachine.ID); jm != nil {
+			jm.State = JournalMachinePending
+			jm.UpdatedAt = time.Now()
+
+			continue
+		}
+
+		jm := &JournalMachine{
+			ID:           p.oldMachine.ID,
+			ProcessGroup: p.oldMachine.ProcessGroup(),
+			Region:       p.oldMachine.Region,
+			State:        JournalMachinePending,
+			NewConfig:    p.newMachine.Config,
+			UpdatedAt:    time.Now(),
+		}
+		if p.originalMachine != nil {
+			jm.OriginalState = p.originalMachine.State
+			jm.OriginalConfig = p.originalMachine.Config
+		}
+		j.Machines[jm.ID] = jm
+	}
+
+	j.save()
+}
+
+// transition moves a journaled machine to a new state. replacedBy is the ID of the
+// machine that took its place, if it was replaced.
+func (j *DeployJournal) transition(id, newState, leaseNonce, replacedBy string) {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	jm := j.lookup(id)
+	if jm == nil {
+		return
+	}
+	jm.State = newState
+	jm.UpdatedAt = time.Now()
+	if leaseNonce != "" {
+		jm.LeaseNonce = leaseNonce
+	}
+	if replacedBy != "" && replacedBy != jm.ID {
+		jm.ReplacedBy = replacedBy
+	}
+
+	j.save()
+}
+
+// lookup finds a journaled machine by its ID or the ID of the machine that replaced it.
+func (j *DeployJournal) lookup(id string) *JournalMachine {
+	if jm, ok := j.Machines[id]; ok {
+		return jm
+	}
+	for _, jm := range j.Machines {
+		if jm.ReplacedBy == id {
+			return jm
+		}
+	}
+
+	return nil
+}
+
+// started is true once any machine has been journaled.
+func (j *DeployJournal) started() bool {
+	if j == nil {
+		return false
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return len(j.Machines) > 0
+}
+
+// sortedMachines returns the journaled machines ordered by ID.
+func (j *DeployJournal) sortedMachines() []*JournalMachine {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	machines := make([]*JournalMachine, 0, len(j.Machines))
+	for _, jm := range j.Machines {
+		machines = append(machines, jm)
+	}
+	slices.SortFunc(machines, func(a, b *JournalMachine) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return machines
+}
+
+// save writes the journal to disk. Failing to journal doesn't fail the deploy, it
+// only means it can't be resumed.
+func (j *DeployJournal) save() {
+	if err := j.write(); err != nil {
+		terminal.Warnf("failed to write deploy journal %s: %v\n", j.path, err)
+	}
+}
+
+func (j *DeployJournal) write() error {
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a deploy killed mid-write doesn't leave a truncated journal
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, j.path)
+}
+
+// remove deletes the journal once there's nothing left to resume.
+func (j *DeployJournal) remove() {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		terminal.Warnf("failed to remove deploy journal %s: %v\n", j.path, err)
+	}
+}
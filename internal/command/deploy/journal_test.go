@@ -0,0 +1,136 @@
+package deploy
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/flyctl/internal/mock"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func testJournalMachine(id, image string) *fly.Machine {
+	return &fly.Machine{
+		ID:     id,
+		Region: "ord",
+		State:  fly.MachineStateStarted,
+		Config: &fly.MachineConfig{
+			Image:    image,
+			Metadata: map[string]string{fly.MachineConfigMetadataKeyFlyProcessGroup: "app"},
+		},
+	}
+}
+
+func TestDeployJournalRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deploys", "my-app.json")
+	journal := newDeployJournal(path, &DeployManifest{AppName: "my-app", DeploymentImage: "new-image"})
+	assert.False(t, journal.resuming())
+	assert.False(t, journal.started())
+
+	m := testJournalMachine("m1", "old-image")
+	journal.begin("release-id", 3, []machinePairing{
+		machinePairingForUpdate(m, &fly.LaunchMachineInput{Config: testJournalMachine("m1", "new-image").Config}),
+	})
+	journal.transition("m1", JournalMachineUpdating, "nonce", "")
+	journal.transition("m1", JournalMachineUpdated, "", "m1-new")
+
+	loaded, err := journalFromFile(path)
+	require.NoError(t, err)
+	assert.True(t, loaded.resuming())
+	assert.True(t, loaded.started())
+	assert.Equal(t, "my-app", loaded.Manifest.AppName)
+	assert.Equal(t, "release-id", loaded.ReleaseID)
+	assert.Equal(t, 3, loaded.ReleaseVersion)
+
+	jm := loaded.lookup("m1-new")
+	require.NotNil(t, jm)
+	assert.Equal(t, "m1", jm.ID)
+	assert.Equal(t, JournalMachineUpdated, jm.State)
+	assert.Equal(t, "nonce", jm.LeaseNonce)
+	assert.Equal(t, "old-image", jm.OriginalConfig.Image)
+	assert.Equal(t, "new-image", jm.NewConfig.Image)
+
+	// Beginning again keeps the config the machine had before the deploy
+	loaded.begin("release-id", 3, []machinePairing{
+		machinePairingForUpdate(testJournalMachine("m1-new", "new-image"), &fly.LaunchMachineInput{Config: testJournalMachine("m1", "new-image").Config}),
+	})
+	assert.Len(t, loaded.Machines, 1)
+	assert.Equal(t, JournalMachinePending, jm.State)
+	assert.Equal(t, "old-image", jm.OriginalConfig.Image)
+
+	loaded.remove()
+	assert.NoFileExists(t, path)
+
+	var nilJournal *DeployJournal
+	nilJournal.transition("m1", JournalMachineUpdated, "", "")
+	nilJournal.remove()
+	assert.False(t, nilJournal.resuming())
+	assert.Empty(t, nilJournal.sortedMachines())
+}
+
+func TestReconcileJournal(t *testing.T) {
+	ios, _, _, _ := iostreams.Test()
+
+	journal := newDeployJournal(filepath.Join(t.TempDir(), "my-app.json"), &DeployManifest{AppName: "my-app"})
+	var pairings []machinePairing
+	for _, id := range []string{"m1", "m2", "m3"} {
+		pairings = append(pairings, machinePairingForUpdate(testJournalMachine(id, "old-image"), &fly.LaunchMachineInput{Config: testJournalMachine(id, "new-image").Config}))
+	}
+	journal.begin("release-id", 3, pairings)
+	journal.transition("m2", JournalMachineUpdating, "nonce-m2", "")
+	journal.transition("m3", JournalMachineUpdating, "nonce-m3", "")
+	journal.resumed = true
+
+	var (
+		released []string
+		launched []fly.LaunchMachineInput
+	)
+	client := &mock.FlapsClient{
+		ListFunc: func(ctx context.Context, appName, state string) ([]*fly.Machine, error) {
+			// m1 was updated, m2 wasn't and m3 was destroyed while being replaced
+			return []*fly.Machine{testJournalMachine("m1", "new-image"), testJournalMachine("m2", "old-image")}, nil
+		},
+		ReleaseLeaseFunc: func(ctx context.Context, appName, machineID, nonce string) error {
+			released = append(released, machineID+"="+nonce)
+
+			return nil
+		},
+		LaunchFunc: func(ctx context.Context, appName string, input fly.LaunchMachineInput) (*fly.Machine, error) {
+			launched = append(launched, input)
+
+			return &fly.Machine{ID: "m4", Region: input.Region, Config: input.Config}, nil
+		},
+	}
+
+	md := &machineDeployment{
+		app:         &flaps.App{Name: "my-app"},
+		io:          ios,
+		colorize:    ios.ColorScheme(),
+		flapsClient: client,
+		journal:     journal,
+	}
+
+	entries, err := md.reconcileJournal(context.Background(), false)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "m2", entries[0].launchInput.ID)
+	assert.Equal(t, "new-image", entries[0].launchInput.Config.Image)
+	assert.Equal(t, []string{"m2=nonce-m2", "m3=nonce-m3"}, released)
+	require.Len(t, launched, 1)
+	assert.Equal(t, "new-image", launched[0].Config.Image)
+	assert.Equal(t, "m4", journal.Machines["m3"].ReplacedBy)
+
+	// Rolling back targets the config the machines had before the deploy
+	launched = nil
+	entries, err = md.reconcileJournal(context.Background(), true)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "m1", entries[0].launchInput.ID)
+	assert.Equal(t, "old-image", entries[0].launchInput.Config.Image)
+	require.Len(t, launched, 1)
+	assert.Equal(t, "old-image", launched[0].Config.Image)
+}
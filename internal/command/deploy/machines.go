@@ -17,6 +17,7 @@ import (
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/fly-go/flaps"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/appsecrets"
 	"github.com/superfly/flyctl/internal/buildinfo"
 	"github.com/superfly/flyctl/internal/cmdutil"
 	"github.com/superfly/flyctl/internal/command/deploy/statics"
@@ -44,11 +45,15 @@ const (
 
 type MachineDeployment interface {
 	DeployMachinesApp(context.Context) error
+	Plan(context.Context) (*DeploymentPlan, error)
+	Resume(ctx context.Context, rollback bool) error
 }
 
 type MachineDeploymentArgs struct {
-	App                   *flaps.App
-	DeploymentImage       string
+	App             *flaps.App
+	DeploymentImage string
+	// ProcessImages are the images of the process groups with images of their own, by group.
+	ProcessImages         map[string]string
 	Strategy              string
 	EnvFromFlags          []string
 	PrimaryRegionFlag     string
@@ -80,12 +85,17 @@ type MachineDeploymentArgs struct {
 	DeployRetries         int
 	BuildID               int64
 	BuilderID             string
+	// DryRun skips first deploy provisioning and the backend release so the deployment can only be planned.
+	DryRun bool
+	// Journal records the deploy's progress. A journal read back from disk resumes its deploy and release.
+	Journal *DeployJournal
 }
 
 func argsFromManifest(manifest *DeployManifest, app *flaps.App) MachineDeploymentArgs {
 	return MachineDeploymentArgs{
 		App:                   app,
 		DeploymentImage:       manifest.DeploymentImage,
+		ProcessImages:         manifest.ProcessImages,
 		Strategy:              manifest.Strategy,
 		EnvFromFlags:          manifest.EnvFromFlags,
 		PrimaryRegionFlag:     manifest.PrimaryRegionFlag,
@@ -128,6 +138,8 @@ type machineDeployment struct {
 	app        *flaps.App
 	appConfig  *appconfig.Config
 	img        string
+	// processImages replace img for the machines of their process groups.
+	processImages map[string]string
 	// machineSet is this application's machines.
 	machineSet            machine.MachineSet
 	releaseCommandMachine machine.MachineSet
@@ -162,6 +174,7 @@ type machineDeployment struct {
 	deployRetries         int
 	buildID               int64
 	builderID             string
+	journal               *DeployJournal
 }
 
 func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ MachineDeployment, err error) {
@@ -258,6 +271,7 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ Ma
 		app:                   args.App,
 		appConfig:             appConfig,
 		img:                   args.DeploymentImage,
+		processImages:         args.ProcessImages,
 		skipSmokeChecks:       args.SkipSmokeChecks,
 		skipHealthChecks:      args.SkipHealthChecks,
 		skipDNSChecks:         args.SkipDNSChecks,
@@ -282,6 +296,7 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ Ma
 		deployRetries:         args.DeployRetries,
 		buildID:               args.BuildID,
 		builderID:             args.BuilderID,
+		journal:               args.Journal,
 	}
 	if err := md.setStrategy(); err != nil {
 		tracing.RecordError(span, err, "failed to set strategy")
@@ -311,19 +326,34 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ Ma
 	}
 
 	// Provisioning must come after setVolumes
-	if err := md.provisionFirstDeploy(ctx, args.AllocIP, args.Org); err != nil {
-		tracing.RecordError(span, err, "failed to provision first depoloy")
+	if !args.DryRun && !args.Journal.resuming() {
+		if err := md.provisionFirstDeploy(ctx, args.AllocIP, args.Org); err != nil {
+			tracing.RecordError(span, err, "failed to provision first depoloy")
 
-		return nil, err
+			return nil, err
+		}
 	}
 
 	// validations must happen after every else
-	if err := md.validateVolumeConfig(ctx); err != nil {
-		tracing.RecordError(span, err, "failed to validate volume config")
+	// A dry run of a first deploy hasn't provisioned the volumes it would validate
+	if !args.DryRun || !md.isFirstDeploy {
+		if err := md.validateVolumeConfig(ctx); err != nil {
+			tracing.RecordError(span, err, "failed to validate volume config")
 
-		return nil, err
+			return nil, err
+		}
 	}
-	if err = md.createReleaseInBackend(ctx); err != nil {
+
+	if args.DryRun {
+		span.SetAttributes(md.ToSpanAttributes()...)
+
+		return md, nil
+	}
+
+	if args.Journal.resuming() {
+		md.releaseId = args.Journal.ReleaseID
+		md.releaseVersion = args.Journal.ReleaseVersion
+	} else if err = md.createReleaseInBackend(ctx); err != nil {
 		tracing.RecordError(span, err, "failed to create release in backend")
 
 		return nil, err
@@ -668,6 +698,11 @@ func (md *machineDeployment) setImg(ctx context.Context) error {
 	if md.img != "" {
 		return nil
 	}
+	// Deploying only process groups with images of their own doesn't build the app's image,
+	// which the release command and hooks run on: the deployed machines don't run it
+	if len(md.processImages) > 0 {
+		return md.setAppImgFromMachines(ctx)
+	}
 	latestImg, err := md.apiClient.LatestImage(ctx, md.app.Name)
 	if err == nil {
 		md.img = latestImg
@@ -683,6 +718,35 @@ func (md *machineDeployment) setImg(ctx context.Context) error {
 	return fmt.Errorf("could not find image to use for deployment; backend error was: %w", err)
 }
 
+// setAppImgFromMachines sets the app's image to the one its machines run, those of the
+// process groups without an image of their own.
+func (md *machineDeployment) setAppImgFromMachines(ctx context.Context) error {
+	machines, err := md.flapsClient.List(ctx, md.app.Name, "")
+	if err != nil {
+		return fmt.Errorf("could not find the app's image: %w", err)
+	}
+
+	ownImages := md.appConfig.ProcessBuildGroups()
+	for _, m := range machines {
+		if m.Config != nil && m.Config.Image != "" && !slices.Contains(ownImages, m.ProcessGroup()) {
+			md.img = m.Config.Image
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("could not find the app's image, no machine runs it: deploy a process group without an image of its own along with %s", strings.Join(slices.Sorted(maps.Keys(md.processImages)), ", "))
+}
+
+// imageFor returns the image of the machines of the process group.
+func (md *machineDeployment) imageFor(processGroup string) string {
+	if img := md.processImages[processGroup]; img != "" {
+		return img
+	}
+
+	return md.img
+}
+
 func (md *machineDeployment) setStrategy() error {
 	md.strategy = "rolling"
 	if md.appConfig.Deploy != nil && md.appConfig.Deploy.Strategy != "" {
@@ -698,7 +762,7 @@ func (md *machineDeployment) createReleaseInBackend(ctx context.Context) error {
 
 	resp, err := md.uiexClient.CreateRelease(ctx, uiex.CreateReleaseRequest{
 		AppName:    md.app.Name,
-		Strategy:   uiex.DeploymentStrategy(strings.ToUpper(md.strategy)),
+		Strategy:   md.releaseStrategy(),
 		Definition: md.appConfig,
 		Image:      md.img,
 		BuildId:    md.buildID,
@@ -714,6 +778,23 @@ func (md *machineDeployment) createReleaseInBackend(ctx context.Context) error {
 	return nil
 }
 
+// releaseStrategy maps the deploy strategy to one the releases API knows about.
+func (md *machineDeployment) releaseStrategy() uiex.DeploymentStrategy {
+	if md.strategy == "progressive" {
+		// The backend has no notion of progressive deploys, which are canaries in steps.
+		return uiex.DeploymentStrategyCanary
+	}
+
+	return uiex.DeploymentStrategy(strings.ToUpper(md.strategy))
+}
+
+// releaseMetadata is the metadata a deploy saves on its release: how the deploy went, and
+// the secrets version its machines were deployed with, which a rollback pins again.
+type releaseMetadata struct {
+	*fly.ReleaseMetadata
+	appconfig.ReleaseSecrets
+}
+
 func (md *machineDeployment) updateReleaseInBackend(ctx context.Context, status string, metadata *fly.ReleaseMetadata) error {
 	ctx, span := tracing.GetTracer().Start(ctx, "update_release_in_backend", trace.WithAttributes(
 		attribute.String("release_id", md.releaseId),
@@ -721,7 +802,18 @@ func (md *machineDeployment) updateReleaseInBackend(ctx context.Context, status
 	))
 	defer span.End()
 
-	_, err := md.uiexClient.UpdateRelease(ctx, md.releaseId, status, metadata)
+	var update any = metadata
+	minvers, err := appsecrets.GetMinvers(md.appConfig.AppName)
+	if err != nil {
+		tracing.RecordError(span, err, "failed to get the secrets version")
+
+		return err
+	}
+	if minvers != nil {
+		update = &releaseMetadata{ReleaseMetadata: metadata, ReleaseSecrets: appconfig.ReleaseSecrets{SecretsVersion: minvers}}
+	}
+
+	_, err = md.uiexClient.UpdateRelease(ctx, md.releaseId, status, update)
 
 	if err != nil {
 		tracing.RecordError(span, err, "failed to update machine release")
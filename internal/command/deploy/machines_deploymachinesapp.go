@@ -82,9 +82,13 @@ func (md *machineDeployment) DeployMachinesApp(ctx context.Context) error {
 
 	var err error
 	if md.restartOnly {
+		// Restarts, e.g. for secrets updates, don't ship anything new: deploy hooks don't run for them
 		err = md.restartMachinesApp(ctx)
 	} else {
 		err = md.deployMachinesApp(ctx)
+		if err == nil {
+			err = md.runPostDeployHooks(ctx)
+		}
 	}
 
 	var status string
@@ -137,29 +141,53 @@ func (md *machineDeployment) DeployMachinesApp(ctx context.Context) error {
 		tracing.RecordError(span, err, "failed to deploy machines")
 	}
 
-	// When FLY_EMIT_RELEASE_JSON is set, emit a JSON line to stdout with the
-	// release ID and version created for this deployment. This lets callers
-	// (e.g. flyctl-deployer) reliably capture the exact release without a
-	// separate API call that could race with concurrent deployments.
+	// Keep the journal around only if there are machines left to finish or roll back
+	if err == nil || !md.journal.started() || errors.Is(err, errDeployRolledBack) {
+		md.journal.remove()
+	} else {
+		fmt.Fprintf(md.io.ErrOut, "Run '%s' to finish this deploy, or '%s' to roll it back\n",
+			md.colorize.Bold("fly deploy --resume"), md.colorize.Bold("fly deploy --resume=rollback"))
+	}
+
 	if err == nil && os.Getenv("FLY_EMIT_RELEASE_JSON") != "" {
-		type releaseJSON struct {
-			ID       string `json:"id"`
-			Version  int    `json:"version"`
-			ImageRef string `json:"image_ref"`
-		}
-		if data, jsonErr := json.Marshal(releaseJSON{
-			ID:       md.releaseId,
-			Version:  md.releaseVersion,
-			ImageRef: md.img,
-		}); jsonErr == nil {
-			fmt.Fprintf(md.io.Out, "%s\n", data)
-		}
+		md.emitReleaseJSON(ctx)
 	}
 
 	return err
 }
 
-func (md *machineDeployment) updateMachine(ctx context.Context, e *machineUpdateEntry, sl statuslogger.StatusLine) error {
+// emitReleaseJSON writes a JSON line to stdout with the release ID and version created
+// for this deployment, for FLY_EMIT_RELEASE_JSON. This lets callers (e.g.
+// flyctl-deployer) reliably capture the exact release without a separate API call that
+// could race with concurrent deployments. With --output ndjson, stdout only carries
+// events, so the release is a release event of the stream instead.
+func (md *machineDeployment) emitReleaseJSON(ctx context.Context) {
+	if sink := statuslogger.EventSinkFromContext(ctx); sink != nil {
+		sink.Emit(statuslogger.Event{
+			Type:           statuslogger.EventRelease,
+			ReleaseID:      md.releaseId,
+			ReleaseVersion: md.releaseVersion,
+			ImageRef:       md.img,
+		})
+
+		return
+	}
+
+	type releaseJSON struct {
+		ID       string `json:"id"`
+		Version  int    `json:"version"`
+		ImageRef string `json:"image_ref"`
+	}
+	if data, err := json.Marshal(releaseJSON{
+		ID:       md.releaseId,
+		Version:  md.releaseVersion,
+		ImageRef: md.img,
+	}); err == nil {
+		fmt.Fprintf(md.io.Out, "%s\n", data)
+	}
+}
+
+func (md *machineDeployment) updateMachine(ctx context.Context, e *machineUpdateEntry, sl statuslogger.StatusLine) (err error) {
 	ctx, span := tracing.GetTracer().Start(ctx, "update_machine", trace.WithAttributes(
 		attribute.String("id", e.launchInput.ID),
 		attribute.Bool("requires_replacement", e.launchInput.RequiresReplacement),
@@ -167,6 +195,15 @@ func (md *machineDeployment) updateMachine(ctx context.Context, e *machineUpdate
 	defer span.End()
 
 	fmtID := e.leasableMachine.FormattedMachineId()
+	journalID := e.leasableMachine.Machine().ID
+	md.journal.transition(journalID, JournalMachineUpdating, e.leasableMachine.LeaseNonce(), "")
+	defer func() {
+		if err != nil {
+			md.journal.transition(journalID, JournalMachineFailed, "", "")
+		} else {
+			md.journal.transition(journalID, JournalMachineUpdated, "", e.leasableMachine.Machine().ID)
+		}
+	}()
 
 	replaceMachine := func() error {
 		sl.Logf("Replacing %s by new machine", fmtID)
@@ -469,6 +506,10 @@ func (md *machineDeployment) deployMachinesApp(ctx context.Context) error {
 	ctx, span := tracing.GetTracer().Start(ctx, "deploy_new_machines")
 	defer span.End()
 
+	if err := runDeployHooks(ctx, &deployHookRun{cfg: md.appConfig, phase: appconfig.DeployHookPreRelease, image: md.img}); err != nil {
+		return err
+	}
+
 	if !md.skipReleaseCommand {
 		if err := md.runReleaseCommands(ctx); err != nil {
 			return fmt.Errorf("release command failed - aborting deployment. %w", err)
@@ -600,6 +641,10 @@ func (md *machineDeployment) updateExistingMachines(ctx context.Context, updateE
 		span.End()
 	}()
 
+	md.journal.begin(md.releaseId, md.releaseVersion, lo.Map(updateEntries, func(e *machineUpdateEntry, _ int) machinePairing {
+		return machinePairingForUpdate(e.leasableMachine.Machine(), e.launchInput)
+	}))
+
 	if md.deployRetries > 0 {
 		err := md.updateExistingMachinesWRecovery(ctx, updateEntries)
 		if err != nil {
@@ -629,6 +674,8 @@ func (md *machineDeployment) updateExistingMachines(ctx context.Context, updateE
 		err = md.updateUsingBlueGreenStrategy(ctx, updateEntries)
 	case "immediate":
 		err = md.updateUsingImmediateStrategy(ctx, updateEntries)
+	case "progressive":
+		err = md.updateUsingProgressiveStrategy(ctx, updateEntries)
 	case "canary", "rolling":
 		fallthrough
 	default:
@@ -668,7 +715,8 @@ func (md *machineDeployment) updateExistingMachinesWRecovery(ctx context.Context
 
 	newAppState := *oldAppState
 	newAppState.Machines = lo.Map(updateEntries, func(e *machineUpdateEntry, _ int) *fly.Machine {
-		newMach := e.leasableMachine.Machine()
+		// a copy, the leasable machine is what strategies revert to on failure
+		newMach := helpers.Clone(e.leasableMachine.Machine())
 		if !e.launchInput.SkipLaunch {
 			newMach.State = "started"
 		}
@@ -693,6 +741,16 @@ func (md *machineDeployment) updateExistingMachinesWRecovery(ctx context.Context
 
 		// TODO(billy) do machine checks here
 		return md.updateUsingBlueGreenStrategy(ctx, updateEntries)
+	case "progressive":
+		if err := md.machineSet.AcquireLeases(ctx, md.leaseTimeout); err != nil {
+			tracing.RecordError(span, err, "failed to acquire lease")
+
+			return err
+		}
+		defer md.machineSet.ReleaseLeases(ctx) // skipcq: GO-S2307
+		md.machineSet.StartBackgroundLeaseRefresh(ctx, md.leaseTimeout, md.leaseDelayBetween)
+
+		return md.updateUsingProgressiveStrategy(ctx, updateEntries)
 	case "immediate":
 		return md.updateMachinesWRecovery(ctx, oldAppState, oldAppState, &newAppState, nil, updateMachineSettings{
 			pushForward:          true,
@@ -1243,16 +1301,16 @@ func (md *machineDeployment) warnAboutProcessGroupChanges(diff ProcessGroupsDiff
 		bullet := md.colorize.Green("*")
 		for name := range diff.groupsNeedingMachines {
 			var description string
-			groupConfig, err := md.appConfig.Flatten(name)
+			machines, standbys, err := md.machinesForNewGroup(name)
 			switch {
 			case err != nil:
 				continue
-			case !md.increasedAvailability || len(groupConfig.Mounts) > 0:
+			case standbys > 0:
+				description = fmt.Sprintf("1 \"%s\" machine and 1 standby machine for it", name)
+			case machines == 1:
 				description = fmt.Sprintf("1 \"%s\" machine", name)
-			case len(groupConfig.AllServices()) > 0:
-				description = fmt.Sprintf("2 \"%s\" machines", name)
 			default:
-				description = fmt.Sprintf("1 \"%s\" machine and 1 standby machine for it", name)
+				description = fmt.Sprintf("%d \"%s\" machines", machines, name)
 			}
 			fmt.Fprintf(md.io.Out, " %s create %s\n", bullet, description)
 		}
@@ -1260,6 +1318,22 @@ func (md *machineDeployment) warnAboutProcessGroupChanges(diff ProcessGroupsDiff
 	fmt.Fprint(md.io.Out, "\n")
 }
 
+// machinesForNewGroup returns how many machines and standbys deployCreateMachinesForGroups
+// launches for a process group that has no machines yet.
+func (md *machineDeployment) machinesForNewGroup(name string) (machines, standbys int, err error) {
+	groupConfig, err := md.appConfig.Flatten(name)
+	switch {
+	case err != nil:
+		return 0, 0, err
+	case !md.increasedAvailability || len(groupConfig.Mounts) > 0:
+		return 1, 0, nil
+	case len(groupConfig.AllServices()) > 0:
+		return 2, 0, nil
+	default:
+		return 1, 1, nil
+	}
+}
+
 func (md *machineDeployment) warnAboutIncorrectListenAddress(ctx context.Context, lm machine.LeasableMachine) {
 	group := lm.Machine().ProcessGroup()
 
@@ -1271,14 +1345,6 @@ func (md *machineDeployment) warnAboutIncorrectListenAddress(ctx context.Context
 	if err != nil {
 		return
 	}
-	services := groupConfig.AllServices()
-
-	tcpServices := make(map[int]struct{})
-	for _, s := range services {
-		if s.Protocol == "tcp" {
-			tcpServices[s.InternalPort] = struct{}{}
-		}
-	}
 
 	processes, err := md.flapsClient.GetProcesses(ctx, md.app.Name, lm.Machine().ID)
 	// Let's not fail the whole deployment because of this, as listen address check is just a warning
@@ -1286,35 +1352,11 @@ func (md *machineDeployment) warnAboutIncorrectListenAddress(ctx context.Context
 		return
 	}
 
-	var foundSockets int
-	for _, proc := range processes {
-		for _, ls := range proc.ListenSockets {
-			foundSockets += 1
-
-			host, portStr, err := net.SplitHostPort(ls.Address)
-			if err != nil {
-				continue
-			}
-			port, err := strconv.Atoi(portStr)
-			if err != nil {
-				continue
-			}
-
-			ip := net.ParseIP(host)
-
-			// We don't know VM's internal ipv4 which is also a valid address to bind to.
-			// Let's assume that whoever binds to a non-loopback address knows what they are doing.
-			// If we expose this address to flyctl later, we can revisit this logic.
-			if !ip.IsLoopback() {
-				delete(tcpServices, port)
-			}
-		}
-	}
-
-	// This can either mean that nothing is listening or that VM is running old init that doesn't expose
+	// Unknown can either mean that nothing is listening or that VM is running old init that doesn't expose
 	// listen sockets. Until we have a way to update init on already created VMs let's ignore this
 	// and pretend that this is old init.
-	if foundSockets == 0 {
+	tcpServices, known := groupConfig.UnreachableTCPPorts(processes)
+	if !known {
 		return
 	}
 
@@ -1325,7 +1367,7 @@ func (md *machineDeployment) warnAboutIncorrectListenAddress(ctx context.Context
 
 	fmt.Fprintf(md.io.ErrOut, "\n%s The app is not listening on the expected address and will not be reachable by fly-proxy.\n", md.colorize.Yellow("WARNING"))
 	fmt.Fprintf(md.io.ErrOut, "You can fix this by configuring your app to listen on the following addresses:\n")
-	for port := range tcpServices {
+	for _, port := range tcpServices {
 		fmt.Fprintf(md.io.ErrOut, "  - %s\n", md.colorize.Green("0.0.0.0:"+strconv.Itoa(port)))
 	}
 	fmt.Fprintf(md.io.ErrOut, "Found these processes inside the machine with open listening sockets:\n")
@@ -1388,6 +1430,11 @@ func (md *machineDeployment) doSmokeChecks(ctx context.Context, lm machine.Leasa
 		machineID: lm.Machine().ID,
 		err:       err,
 	}
+	statuslogger.Emit(ctx, statuslogger.Event{
+		Type:      statuslogger.EventSmokeCheckFailed,
+		MachineID: smokeErr.machineID,
+		Error:     err.Error(),
+	})
 
 	if showLogs {
 		resumeLogFn := statuslogger.Pause(ctx)
@@ -1,6 +1,7 @@
 package deploy
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/superfly/flyctl/internal/flapsutil"
 	"github.com/superfly/flyctl/internal/machine"
 	"github.com/superfly/flyctl/internal/mock"
+	"github.com/superfly/flyctl/internal/statuslogger"
 	"github.com/superfly/flyctl/iostreams"
 )
 
@@ -40,6 +42,21 @@ func TestUpdateExistingMachinesWRecovery(t *testing.T) {
 	assert.Error(t, err, "failed to find machine test-machine-id")
 }
 
+func TestEmitReleaseJSON(t *testing.T) {
+	ios, _, out, _ := iostreams.Test()
+	md := &machineDeployment{io: ios, releaseId: "rel-1", releaseVersion: 7, img: "registry.fly.io/app:deployment-1"}
+
+	md.emitReleaseJSON(context.Background())
+	assert.JSONEq(t, `{"id":"rel-1","version":7,"image_ref":"registry.fly.io/app:deployment-1"}`, out.String())
+
+	// with an event stream, stdout only carries events
+	out.Reset()
+	var events bytes.Buffer
+	md.emitReleaseJSON(statuslogger.NewEventSinkContext(context.Background(), statuslogger.NewEventSink(&events)))
+	assert.Empty(t, out.String())
+	assert.Contains(t, events.String(), `"type":"release","release_id":"rel-1","release_version":7,"image_ref":"registry.fly.io/app:deployment-1"`)
+}
+
 func TestDeployMachinesApp(t *testing.T) {
 	ios, _, _, _ := iostreams.Test()
 	client := &mockFlapsClient{}
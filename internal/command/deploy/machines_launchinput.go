@@ -44,10 +44,10 @@ func (md *machineDeployment) launchInputForLaunch(processGroup string, guest *fl
 		mConfig.Guest = guest
 	}
 
-	mConfig.Image = md.img
-	md.setMachineReleaseData(mConfig)
 	// Get the final process group and prevent empty string
 	processGroup = mConfig.ProcessGroup()
+	mConfig.Image = md.imageFor(processGroup)
+	md.setMachineReleaseData(mConfig)
 	region := md.appConfig.PrimaryRegion
 
 	if len(mConfig.Mounts) > 0 {
@@ -101,10 +101,10 @@ func (md *machineDeployment) launchInputForUpdate(origMachineRaw *fly.Machine) (
 	if err != nil {
 		return nil, err
 	}
-	mConfig.Image = md.img
-	md.setMachineReleaseData(mConfig)
 	// Get the final process group and prevent empty string
 	processGroup = mConfig.ProcessGroup()
+	mConfig.Image = md.imageFor(processGroup)
+	md.setMachineReleaseData(mConfig)
 
 	// Update container image
 	if err = md.updateContainerImage(mConfig); err != nil {
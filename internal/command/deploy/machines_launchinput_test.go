@@ -35,6 +35,40 @@ func TestLaunchInputForUpdate(t *testing.T) {
 	t.Run("LaunchFiles", testLaunchInputForLaunchFiles)
 	t.Run("LaunchFiles", testLaunchInputForUpdateFiles)
 	t.Run("ImageConfigSubstitution", testUpdateContainerImageConfig)
+	t.Run("ProcessImages", testLaunchInputForProcessImages)
+}
+
+// Test machines of process groups with images of their own
+func testLaunchInputForProcessImages(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{
+		AppName:       "my-cool-app",
+		PrimaryRegion: "scl",
+		Processes: map[string]string{
+			"web":    "npm start",
+			"worker": "npm run worker",
+		},
+	})
+	require.NoError(t, err)
+	md.processImages = map[string]string{"worker": "super/chrome"}
+
+	li, err := md.launchInputForLaunch("web", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "super/balloon", li.Config.Image)
+
+	li, err = md.launchInputForLaunch("worker", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "super/chrome", li.Config.Image)
+
+	md.processImages["worker"] = "super/chromium"
+	li, err = md.launchInputForUpdate(&fly.Machine{
+		ID:         "ab1234567890",
+		Region:     li.Region,
+		Config:     helpers.Clone(li.Config),
+		HostStatus: fly.HostStatusOk,
+		State:      fly.MachineStateStarted,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "super/chromium", li.Config.Image)
 }
 
 // Test the basic flow of launching, restarting and updating a machine for default process group
@@ -129,6 +129,13 @@ func (md *machineDeployment) runReleaseCommand(ctx context.Context, commandType
 
 				return
 			}
+			statuslogger.Emit(ctx, statuslogger.Event{
+				Type:      statuslogger.EventReleaseCommandOutput,
+				Time:      ts,
+				MachineID: releaseCmdMachine.Machine().ID,
+				Command:   commandType,
+				Message:   entry.Message,
+			})
 			msg := fmt.Sprintf("%s %s", aurora.Faint(format.Time(ts)), entry.Message)
 			if buf != nil {
 				buf.Value = msg
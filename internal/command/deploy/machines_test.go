@@ -2,6 +2,7 @@ package deploy
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -188,6 +189,37 @@ func testMachineDeploymentForSetMachines(t *testing.T, strategy string, flyLaunc
 	}
 }
 
+func TestSetImgWithOnlyProcessImages(t *testing.T) {
+	worker := testProcessGroupMachine("m1", "ord", map[string]string{fly.MachineConfigMetadataKeyFlyProcessGroup: "worker"})
+	worker.Config.Image = "registry.fly.io/my-cool-app:deployment-2-worker"
+	web := testProcessGroupMachine("m2", "ord", map[string]string{fly.MachineConfigMetadataKeyFlyProcessGroup: "web"})
+	web.Config.Image = "registry.fly.io/my-cool-app:deployment-1"
+
+	ios, _, _, _ := iostreams.Test()
+	machines := []*fly.Machine{worker, web}
+	md := &machineDeployment{
+		app: &flaps.App{Name: "my-cool-app"},
+		flapsClient: &mock.FlapsClient{
+			ListFunc: func(ctx context.Context, appName, state string) ([]*fly.Machine, error) {
+				return machines, nil
+			},
+		},
+		appConfig: &appconfig.Config{
+			Processes: map[string]string{"web": "web", "worker": "worker"},
+			Build:     &appconfig.Build{Processes: map[string]*appconfig.ProcessBuild{"worker": {DockerBuildTarget: "worker"}}},
+		},
+		// --process-groups worker builds the worker's image only
+		processImages: map[string]string{"worker": "registry.fly.io/my-cool-app:deployment-3-worker"},
+		machineSet:    machine.NewMachineSet(nil, ios, "", []*fly.Machine{worker}, true),
+	}
+
+	require.NoError(t, md.setImg(context.Background()))
+	assert.Equal(t, "registry.fly.io/my-cool-app:deployment-1", md.img, "the app's image, not the worker's")
+
+	md.img, machines = "", []*fly.Machine{worker}
+	assert.ErrorContains(t, md.setImg(context.Background()), "no machine runs it")
+}
+
 // Test any LaunchMachineInput field that must not be set on a machine
 // used to run release command.
 func Test_resolveUpdatedMachineConfig_ReleaseCommand(t *testing.T) {
@@ -535,3 +567,22 @@ func Test_resolveUpdatedMachineConfig_restartOnlyProcessGroup(t *testing.T) {
 		MinSecretsVersion: nil,
 	}, got)
 }
+
+func TestReleaseMetadataRecordsSecretsVersion(t *testing.T) {
+	version := uint64(1<<63 + 1)
+	encoded, err := json.Marshal(&releaseMetadata{
+		ReleaseMetadata: &fly.ReleaseMetadata{PostDeploymentInfo: fly.PostDeploymentInfo{Error: "boom"}},
+		ReleaseSecrets:  appconfig.ReleaseSecrets{SecretsVersion: &version},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"post_deployment_info":{"flyctl_version":"","error":"boom"},"secrets_version":9223372036854775809}`, string(encoded))
+
+	var secrets appconfig.ReleaseSecrets
+	require.NoError(t, json.Unmarshal(encoded, &secrets))
+	assert.Equal(t, version, *secrets.SecretsVersion)
+
+	// a release still running has no deploy outcome yet
+	encoded, err = json.Marshal(&releaseMetadata{ReleaseSecrets: appconfig.ReleaseSecrets{SecretsVersion: &version}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"secrets_version":9223372036854775809}`, string(encoded))
+}
@@ -21,6 +21,7 @@ type DeployManifest struct {
 	AppName               string
 	Config                *appconfig.Config         `json:"config"`
 	DeploymentImage       string                    `json:"deployment_image,omitempty"`
+	ProcessImages         map[string]string         `json:"process_images,omitempty"`
 	Strategy              string                    `json:"strategy,omitempty"`
 	EnvFromFlags          []string                  `json:"env_from_flags,omitempty"`
 	PrimaryRegionFlag     string                    `json:"primary_region_flag,omitempty"`
@@ -56,6 +57,7 @@ func NewManifest(AppName string, config *appconfig.Config, args MachineDeploymen
 		AppName:               AppName,
 		Config:                config,
 		DeploymentImage:       args.DeploymentImage,
+		ProcessImages:         args.ProcessImages,
 		Strategy:              args.Strategy,
 		EnvFromFlags:          args.EnvFromFlags,
 		PrimaryRegionFlag:     args.PrimaryRegionFlag,
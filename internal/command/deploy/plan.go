@@ -65,6 +65,20 @@ type machinePairing struct {
 	newMachine      *fly.Machine
 }
 
+// machinePairingForUpdate pairs a machine with the machine it becomes once launchInput is applied.
+func machinePairingForUpdate(m *fly.Machine, launchInput *fly.LaunchMachineInput) machinePairing {
+	newMachine := *m
+	newMachine.Config = launchInput.Config
+	if !launchInput.SkipLaunch {
+		newMachine.State = fly.MachineStateStarted
+	}
+	if launchInput.RequiresReplacement {
+		newMachine.State = "replacing"
+	}
+
+	return machinePairing{originalMachine: m, oldMachine: m, newMachine: &newMachine}
+}
+
 // appState returns the app's state from Flaps.
 func (md *machineDeployment) appState(ctx context.Context, existingAppState *AppState) (*AppState, error) {
 	ctx, span := tracing.GetTracer().Start(ctx, "app_state")
@@ -546,34 +560,41 @@ func (e *unrecoverableError) Unwrap() error {
 	return e.err
 }
 
-func compareConfigs(ctx context.Context, oldConfig, newConfig *fly.MachineConfig) bool {
-	_, span := tracing.GetTracer().Start(ctx, "compare_configs")
-	defer span.End()
+// compareConfigsOptions are the cmp options shared by compareConfigs and diffConfigs.
+var compareConfigsOptions = cmp.Options{
+	cmp.FilterPath(func(p cmp.Path) bool {
+		vx := p.Last().String()
 
-	opt := cmp.Options{
-		cmp.FilterPath(func(p cmp.Path) bool {
-			vx := p.Last().String()
+		// ignore the flyctl version used for the deployment. this is mostly useful for testing
+		if vx == `["fly_flyctl_version"]` {
+			return true
+		}
 
-			// ignore the flyctl version used for the deployment. this is mostly useful for testing
-			if vx == `["fly_flyctl_version"]` {
-				return true
-			}
+		return false
+	}, cmp.Ignore()),
+	// Treat nil slices and empty slices as equal to avoid spurious diffs
+	// from JSON roundtripping (e.g. API returns [] where flyctl sent nil).
+	cmp.FilterValues(func(x, y interface{}) bool {
+		return isEmptyOrNilSlice(x) && isEmptyOrNilSlice(y)
+	}, cmp.Ignore()),
+}
 
-			return false
-		}, cmp.Ignore()),
-		// Treat nil slices and empty slices as equal to avoid spurious diffs
-		// from JSON roundtripping (e.g. API returns [] where flyctl sent nil).
-		cmp.FilterValues(func(x, y interface{}) bool {
-			return isEmptyOrNilSlice(x) && isEmptyOrNilSlice(y)
-		}, cmp.Ignore()),
-	}
+func compareConfigs(ctx context.Context, oldConfig, newConfig *fly.MachineConfig) bool {
+	_, span := tracing.GetTracer().Start(ctx, "compare_configs")
+	defer span.End()
 
-	isEqual := cmp.Equal(oldConfig, newConfig, opt)
+	isEqual := cmp.Equal(oldConfig, newConfig, compareConfigsOptions)
 	span.SetAttributes(attribute.Bool("configs_equal", isEqual))
 
 	return isEqual
 }
 
+// diffConfigs returns a human readable diff between two machine configs, ignoring the
+// same fields compareConfigs does. It's empty when the configs are equal.
+func diffConfigs(oldConfig, newConfig *fly.MachineConfig) string {
+	return cmp.Diff(oldConfig, newConfig, compareConfigsOptions)
+}
+
 func isEmptyOrNilSlice(v interface{}) bool {
 	if v == nil {
 		return true
@@ -25,6 +25,7 @@ import (
 	"github.com/superfly/flyctl/internal/ctrlc"
 	"github.com/superfly/flyctl/internal/flapsutil"
 	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/statuslogger"
 	"github.com/superfly/flyctl/internal/tracing"
 	"github.com/superfly/flyctl/iostreams"
 )
@@ -1022,6 +1023,8 @@ func (bg *blueGreen) Rollback(ctx context.Context, err error) error {
 	defer span.End()
 
 	if bg.rollbackLog.disableRollback {
+		statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventRollback, Strategy: "bluegreen", Decision: "skip", Error: err.Error()})
+
 		return nil
 	}
 
@@ -1032,17 +1035,22 @@ func (bg *blueGreen) Rollback(ctx context.Context, err error) error {
 		return nil
 	}
 
-	if bg.CanDestroyGreenMachines(err) {
-		fmt.Fprintf(bg.io.ErrOut, "\nRolling back failed deployment\n")
-		for _, mach := range bg.greenMachines.machines() {
-			err := mach.Destroy(ctx, true)
-			if err != nil {
-				tracing.RecordError(span, err, "failed to destroy green machine")
+	if !bg.CanDestroyGreenMachines(err) {
+		statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventRollback, Strategy: "bluegreen", Decision: "skip", Error: err.Error()})
 
-				return err
-			}
-			fmt.Fprintf(bg.io.ErrOut, "  Deleted machine %s\n", bg.colorize.Bold(mach.FormattedMachineId()))
+		return nil
+	}
+
+	statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventRollback, Strategy: "bluegreen", Decision: "rollback", Error: err.Error()})
+	fmt.Fprintf(bg.io.ErrOut, "\nRolling back failed deployment\n")
+	for _, mach := range bg.greenMachines.machines() {
+		err := mach.Destroy(ctx, true)
+		if err != nil {
+			tracing.RecordError(span, err, "failed to destroy green machine")
+
+			return err
 		}
+		fmt.Fprintf(bg.io.ErrOut, "  Deleted machine %s\n", bg.colorize.Bold(mach.FormattedMachineId()))
 	}
 
 	return nil
@@ -0,0 +1,277 @@
+package deploy
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/pool"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/statuslogger"
+	"github.com/superfly/flyctl/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrProgressiveGate = errors.New("progressive deploy health gate failed")
+
+// progressiveEntry is a machine update along with the machine as it was before
+// the deploy began, which is what we revert to if a step fails.
+type progressiveEntry struct {
+	*machineUpdateEntry
+	originalMachine *fly.Machine
+}
+
+// progressiveBatches splits entries into one batch per step. Each step covers a
+// cumulative percentage of every process group, so groups grow at the same pace
+// regardless of their size. Empty batches are dropped.
+func progressiveBatches(entries []*progressiveEntry, steps []int) [][]*progressiveEntry {
+	byGroup := lo.GroupBy(entries, func(e *progressiveEntry) string {
+		return e.launchInput.Config.ProcessGroup()
+	})
+	groups := lo.Keys(byGroup)
+	slices.Sort(groups)
+
+	batches := make([][]*progressiveEntry, len(steps))
+	for _, group := range groups {
+		groupEntries := byGroup[group]
+		done := 0
+		for idx, pct := range steps {
+			target := int(math.Ceil(float64(len(groupEntries)) * float64(pct) / 100))
+			target = min(max(target, done), len(groupEntries))
+			batches[idx] = append(batches[idx], groupEntries[done:target]...)
+			done = target
+		}
+	}
+
+	return slices.DeleteFunc(batches, func(b []*progressiveEntry) bool {
+		return len(b) == 0
+	})
+}
+
+// updateUsingProgressiveStrategy updates machines in growing steps, waiting for a
+// pause and a health gate between each. Machines updated so far are reverted to
+// their original config when a step or gate fails, unless auto rollback is off.
+func (md *machineDeployment) updateUsingProgressiveStrategy(ctx context.Context, updateEntries []*machineUpdateEntry) error {
+	ctx, span := tracing.GetTracer().Start(ctx, "progressive")
+	defer span.End()
+
+	entries := lo.Map(updateEntries, func(e *machineUpdateEntry, _ int) *progressiveEntry {
+		return &progressiveEntry{machineUpdateEntry: e, originalMachine: helpers.Clone(e.leasableMachine.Machine())}
+	})
+	slices.SortFunc(entries, func(a, b *progressiveEntry) int {
+		return cmp.Compare(a.originalMachine.ID, b.originalMachine.ID)
+	})
+
+	batches := progressiveBatches(entries, md.appConfig.Deploy.ProgressiveSteps())
+	pause := md.appConfig.Deploy.ProgressivePause()
+	span.SetAttributes(attribute.Int("steps", len(batches)), attribute.Float64("pause", pause.Seconds()))
+
+	var updated []*progressiveEntry
+	for idx, batch := range batches {
+		fmt.Fprintf(md.io.Out, "Progressive step %d/%d: updating %d of %d machines\n", idx+1, len(batches), len(updated)+len(batch), len(entries))
+
+		touched, err := md.progressiveUpdateBatch(ctx, batch)
+		updated = append(updated, touched...)
+		if err == nil && idx < len(batches)-1 {
+			err = md.progressiveGate(ctx, updated, pause)
+		}
+		if err != nil {
+			tracing.RecordError(span, err, "progressive step failed")
+
+			return md.progressiveRollback(ctx, updated, err)
+		}
+	}
+
+	return nil
+}
+
+// progressiveUpdateBatch updates and waits for every machine in the batch. It returns
+// the entries whose config was changed, even if they later failed their checks.
+func (md *machineDeployment) progressiveUpdateBatch(ctx context.Context, batch []*progressiveEntry) ([]*progressiveEntry, error) {
+	sl := statuslogger.Create(ctx, len(batch), true)
+	defer sl.Destroy(false)
+
+	var (
+		mu      sync.Mutex
+		touched []*progressiveEntry
+	)
+
+	updatePool := pool.New().
+		WithErrors().
+		WithMaxGoroutines(md.maxConcurrent).
+		WithContext(ctx).
+		WithCancelOnError()
+
+	for idx, e := range batch {
+		line := sl.Line(idx)
+		eCtx := statuslogger.NewContext(ctx, line)
+
+		updatePool.Go(func(poolCtx context.Context) error {
+			fmtID := e.leasableMachine.FormattedMachineId()
+			if err := poolCtx.Err(); err != nil {
+				line.LogStatus(statuslogger.StatusFailure, fmt.Sprintf("Machine %s update %s", md.colorize.Bold(fmtID), md.colorize.Yellow("canceled")))
+
+				return err
+			}
+
+			line.LogStatus(statuslogger.StatusRunning, fmt.Sprintf("Updating %s", md.colorize.Bold(fmtID)))
+			if err := md.updateMachine(eCtx, e.machineUpdateEntry, line); err != nil {
+				line.LogStatus(statuslogger.StatusFailure, fmt.Sprintf("Machine %s update %s: %s", md.colorize.Bold(fmtID), md.colorize.Red("failed"), err))
+
+				return err
+			}
+
+			mu.Lock()
+			touched = append(touched, e)
+			mu.Unlock()
+
+			if err := md.waitForMachine(eCtx, e.machineUpdateEntry, line); err != nil {
+				line.LogStatus(statuslogger.StatusFailure, fmt.Sprintf("Machine %s update %s: %s", md.colorize.Bold(fmtID), md.colorize.Red("failed"), err))
+
+				return err
+			}
+
+			line.LogStatus(statuslogger.StatusSuccess, fmt.Sprintf("Machine %s update %s", md.colorize.Bold(fmtID), md.colorize.Green("succeeded")))
+
+			return nil
+		})
+	}
+
+	err := updatePool.Wait()
+
+	return touched, err
+}
+
+// progressiveGate waits for the configured pause and then checks that every machine
+// updated so far is still passing its health checks.
+func (md *machineDeployment) progressiveGate(ctx context.Context, updated []*progressiveEntry, pause time.Duration) error {
+	ctx, span := tracing.GetTracer().Start(ctx, "progressive_gate", trace.WithAttributes(
+		attribute.Int("machines", len(updated)),
+	))
+	defer span.End()
+
+	if pause > 0 {
+		fmt.Fprintf(md.io.Out, "Waiting %s before checking the health of updated machines\n", pause)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pause):
+		}
+	}
+
+	if md.skipHealthChecks {
+		return nil
+	}
+
+	gatePool := pool.New().
+		WithErrors().
+		WithMaxGoroutines(md.maxConcurrent).
+		WithContext(ctx)
+
+	for _, e := range updated {
+		if e.launchInput.SkipLaunch {
+			continue
+		}
+		lm := e.leasableMachine
+		gatePool.Go(func(ctx context.Context) error {
+			if err := lm.WaitForHealthchecksToPass(ctx, md.waitTimeout); err != nil {
+				return fmt.Errorf("%w: machine %s: %w", ErrProgressiveGate, lm.FormattedMachineId(), err)
+			}
+
+			return nil
+		})
+	}
+
+	if err := gatePool.Wait(); err != nil {
+		tracing.RecordError(span, err, "health gate failed")
+
+		return err
+	}
+
+	fmt.Fprintf(md.io.Out, "%s %d updated machines are healthy\n", md.colorize.Green("✓"), len(updated))
+
+	return nil
+}
+
+// progressiveRollback reverts the given machines to the config they had before the
+// deploy began and returns the error that caused the rollback.
+func (md *machineDeployment) progressiveRollback(ctx context.Context, updated []*progressiveEntry, cause error) error {
+	if !md.appConfig.Deploy.ProgressiveAutoRollback() || len(updated) == 0 {
+		statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventRollback, Strategy: "progressive", Decision: "skip", Error: cause.Error()})
+
+		return suggestChangeWaitTimeout(cause, "wait-timeout")
+	}
+	statuslogger.Emit(ctx, statuslogger.Event{Type: statuslogger.EventRollback, Strategy: "progressive", Decision: "rollback", Error: cause.Error()})
+
+	// Keep rolling back even if the deploy was interrupted.
+	ctx = context.WithoutCancel(ctx)
+	ctx, span := tracing.GetTracer().Start(ctx, "progressive_rollback", trace.WithAttributes(
+		attribute.Int("machines", len(updated)),
+	))
+	defer span.End()
+
+	fmt.Fprintf(md.io.ErrOut, "%s %s\nReverting %d machines to their previous configuration\n", md.colorize.Red("Progressive deploy failed:"), cause, len(updated))
+
+	sl := statuslogger.Create(ctx, len(updated), true)
+	defer sl.Destroy(false)
+
+	rollbackPool := pool.New().
+		WithErrors().
+		WithMaxGoroutines(md.maxConcurrent).
+		WithContext(ctx)
+
+	for idx, e := range updated {
+		line := sl.Line(idx)
+		rollbackPool.Go(func(ctx context.Context) error {
+			ctx = statuslogger.NewContext(ctx, line)
+			original := e.originalMachine
+			config := machine.CloneConfig(original.Config)
+			revert := &machineUpdateEntry{
+				leasableMachine: e.leasableMachine,
+				launchInput: &fly.LaunchMachineInput{
+					Config:              config,
+					Region:              original.Region,
+					SkipLaunch:          shouldSkipLaunch(original, config),
+					RequiresReplacement: e.launchInput.RequiresReplacement,
+				},
+			}
+
+			fmtID := revert.leasableMachine.FormattedMachineId()
+			line.LogStatus(statuslogger.StatusRunning, fmt.Sprintf("Reverting %s", md.colorize.Bold(fmtID)))
+			if err := md.updateMachine(ctx, revert, line); err != nil {
+				line.LogStatus(statuslogger.StatusFailure, fmt.Sprintf("Machine %s revert %s: %s", md.colorize.Bold(fmtID), md.colorize.Red("failed"), err))
+
+				return fmt.Errorf("failed to revert machine %s: %w", fmtID, err)
+			}
+
+			if !revert.launchInput.SkipLaunch && !md.skipHealthChecks {
+				if err := revert.leasableMachine.WaitForState(ctx, fly.MachineStateStarted, md.waitTimeout, machine.WithJustCreated()); err != nil {
+					line.LogStatus(statuslogger.StatusFailure, fmt.Sprintf("Machine %s revert %s: %s", md.colorize.Bold(fmtID), md.colorize.Red("failed"), err))
+
+					return fmt.Errorf("failed to revert machine %s: %w", fmtID, err)
+				}
+			}
+
+			line.LogStatus(statuslogger.StatusSuccess, fmt.Sprintf("Machine %s %s", md.colorize.Bold(revert.leasableMachine.FormattedMachineId()), md.colorize.Green("reverted")))
+
+			return nil
+		})
+	}
+
+	if err := rollbackPool.Wait(); err != nil {
+		tracing.RecordError(span, err, "failed to roll back")
+
+		return fmt.Errorf("progressive deploy failed: %w; rollback also failed: %w", cause, err)
+	}
+
+	return fmt.Errorf("progressive deploy failed: %w, %w", suggestChangeWaitTimeout(cause, "wait-timeout"), errDeployRolledBack)
+}
@@ -0,0 +1,252 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/mock"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func testProgressiveEntry(client *mock.FlapsClient, ios *iostreams.IOStreams, id, group, image string) *machineUpdateEntry {
+	m := &fly.Machine{
+		ID:         id,
+		State:      fly.MachineStateStarted,
+		LeaseNonce: "nonce-" + id,
+		Config: &fly.MachineConfig{
+			Image:    image,
+			Metadata: map[string]string{fly.MachineConfigMetadataKeyFlyProcessGroup: group},
+		},
+	}
+	newConfig := machine.CloneConfig(m.Config)
+	newConfig.Image = "new-image"
+
+	return &machineUpdateEntry{
+		leasableMachine: machine.NewLeasableMachine(client, ios, "my-app", m, false),
+		launchInput:     &fly.LaunchMachineInput{ID: id, Config: newConfig},
+	}
+}
+
+func TestProgressiveBatches(t *testing.T) {
+	ios, _, _, _ := iostreams.Test()
+	client := &mock.FlapsClient{}
+
+	var entries []*progressiveEntry
+	for i := range 10 {
+		e := testProgressiveEntry(client, ios, fmt.Sprintf("app-%02d", i), "app", "old-image")
+		entries = append(entries, &progressiveEntry{machineUpdateEntry: e, originalMachine: e.leasableMachine.Machine()})
+	}
+	for i := range 2 {
+		e := testProgressiveEntry(client, ios, fmt.Sprintf("worker-%d", i), "worker", "old-image")
+		entries = append(entries, &progressiveEntry{machineUpdateEntry: e, originalMachine: e.leasableMachine.Machine()})
+	}
+
+	batches := progressiveBatches(entries, []int{10, 25, 50, 100})
+	require.Len(t, batches, 4)
+
+	sizes := func(batch []*progressiveEntry) map[string]int {
+		out := map[string]int{}
+		for _, e := range batch {
+			out[e.launchInput.Config.ProcessGroup()]++
+		}
+
+		return out
+	}
+	assert.Equal(t, map[string]int{"app": 1, "worker": 1}, sizes(batches[0]))
+	assert.Equal(t, map[string]int{"app": 2}, sizes(batches[1]))
+	assert.Equal(t, map[string]int{"app": 2}, sizes(batches[2]))
+	assert.Equal(t, map[string]int{"app": 5, "worker": 1}, sizes(batches[3]))
+
+	// A single machine is updated in the first step and nothing is left for later steps.
+	batches = progressiveBatches(entries[:1], []int{10, 25, 50, 100})
+	require.Len(t, batches, 1)
+	assert.Len(t, batches[0], 1)
+}
+
+func TestUpdateUsingProgressiveStrategyRollsBack(t *testing.T) {
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+
+	var (
+		mu      sync.Mutex
+		updates []string
+	)
+	client := &mock.FlapsClient{
+		UpdateFunc: func(ctx context.Context, appName string, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if input.ID == "m3" && input.Config.Image == "new-image" {
+				return nil, fmt.Errorf("could not update %s", input.ID)
+			}
+			updates = append(updates, input.ID+"="+input.Config.Image)
+
+			return &fly.Machine{ID: input.ID, State: fly.MachineStateStarted, LeaseNonce: nonce, Config: input.Config}, nil
+		},
+		GetProcessesFunc: func(ctx context.Context, appName, machineID string) (fly.MachinePsResponse, error) {
+			return nil, nil
+		},
+	}
+
+	md := &machineDeployment{
+		app:              &flaps.App{Name: "my-app"},
+		io:               ios,
+		colorize:         ios.ColorScheme(),
+		flapsClient:      client,
+		strategy:         "progressive",
+		skipHealthChecks: true,
+		skipSmokeChecks:  true,
+		maxConcurrent:    1,
+		waitTimeout:      time.Second,
+		appConfig: &appconfig.Config{
+			Deploy: &appconfig.Deploy{
+				Strategy:    "progressive",
+				Progressive: &appconfig.Progressive{Steps: []int{25, 50}},
+			},
+		},
+	}
+
+	var entries []*machineUpdateEntry
+	for _, id := range []string{"m1", "m2", "m3", "m4"} {
+		entries = append(entries, testProgressiveEntry(client, ios, id, "app", "old-image"))
+	}
+
+	err := md.updateUsingProgressiveStrategy(ctx, entries)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rolled back")
+	assert.Equal(t, []string{
+		"m1=new-image",
+		"m2=new-image",
+		"m1=old-image",
+		"m2=old-image",
+	}, updates)
+}
+
+func TestUpdateUsingProgressiveStrategyWithoutRollback(t *testing.T) {
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+
+	var updates []string
+	client := &mock.FlapsClient{
+		UpdateFunc: func(ctx context.Context, appName string, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			if input.ID == "m2" {
+				return nil, fmt.Errorf("could not update %s", input.ID)
+			}
+			updates = append(updates, input.ID+"="+input.Config.Image)
+
+			return &fly.Machine{ID: input.ID, State: fly.MachineStateStarted, LeaseNonce: nonce, Config: input.Config}, nil
+		},
+		GetProcessesFunc: func(ctx context.Context, appName, machineID string) (fly.MachinePsResponse, error) {
+			return nil, nil
+		},
+	}
+
+	md := &machineDeployment{
+		app:              &flaps.App{Name: "my-app"},
+		io:               ios,
+		colorize:         ios.ColorScheme(),
+		flapsClient:      client,
+		skipHealthChecks: true,
+		skipSmokeChecks:  true,
+		maxConcurrent:    1,
+		appConfig: &appconfig.Config{
+			Deploy: &appconfig.Deploy{
+				Strategy:    "progressive",
+				Progressive: &appconfig.Progressive{Steps: []int{50}, AutoRollback: new(false)},
+			},
+		},
+	}
+
+	entries := []*machineUpdateEntry{
+		testProgressiveEntry(client, ios, "m1", "app", "old-image"),
+		testProgressiveEntry(client, ios, "m2", "app", "old-image"),
+	}
+
+	err := md.updateUsingProgressiveStrategy(ctx, entries)
+	require.Error(t, err)
+	assert.Equal(t, []string{"m1=new-image"}, updates)
+}
+
+func TestUpdateExistingMachinesWRecoveryProgressiveRollsBack(t *testing.T) {
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+
+	var (
+		mu       sync.Mutex
+		updates  []string
+		machines []*fly.Machine
+	)
+	client := &mock.FlapsClient{
+		ListFunc: func(ctx context.Context, appName, state string) ([]*fly.Machine, error) {
+			return machines, nil
+		},
+		UpdateFunc: func(ctx context.Context, appName string, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if input.ID == "m3" && input.Config.Image == "new-image" {
+				return nil, fmt.Errorf("could not update %s", input.ID)
+			}
+			updates = append(updates, input.ID+"="+input.Config.Image)
+
+			return &fly.Machine{ID: input.ID, State: fly.MachineStateStarted, LeaseNonce: nonce, Config: input.Config}, nil
+		},
+		GetProcessesFunc: func(ctx context.Context, appName, machineID string) (fly.MachinePsResponse, error) {
+			return nil, nil
+		},
+		RefreshLeaseFunc: func(ctx context.Context, appName, machineID string, ttl *int, nonce string) (*fly.MachineLease, error) {
+			return &fly.MachineLease{Status: "success", Data: &fly.MachineLeaseData{Nonce: nonce}}, nil
+		},
+		ReleaseLeaseFunc: func(ctx context.Context, appName, machineID, nonce string) error {
+			return nil
+		},
+	}
+
+	var entries []*machineUpdateEntry
+	for _, id := range []string{"m1", "m2", "m3", "m4"} {
+		e := testProgressiveEntry(client, ios, id, "app", "old-image")
+		entries = append(entries, e)
+		machines = append(machines, e.leasableMachine.Machine())
+	}
+
+	md := &machineDeployment{
+		app:               &flaps.App{Name: "my-app"},
+		io:                ios,
+		colorize:          ios.ColorScheme(),
+		flapsClient:       client,
+		strategy:          "progressive",
+		skipHealthChecks:  true,
+		skipSmokeChecks:   true,
+		maxConcurrent:     1,
+		waitTimeout:       time.Second,
+		leaseTimeout:      time.Minute,
+		leaseDelayBetween: time.Minute,
+		machineSet:        machine.NewMachineSet(client, ios, "my-app", machines, false),
+		appConfig: &appconfig.Config{
+			Deploy: &appconfig.Deploy{
+				Strategy:    "progressive",
+				Progressive: &appconfig.Progressive{Steps: []int{25, 50}},
+			},
+		},
+	}
+
+	err := md.updateExistingMachinesWRecovery(ctx, entries)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rolled back")
+	assert.ErrorIs(t, err, errDeployRolledBack, "there's nothing left to resume")
+	assert.Equal(t, []string{
+		"m1=new-image",
+		"m2=new-image",
+		"m1=old-image",
+		"m2=old-image",
+	}, updates)
+}
@@ -0,0 +1,22 @@
+package dev
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/internal/command"
+)
+
+func New() *cobra.Command {
+	const (
+		short = "Tools for developing and testing flyctl"
+		long  = `Tools for developing flyctl and testing it without reaching Fly.io.`
+	)
+
+	cmd := command.New("dev", short, long, nil)
+	cmd.Hidden = true
+
+	cmd.AddCommand(
+		newFlapsServer(),
+	)
+
+	return cmd
+}
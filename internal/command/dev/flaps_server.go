@@ -0,0 +1,95 @@
+package dev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/inmem"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newFlapsServer() *cobra.Command {
+	const (
+		short = "Run a local stand-in for the Machines API"
+		long  = `Run a local HTTP server implementing the Machines API paths used by flyctl,
+backed by an in-memory state that's lost when the server stops.
+
+Point FLY_FLAPS_BASE_URL at the server to run flyctl commands against it. Apps must
+exist before their machines, volumes and secrets can be managed, use --app to create
+them when the server starts.`
+		usage = "flaps-server"
+	)
+
+	cmd := command.New(usage, short, long, runFlapsServer)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.String{
+			Name:        "bind",
+			Description: "Address to listen on",
+			Default:     "127.0.0.1:4280",
+		},
+		flag.StringSlice{
+			Name:        "app",
+			Shorthand:   "a",
+			Description: "Name of an app to create when the server starts, can be repeated",
+		},
+		flag.Org(),
+	)
+
+	return cmd
+}
+
+func runFlapsServer(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	org := flag.GetOrg(ctx)
+	if org == "" {
+		org = "personal"
+	}
+
+	server := inmem.NewServer()
+	for _, name := range flag.GetStringSlice(ctx, "app") {
+		server.CreateApp(&fly.App{
+			ID:           name,
+			Name:         name,
+			Status:       "deployed",
+			Organization: fly.Organization{Slug: org},
+		})
+	}
+
+	listener, err := net.Listen("tcp", flag.GetString(ctx, "bind"))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	srv := &http.Server{
+		Handler:           server.FlapsHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(io.Out, "Machines API stand-in listening on http://%s\n", listener.Addr())
+	fmt.Fprintf(io.Out, "Run flyctl with FLY_FLAPS_BASE_URL=http://%s to use it\n", listener.Addr())
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
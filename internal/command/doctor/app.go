@@ -0,0 +1,103 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/iostreams"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+)
+
+func newApp() (cmd *cobra.Command) {
+	const (
+		short = "Run diagnostic checks against an app"
+		usage = "app"
+	)
+
+	var long strings.Builder
+	long.WriteString(`Run diagnostic checks against an app: all of them, or those given with --check.
+With --json, the outcome of each check is reported as JSON, for use in CI. The
+command fails when a check of error severity fails.
+
+The checks are:
+`)
+	for _, c := range appChecks {
+		fmt.Fprintf(&long, "  %-16s %s (%s)\n", c.ID, c.Description, c.Severity)
+	}
+
+	cmd = command.New(usage, short, long.String(), runApp,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.StringSlice{
+			Name:        "check",
+			Description: "Comma-separated list of the checks to run, such as lease,volumes",
+		},
+	)
+
+	return
+}
+
+// appReport is the JSON output of fly doctor app.
+type appReport struct {
+	App    string        `json:"app"`
+	Checks []CheckResult `json:"checks"`
+}
+
+func runApp(ctx context.Context) error {
+	var (
+		io         = iostreams.FromContext(ctx)
+		jsonOutput = config.FromContext(ctx).JSONOutput
+	)
+
+	checks, err := selectChecks(flag.GetStringSlice(ctx, "check"))
+	if err != nil {
+		return err
+	}
+
+	ac, err := NewAppChecker(ctx, jsonOutput, io.ColorScheme())
+	if err != nil {
+		return err
+	}
+
+	results := ac.runChecks(checks)
+
+	if jsonOutput {
+		if err := render.JSON(io.Out, appReport{App: ac.app.Name, Checks: results}); err != nil {
+			return err
+		}
+	}
+
+	return failedChecksError(results)
+}
+
+// failedChecksError returns an error naming the failed checks of error severity, if any.
+func failedChecksError(results []CheckResult) error {
+	var failed []string
+	for _, r := range results {
+		if r.Status == StatusFailed && r.Severity == SeverityError {
+			failed = append(failed, r.ID)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return errors.New("failed checks: " + strings.Join(failed, ", "))
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -17,20 +18,30 @@ import (
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/build/imgsrc"
 	"github.com/superfly/flyctl/internal/command/apps"
+	"github.com/superfly/flyctl/internal/flapsutil"
 	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/flyctl/internal/state"
 	"github.com/superfly/flyctl/iostreams"
 )
 
 type AppChecker struct {
-	jsonOutput bool
-	checks     map[string]string
-	color      *iostreams.ColorScheme
-	ctx        context.Context
-	app        *fly.AppCompact
-	workDir    string
-	appConfig  *appconfig.Config
-	apiClient  flyutil.Client
+	jsonOutput  bool
+	checks      map[string]string
+	color       *iostreams.ColorScheme
+	ctx         context.Context
+	app         *fly.AppCompact
+	workDir     string
+	appConfig   *appconfig.Config
+	apiClient   flyutil.Client
+	flapsClient flapsutil.FlapsClient
+
+	// listed by the checks that need them, at most once
+	ipAddresses []fly.IPAddress
+	ipsErr      error
+	ipsListed   bool
+	machines    []*fly.Machine
+	machinesErr error
+	listed      bool
 }
 
 func NewAppChecker(ctx context.Context, jsonOutput bool, color *iostreams.ColorScheme) (*AppChecker, error) {
@@ -54,14 +65,15 @@ func NewAppChecker(ctx context.Context, jsonOutput bool, color *iostreams.ColorS
 	}
 
 	ac := &AppChecker{
-		jsonOutput: jsonOutput,
-		checks:     make(map[string]string),
-		color:      color,
-		ctx:        ctx,
-		apiClient:  apiClient,
-		workDir:    state.WorkingDirectory(ctx),
-		app:        nil,
-		appConfig:  nil,
+		jsonOutput:  jsonOutput,
+		checks:      make(map[string]string),
+		color:       color,
+		ctx:         ctx,
+		apiClient:   apiClient,
+		flapsClient: flapsutil.ClientFromContext(ctx),
+		workDir:     state.WorkingDirectory(ctx),
+		app:         nil,
+		appConfig:   nil,
 	}
 
 	ac.app = appCompact
@@ -89,49 +101,129 @@ func (ac *AppChecker) lprint(color func(string) string, fmtstr string, args ...a
 	}
 }
 
+// checkAll runs the app checks of fly doctor, and returns their outcomes keyed the way it
+// reports them.
 func (ac *AppChecker) checkAll() map[string]string {
+	ac.runChecks(slices.DeleteFunc(slices.Clone(appChecks), func(c *appCheck) bool {
+		return !slices.Contains(doctorChecks, c.ID)
+	}))
+
+	return ac.checks
+}
+
+// runChecks runs checks in order, printing their outcomes as they complete.
+func (ac *AppChecker) runChecks(checks []*appCheck) []CheckResult {
 	ac.lprint(nil, "\nApp specific checks for %s:\n", ac.app.Name)
 
-	ipAddresses := ac.checkIpsAllocated()
-	ac.checkDnsRecords(ipAddresses)
+	results := make([]CheckResult, 0, len(checks))
+	for _, check := range checks {
+		ac.lprint(nil, "%s... ", check.Description)
 
-	relPath, err := filepath.Rel(ac.workDir, ac.appConfig.ConfigFilePath())
-	if err == nil && relPath == appconfig.DefaultConfigFileName {
-		ac.lprint(nil, "\nBuild checks for %s:\n", ac.app.Name)
-		contextSize := ac.checkDockerContext()
-		// only show longer .dockerignore message when context size > 50MB
-		ac.checkDockerIgnore(contextSize > 50*1024*1024)
+		status, message := check.run(ac)
+		switch {
+		case check.ownKeys, status == StatusSkipped:
+		case status == StatusPassed:
+			ac.checks[check.ID] = "ok"
+		default:
+			ac.checks[check.ID] = message
+		}
+
+		result := CheckResult{
+			ID:          check.ID,
+			Description: check.Description,
+			Severity:    check.Severity,
+			Status:      status,
+			Message:     message,
+		}
+		if status == StatusFailed {
+			result.Remediation = check.Remediation
+		}
+		results = append(results, result)
+
+		ac.printResult(result)
 	}
 
-	return ac.checks
+	return results
 }
 
-func (ac *AppChecker) checkIpsAllocated() []fly.IPAddress {
-	ac.lprint(nil, "Checking that app has ip addresses allocated... ")
+func (ac *AppChecker) printResult(result CheckResult) {
+	switch result.Status {
+	case StatusPassed:
+		ac.lprint(ac.color.Green, "PASSED")
+		if result.Message != "" {
+			ac.lprint(nil, " (%s)", result.Message)
+		}
+		ac.lprint(nil, "\n")
+	case StatusSkipped:
+		ac.lprint(nil, "Skipped (%s)\n", result.Message)
+	case StatusFailed:
+		color := ac.color.Yellow
+		if result.Severity == SeverityError {
+			color = ac.color.Red
+		}
+		ac.lprint(color, "FAILED\n")
+		ac.lprint(nil, "%s\n", indent(result.Message))
+		if result.Remediation != "" {
+			ac.lprint(nil, "%s\n", indent(result.Remediation))
+		}
+		ac.lprint(nil, "\n")
+	}
+}
 
-	ipAddresses, err := ac.apiClient.GetIPAddresses(ac.ctx, ac.app.Name)
-	if err != nil {
-		ac.lprint(nil, "API error listing IP addresses for app %s: %v\n", ac.app.Name, err)
+func indent(s string) string {
+	return "\t" + strings.ReplaceAll(s, "\n", "\n\t")
+}
 
-		return nil
+func (ac *AppChecker) listIPAddresses() ([]fly.IPAddress, error) {
+	if !ac.ipsListed {
+		ac.ipAddresses, ac.ipsErr = ac.apiClient.GetIPAddresses(ac.ctx, ac.app.Name)
+		ac.ipsListed = true
 	}
 
-	if len(ipAddresses) > 0 {
-		ac.checks["appHasIps"] = "ok"
-		ac.lprint(ac.color.Green, "PASSED\n")
-	} else {
+	return ac.ipAddresses, ac.ipsErr
+}
+
+func (ac *AppChecker) listMachines() ([]*fly.Machine, error) {
+	if !ac.listed {
+		ac.machines, _, ac.machinesErr = ac.flapsClient.ListFlyAppsMachines(ac.ctx, ac.app.Name)
+		ac.listed = true
+	}
+
+	return ac.machines, ac.machinesErr
+}
+
+// inSourceDir tells whether flyctl runs from the directory of the app's source, where
+// build checks make sense.
+func (ac *AppChecker) inSourceDir() bool {
+	relPath, err := filepath.Rel(ac.workDir, ac.appConfig.ConfigFilePath())
+
+	return err == nil && relPath == appconfig.DefaultConfigFileName
+}
+
+func (ac *AppChecker) checkIpsAllocated() (Status, string) {
+	ipAddresses, err := ac.listIPAddresses()
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("API error listing IP addresses for app %s: %v", ac.app.Name, err)
+	}
+
+	if len(ipAddresses) == 0 {
 		ac.checks["appHasIps"] = "No ips"
-		ac.lprint(nil, `Nope
-	No ip addresses assigned to this app. If the app is not intended to receive traffic, this is fine.
-	Otherwise, it likely means that the services configuration is not correctly setup to receive http, tls, tcp, or udp traffic.
-	https://fly.io/docs/reference/configuration/#the-services-sections
-`)
+
+		return StatusFailed, `No ip addresses assigned to this app. If the app is not intended to receive traffic, this is fine.
+Otherwise, it likely means that the services configuration is not correctly setup to receive http, tls, tcp, or udp traffic.`
 	}
 
-	return ipAddresses
+	ac.checks["appHasIps"] = "ok"
+
+	return StatusPassed, ""
 }
 
-func (ac *AppChecker) checkDnsRecords(ipAddresses []fly.IPAddress) {
+func (ac *AppChecker) checkDnsRecords() (Status, string) {
+	ipAddresses, err := ac.listIPAddresses()
+	if err != nil {
+		return StatusSkipped, fmt.Sprintf("API error listing IP addresses for app %s: %v", ac.app.Name, err)
+	}
+
 	v4s := make(map[string]bool)
 	v6s := make(map[string]bool)
 	for _, ip := range ipAddresses {
@@ -143,13 +235,11 @@ func (ac *AppChecker) checkDnsRecords(ipAddresses []fly.IPAddress) {
 		case "private_v6":
 			// This is a valid type, but not of interest here.
 		default:
-			ac.lprint(nil, "Ip address %s has unexpected type '%s'. Please file a bug with this message at https://github.com/superfly/flyctl/issues/new?assignees=&labels=bug&template=flyctl-bug-report.md&title=\n", ip.Address, ip.Type)
+			return StatusFailed, fmt.Sprintf("Ip address %s has unexpected type '%s'. Please file a bug with this message at https://github.com/superfly/flyctl/issues/new?assignees=&labels=bug&template=flyctl-bug-report.md&title=", ip.Address, ip.Type)
 		}
 	}
 	if len(v4s) == 0 && len(v6s) == 0 {
-		ac.lprint(nil, "No public ipv4 or ipv6 ip addresses allocated to app %s\n", ac.app.Name)
-
-		return
+		return StatusSkipped, fmt.Sprintf("no public ipv4 or ipv6 ip addresses allocated to app %s", ac.app.Name)
 	}
 
 	appHostname := ac.app.Hostname
@@ -157,43 +247,40 @@ func (ac *AppChecker) checkDnsRecords(ipAddresses []fly.IPAddress) {
 	dnsClient := &dns.Client{}
 	ns, err := getFirstFlyDevNameserver(dnsClient)
 	if err != nil {
-		ac.lprint(nil, "%s. Can't proceed to check A or AAAA records.\n", err.Error())
-
-		return
+		return StatusFailed, fmt.Sprintf("%s. Can't proceed to check A or AAAA records.", err.Error())
 	}
 	nsAddr := net.JoinHostPort(strings.TrimSuffix(ns, "."), "53")
 
-	if len(v4s) > 0 {
-		ac.lprint(nil, "Checking A record for %s... ", appHostname)
-		err, jsonErr := checkDnsRecords(dnsClient, nsAddr, ac.app.Name, appFqdn, "A", v4s)
-		if err == nil {
-			ac.lprint(ac.color.Green, "PASSED\n")
-			ac.checks["appARecord"] = "ok"
-		} else {
-			ac.lprint(nil, "%s\n\n", err.Error())
-			if jsonErr != "" {
-				ac.checks["appARecord"] = jsonErr
-			} else {
-				ac.checks["appARecord"] = err.Error()
-			}
+	var failures []string
+	for _, record := range []struct {
+		qType, key string
+		ips        map[string]bool
+	}{
+		{"A", "appARecord", v4s},
+		{"AAAA", "appAAAARecord", v6s},
+	} {
+		if len(record.ips) == 0 {
+			continue
 		}
-	}
 
-	if len(v6s) > 0 {
-		ac.lprint(nil, "Checking AAAA record for %s... ", appHostname)
-		err, jsonErr := checkDnsRecords(dnsClient, nsAddr, ac.app.Name, appFqdn, "AAAA", v6s)
-		if err == nil {
-			ac.lprint(ac.color.Green, "PASSED\n")
-			ac.checks["appAAAARecord"] = "ok"
-		} else {
-			ac.lprint(nil, "%s\n\n", err.Error())
-			if jsonErr != "" {
-				ac.checks["appAAAARecord"] = jsonErr
-			} else {
-				ac.checks["appAAAARecord"] = err.Error()
-			}
+		err, jsonErr := checkDnsRecords(dnsClient, nsAddr, ac.app.Name, appFqdn, record.qType, record.ips)
+		switch {
+		case err == nil:
+			ac.checks[record.key] = "ok"
+		case jsonErr != "":
+			ac.checks[record.key] = jsonErr
+			failures = append(failures, err.Error())
+		default:
+			ac.checks[record.key] = err.Error()
+			failures = append(failures, err.Error())
 		}
 	}
+
+	if len(failures) > 0 {
+		return StatusFailed, strings.Join(failures, "\n")
+	}
+
+	return StatusPassed, appHostname
 }
 
 func getFirstFlyDevNameserver(dnsClient *dns.Client) (string, error) {
@@ -263,24 +350,25 @@ func checkDnsRecords(dnsClient *dns.Client, nsAddr string, appName string, appFq
 	} else if len(ipsOnAppNotInDns) > 0 {
 		missingIps := strings.Join(ipsOnAppNotInDns, ", ")
 
-		return fmt.Errorf(`Nope
-	These IPs are missing from the %s %s record: %s
-	This likely means we had an operational issue when we tried to create the record.
-	Post in https://community.fly.io/ or send us an email if you have a support plan, and we'll get this fixed`,
+		return fmt.Errorf(`These IPs are missing from the %s %s record: %s
+This likely means we had an operational issue when we tried to create the record.
+Post in https://community.fly.io/ or send us an email if you have a support plan, and we'll get this fixed`,
 			appFqdn, qType, missingIps), fmt.Sprintf("missing these ips from the %s record: %s", qType, missingIps)
 	} else { // len(ipsInDnsNotInApp) > 0
 		missingIps := strings.Join(ipsInDnsNotInApp, ", ")
 
-		return fmt.Errorf(`Nope
-	These IPs are set in the %s record for %s, but they are not associated with the %s app: %s
-	This likely means we had an operational issue when we tried to create the record.
-	Post in https://community.fly.io/ or send us an email if you have a support plan, and we'll get this fixed`,
+		return fmt.Errorf(`These IPs are set in the %s record for %s, but they are not associated with the %s app: %s
+This likely means we had an operational issue when we tried to create the record.
+Post in https://community.fly.io/ or send us an email if you have a support plan, and we'll get this fixed`,
 			qType, appFqdn, appName, missingIps), fmt.Sprintf("extra ips on %s record not associated with app: %s", qType, missingIps)
 	}
 }
 
-func (ac *AppChecker) checkDockerContext() int {
-	ac.lprint(nil, "Checking docker context size (this may take little bit)... ")
+func (ac *AppChecker) checkDockerContext() (Status, string) {
+	if !ac.inSourceDir() {
+		return StatusSkipped, "not run from the app's source directory"
+	}
+
 	checkKey := "appDockerContextSizeBytes"
 	var dockerfile string
 	var err error
@@ -290,9 +378,7 @@ func (ac *AppChecker) checkDockerContext() int {
 	if dockerfile != "" {
 		dockerfile, err = filepath.Abs(dockerfile)
 		if err != nil || !helpers.FileExists(dockerfile) {
-			ac.lprint(nil, "Nope, Dockerfile '%s' not found\n", dockerfile)
-
-			return -1
+			return StatusFailed, fmt.Sprintf("Dockerfile '%s' not found", dockerfile)
 		}
 	} else {
 		dockerfile = filepath.Join(ac.workDir, "Dockerfile")
@@ -300,45 +386,33 @@ func (ac *AppChecker) checkDockerContext() int {
 			dockerfile = filepath.Join(ac.workDir, "dockerfile")
 		}
 	}
-	if dockerfile == "" {
-		ac.lprint(nil, "Nope, Dockerfile not found")
-
-		return -1
-	}
 	archiveInfo, err := imgsrc.CreateArchive(dockerfile, ac.workDir, ac.appConfig.Ignorefile(), true)
 	if err != nil {
-		ac.lprint(nil, "Nope, failed to create archive\n\t%s", err.Error())
-
-		return -1
+		return StatusFailed, fmt.Sprintf("failed to create archive: %s", err.Error())
 	}
 
 	archiveSize := archiveInfo.SizeInBytes
-	ac.lprint(ac.color.Green, "PASSED")
-	ac.lprint(nil, " (%s)\n", humanize.Bytes(uint64(archiveSize)))
 	ac.checks[checkKey] = strconv.Itoa(archiveSize)
 
-	return archiveSize
+	return StatusPassed, humanize.Bytes(uint64(archiveSize))
 }
 
-func (ac *AppChecker) checkDockerIgnore(printDetailedMsg bool) {
+func (ac *AppChecker) checkDockerIgnore() (Status, string) {
+	if !ac.inSourceDir() {
+		return StatusSkipped, "not run from the app's source directory"
+	}
 	if ac.appConfig.Build != nil && ac.appConfig.Build.Image != "" {
-		return
+		return StatusSkipped, "the app deploys a prebuilt image"
 	}
-	ac.lprint(nil, "Checking for .dockerignore... ")
+
 	checkKey := "appDockerIgnore"
 	fullPath := filepath.Join(ac.workDir, ".dockerignore")
 	if _, err := os.Stat(fullPath); errors.Is(err, os.ErrNotExist) {
 		ac.checks[checkKey] = "no .dockerignore file found"
-		ac.lprint(nil, "Nope\n")
-		if printDetailedMsg {
-			ac.lprint(nil, `			Found no .dockerignore to limit docker context size. Large docker contexts can slow down builds.
-			Create a .dockerignore file to indicate which files and directories may be ignored when building the docker image for this app.
-			More info at: https://docs.docker.com/engine/reference/builder/#dockerignore-file`)
-			ac.lprint(nil, "\n")
-		}
 
-		return
+		return StatusFailed, "Found no .dockerignore to limit docker context size. Large docker contexts can slow down builds."
 	}
-	ac.lprint(ac.color.Green, "PASSED\n")
 	ac.checks[checkKey] = "ok"
+
+	return StatusPassed, ""
 }
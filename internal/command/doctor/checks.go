@@ -0,0 +1,463 @@
+package doctor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	fly "github.com/superfly/fly-go"
+
+	"github.com/superfly/flyctl/internal/command/secrets"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/uiexutil"
+)
+
+// Severity is how much a failed check matters.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// CheckResult is the outcome of an app check, as fly doctor app reports it.
+type CheckResult struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Severity    Severity `json:"severity"`
+	Status      Status   `json:"status"`
+	Message     string   `json:"message,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+}
+
+// appCheck is a diagnostic of an app.
+type appCheck struct {
+	ID          string
+	Description string
+	Severity    Severity
+	// Remediation tells how to fix what the check found.
+	Remediation string
+
+	// run returns the status of the check, with a message detailing it.
+	run func(*AppChecker) (Status, string)
+	// ownKeys is set for the checks that report themselves under the keys fly doctor
+	// has always used. The outcomes of the other checks are reported under their ID.
+	ownKeys bool
+}
+
+// stuckReplacingAfter is how long a machine may be replacing before it's considered stuck.
+const stuckReplacingAfter = 10 * time.Minute
+
+// doctorChecks are the app checks plain fly doctor runs. The others only run with fly
+// doctor app.
+var doctorChecks = []string{"ips", "dns", "docker-context", "dockerignore"}
+
+// appChecks are the checks fly doctor app runs against apps, in order.
+var appChecks = []*appCheck{
+	{
+		ID:          "ips",
+		Description: "IP addresses are allocated",
+		Severity:    SeverityWarning,
+		Remediation: "See https://fly.io/docs/reference/configuration/#the-services-sections",
+		run:         (*AppChecker).checkIpsAllocated,
+		ownKeys:     true,
+	},
+	{
+		ID:          "dns",
+		Description: "DNS records match the IP addresses",
+		Severity:    SeverityError,
+		Remediation: "Post in https://community.fly.io/ or send us an email if you have a support plan, and we'll get this fixed.",
+		run:         (*AppChecker).checkDnsRecords,
+		ownKeys:     true,
+	},
+	{
+		ID:          "listen-address",
+		Description: "Processes listen where fly-proxy can reach them",
+		Severity:    SeverityError,
+		Remediation: "Configure the app to listen on 0.0.0.0 or [::] instead of localhost.",
+		run:         (*AppChecker).checkListenAddresses,
+	},
+	{
+		ID:          "health-checks",
+		Description: "Health checks of started machines pass",
+		Severity:    SeverityError,
+		Remediation: "Check that the health checks match the ports and paths the app serves, and run 'fly logs' to see why they fail.",
+		run:         (*AppChecker).checkHealthChecks,
+	},
+	{
+		ID:          "replacing",
+		Description: "No machine is stuck replacing",
+		Severity:    SeverityWarning,
+		Remediation: "Run 'fly machine restart <id>' on the stuck machines, or destroy them with 'fly machine destroy --force <id>' and deploy again.",
+		run:         (*AppChecker).checkReplacing,
+	},
+	{
+		ID:          "lease",
+		Description: "No lease is held on machines",
+		Severity:    SeverityWarning,
+		Remediation: "Unless a deployment or another update is running, release them with 'fly machine leases clear <id>'.",
+		run:         (*AppChecker).checkLeases,
+	},
+	{
+		ID:          "volumes",
+		Description: "Volumes are attached to machines",
+		Severity:    SeverityWarning,
+		Remediation: "Mount them through the [mounts] section of fly.toml, or destroy those no longer needed with 'fly volumes destroy <id>'.",
+		run:         (*AppChecker).checkVolumes,
+	},
+	{
+		ID:          "secrets",
+		Description: "Secrets are deployed to every machine",
+		Severity:    SeverityWarning,
+		Remediation: "Run 'fly secrets deploy' to deploy them to every machine.",
+		run:         (*AppChecker).checkSecrets,
+	},
+	{
+		ID:          "image-platform",
+		Description: "Images are built for " + machinePlatform,
+		Severity:    SeverityError,
+		Remediation: "Build the image for linux/amd64, such as with 'docker build --platform linux/amd64', and deploy it again.",
+		run:         (*AppChecker).checkImagePlatform,
+	},
+	{
+		ID:          "docker-context",
+		Description: "Docker context size (this may take little bit)",
+		Severity:    SeverityInfo,
+		run:         (*AppChecker).checkDockerContext,
+		ownKeys:     true,
+	},
+	{
+		ID:          "dockerignore",
+		Description: ".dockerignore limits the docker context",
+		Severity:    SeverityWarning,
+		Remediation: `Create a .dockerignore file to indicate which files and directories may be ignored when building the docker image for this app.
+More info at: https://docs.docker.com/engine/reference/builder/#dockerignore-file`,
+		run:     (*AppChecker).checkDockerIgnore,
+		ownKeys: true,
+	},
+}
+
+// selectChecks returns the app checks of the given IDs, in the order they run, or every
+// check if there are none.
+func selectChecks(ids []string) ([]*appCheck, error) {
+	if len(ids) == 0 {
+		return appChecks, nil
+	}
+
+	for _, id := range ids {
+		if !slices.ContainsFunc(appChecks, func(c *appCheck) bool { return c.ID == id }) {
+			return nil, fmt.Errorf("unknown check %q, the checks are: %s", id, strings.Join(checkIDs(), ", "))
+		}
+	}
+
+	return slices.DeleteFunc(slices.Clone(appChecks), func(c *appCheck) bool {
+		return !slices.Contains(ids, c.ID)
+	}), nil
+}
+
+func checkIDs() []string {
+	ids := make([]string, 0, len(appChecks))
+	for _, c := range appChecks {
+		ids = append(ids, c.ID)
+	}
+
+	return ids
+}
+
+func (ac *AppChecker) checkListenAddresses() (Status, string) {
+	machines, err := ac.listMachines()
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("failed listing machines: %v", err)
+	}
+
+	var (
+		checked  = map[string]bool{}
+		failures []string
+	)
+	for _, m := range machines {
+		group := m.ProcessGroup()
+		if m.State != fly.MachineStateStarted || checked[group] {
+			continue
+		}
+
+		groupConfig, err := ac.appConfig.Flatten(group)
+		if err != nil {
+			continue
+		}
+
+		processes, err := ac.flapsClient.GetProcesses(ac.ctx, ac.app.Name, m.ID)
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("failed listing the processes of machine %s: %v", m.ID, err)
+		}
+		checked[group] = true
+
+		ports, known := groupConfig.UnreachableTCPPorts(processes)
+		if !known {
+			continue
+		}
+		for _, port := range ports {
+			failures = append(failures, fmt.Sprintf("%s processes don't listen on 0.0.0.0:%d", group, port))
+		}
+	}
+
+	switch {
+	case len(checked) == 0:
+		return StatusSkipped, "no started machines"
+	case len(failures) > 0:
+		return StatusFailed, strings.Join(failures, "\n")
+	default:
+		return StatusPassed, ""
+	}
+}
+
+func (ac *AppChecker) checkHealthChecks() (Status, string) {
+	machines, err := ac.listMachines()
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("failed listing machines: %v", err)
+	}
+
+	var (
+		checked  bool
+		failures []string
+	)
+	for _, m := range machines {
+		if m.State != fly.MachineStateStarted {
+			continue
+		}
+
+		for _, check := range m.Checks {
+			checked = true
+			if check.Status != fly.Passing {
+				failures = append(failures, fmt.Sprintf("machine %s check %s is %s", m.ID, check.Name, check.Status))
+			}
+		}
+	}
+
+	switch {
+	case !checked:
+		return StatusSkipped, "no health checks on started machines"
+	case len(failures) > 0:
+		return StatusFailed, strings.Join(failures, "\n")
+	default:
+		return StatusPassed, ""
+	}
+}
+
+func (ac *AppChecker) checkReplacing() (Status, string) {
+	machines, err := ac.listMachines()
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("failed listing machines: %v", err)
+	}
+
+	var stuck []string
+	for _, m := range machines {
+		if m.State != "replacing" {
+			continue
+		}
+
+		updatedAt, err := time.Parse(time.RFC3339, m.UpdatedAt)
+		if err != nil || time.Since(updatedAt) < stuckReplacingAfter {
+			continue
+		}
+		stuck = append(stuck, fmt.Sprintf("machine %s has been replacing since %s", m.ID, updatedAt.Format(time.RFC3339)))
+	}
+
+	if len(stuck) > 0 {
+		return StatusFailed, strings.Join(stuck, "\n")
+	}
+
+	return StatusPassed, ""
+}
+
+func (ac *AppChecker) checkLeases() (Status, string) {
+	machines, err := ac.listMachines()
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("failed listing machines: %v", err)
+	}
+
+	var held []string
+	for _, m := range machines {
+		lease, err := ac.flapsClient.FindLease(ac.ctx, ac.app.Name, m.ID)
+		if err != nil {
+			if strings.Contains(err.Error(), " lease not found") {
+				continue
+			}
+
+			return StatusFailed, fmt.Sprintf("failed looking up the lease of machine %s: %v", m.ID, err)
+		}
+		if lease == nil || lease.Data == nil {
+			continue
+		}
+
+		expires := time.Unix(lease.Data.ExpiresAt, 0)
+		if time.Now().After(expires) {
+			continue
+		}
+		held = append(held, fmt.Sprintf("machine %s is leased by %s until %s", m.ID, lease.Data.Owner, expires.Format(time.RFC3339)))
+	}
+
+	if len(held) > 0 {
+		return StatusFailed, strings.Join(held, "\n")
+	}
+
+	return StatusPassed, ""
+}
+
+func (ac *AppChecker) checkVolumes() (Status, string) {
+	volumes, err := ac.flapsClient.GetVolumes(ac.ctx, ac.app.Name)
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("failed listing volumes: %v", err)
+	}
+
+	var unattached []string
+	for _, v := range volumes {
+		switch v.State {
+		case "destroying", "destroyed", "pending_destroy":
+			continue
+		}
+
+		if !v.IsAttached() {
+			unattached = append(unattached, fmt.Sprintf("volume %s (%s) in %s isn't attached to a machine", v.ID, v.Name, v.Region))
+		}
+	}
+
+	if len(unattached) > 0 {
+		return StatusFailed, strings.Join(unattached, "\n")
+	}
+
+	return StatusPassed, ""
+}
+
+func (ac *AppChecker) checkSecrets() (Status, string) {
+	list, ok, err := secrets.ListWithStatus(ac.ctx, ac.flapsClient, uiexutil.ClientFromContext(ac.ctx), ac.app.Name)
+	switch {
+	case err != nil:
+		return StatusFailed, fmt.Sprintf("failed listing secrets: %v", err)
+	case !ok:
+		return StatusSkipped, "the deployment status of secrets is unavailable"
+	}
+
+	var undeployed []string
+	for _, secret := range list {
+		switch secret.Status {
+		case secrets.StatusStaged:
+			undeployed = append(undeployed, fmt.Sprintf("secret %s is staged but not deployed", secret.Name))
+		case secrets.StatusPartiallyDeployed:
+			undeployed = append(undeployed, fmt.Sprintf("secret %s is deployed to some machines only", secret.Name))
+		}
+	}
+
+	if len(undeployed) > 0 {
+		return StatusFailed, strings.Join(undeployed, "\n")
+	}
+
+	return StatusPassed, ""
+}
+
+// machinePlatform is the platform machines run images of.
+const machinePlatform = "linux/amd64"
+
+func (ac *AppChecker) checkImagePlatform() (Status, string) {
+	machines, err := ac.listMachines()
+	if err != nil {
+		return StatusFailed, fmt.Sprintf("failed listing machines: %v", err)
+	}
+
+	var (
+		checked    = map[string]bool{}
+		mismatches []string
+	)
+	for _, m := range machines {
+		image := m.FullImageRef()
+		if m.ImageRef.Repository == "" || checked[image] {
+			continue
+		}
+		checked[image] = true
+
+		platforms, err := ac.imagePlatforms(image)
+		if err != nil {
+			return StatusFailed, fmt.Sprintf("failed inspecting image %s: %v", image, err)
+		}
+		if !slices.Contains(platforms, machinePlatform) {
+			mismatches = append(mismatches, fmt.Sprintf("image %s is built for %s, not %s", image, strings.Join(platforms, ", "), machinePlatform))
+		}
+	}
+
+	switch {
+	case len(checked) == 0:
+		return StatusSkipped, "no deployed images"
+	case len(mismatches) > 0:
+		return StatusFailed, strings.Join(mismatches, "\n")
+	default:
+		return StatusPassed, ""
+	}
+}
+
+// registryOptions authenticates to the Fly registry with the user's token and to other
+// registries with the local Docker credentials. They can't be combined.
+func (ac *AppChecker) registryOptions(ref name.Reference) []remote.Option {
+	cfg := config.FromContext(ac.ctx)
+	if ref.Context().RegistryStr() == cfg.RegistryHost {
+		return []remote.Option{remote.WithContext(ac.ctx), remote.WithAuth(&authn.Basic{Username: "x", Password: cfg.Tokens.Docker()})}
+	}
+
+	return []remote.Option{remote.WithContext(ac.ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain)}
+}
+
+// imagePlatforms returns the platforms image is available for.
+func (ac *AppChecker) imagePlatforms(image string) ([]string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := remote.Get(ref, ac.registryOptions(ref)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if desc.MediaType.IsIndex() {
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+
+		var platforms []string
+		for _, m := range manifest.Manifests {
+			if m.Platform != nil {
+				platforms = append(platforms, m.Platform.OS+"/"+m.Platform.Architecture)
+			}
+		}
+
+		return platforms, nil
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return nil, err
+	}
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{configFile.OS + "/" + configFile.Architecture}, nil
+}
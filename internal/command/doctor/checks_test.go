@@ -0,0 +1,171 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/tokens"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/mock"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newTestChecker(t *testing.T, flapsClient *mock.FlapsClient) *AppChecker {
+	t.Helper()
+
+	ios, _, _, _ := iostreams.Test()
+
+	return &AppChecker{
+		jsonOutput:  true,
+		checks:      map[string]string{},
+		color:       ios.ColorScheme(),
+		ctx:         context.Background(),
+		app:         &fly.AppCompact{Name: "my-app"},
+		workDir:     t.TempDir(),
+		appConfig:   appconfig.NewConfig(),
+		flapsClient: flapsClient,
+	}
+}
+
+func TestSelectChecks(t *testing.T) {
+	checks, err := selectChecks(nil)
+	require.NoError(t, err)
+	assert.Equal(t, appChecks, checks)
+
+	checks, err = selectChecks([]string{"volumes", "lease"})
+	require.NoError(t, err)
+	require.Len(t, checks, 2)
+	// in the order they run, not the order they were given in
+	assert.Equal(t, "lease", checks[0].ID)
+	assert.Equal(t, "volumes", checks[1].ID)
+
+	_, err = selectChecks([]string{"lease", "nope"})
+	assert.ErrorContains(t, err, `unknown check "nope"`)
+
+	// plain fly doctor keeps to the checks it always ran
+	checks, err = selectChecks(doctorChecks)
+	require.NoError(t, err)
+	require.Len(t, checks, len(doctorChecks))
+	for _, check := range checks {
+		assert.True(t, check.ownKeys, check.ID)
+	}
+}
+
+func TestRunChecks(t *testing.T) {
+	ac := newTestChecker(t, &mock.FlapsClient{
+		GetVolumesFunc: func(ctx context.Context, appName string) ([]fly.Volume, error) {
+			return []fly.Volume{
+				{ID: "vol_attached", State: "created", AttachedMachine: new("m1")},
+				{ID: "vol_unattached", Name: "data", Region: "ams", State: "created"},
+				{ID: "vol_destroyed", State: "destroyed"},
+			}, nil
+		},
+		ListFlyAppsMachinesFunc: func(ctx context.Context, appName string) ([]*fly.Machine, *fly.Machine, error) {
+			return []*fly.Machine{
+				{ID: "m1", State: "started"},
+				{ID: "m2", State: "replacing", UpdatedAt: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+				{ID: "m3", State: "replacing", UpdatedAt: time.Now().Format(time.RFC3339)},
+			}, nil, nil
+		},
+		FindLeaseFunc: func(ctx context.Context, appName, machineID string) (*fly.MachineLease, error) {
+			if machineID == "m1" {
+				return &fly.MachineLease{Data: &fly.MachineLeaseData{Owner: "someone@example.com", ExpiresAt: time.Now().Add(time.Hour).Unix()}}, nil
+			}
+
+			return nil, errors.New("failed to get lease on VM: lease not found")
+		},
+	})
+
+	checks, err := selectChecks([]string{"volumes", "lease", "replacing", "health-checks"})
+	require.NoError(t, err)
+
+	results := ac.runChecks(checks)
+	require.Len(t, results, 4)
+
+	byID := map[string]CheckResult{}
+	for _, r := range results {
+		byID[r.ID] = r
+	}
+
+	assert.Equal(t, StatusSkipped, byID["health-checks"].Status)
+
+	assert.Equal(t, StatusFailed, byID["replacing"].Status)
+	assert.Contains(t, byID["replacing"].Message, "m2")
+	assert.NotContains(t, byID["replacing"].Message, "m3")
+
+	assert.Equal(t, StatusFailed, byID["lease"].Status)
+	assert.Contains(t, byID["lease"].Message, "someone@example.com")
+	assert.NotEmpty(t, byID["lease"].Remediation)
+
+	assert.Equal(t, StatusFailed, byID["volumes"].Status)
+	assert.Equal(t, "volume vol_unattached (data) in ams isn't attached to a machine", byID["volumes"].Message)
+
+	// new checks are reported under their ID in the fly doctor JSON output
+	assert.Equal(t, byID["volumes"].Message, ac.checks["volumes"])
+	assert.NotContains(t, ac.checks, "health-checks")
+
+	// only failed checks of error severity fail the command
+	assert.NoError(t, failedChecksError(results))
+	assert.ErrorContains(t, failedChecksError([]CheckResult{
+		{ID: "dns", Severity: SeverityError, Status: StatusFailed},
+	}), "failed checks: dns")
+}
+
+func TestImagePlatforms(t *testing.T) {
+	// a registry only letting in the user's Fly token, like the Fly registry
+	reg := registry.New()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "x" || password != "fo1_token" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	image := u.Host + "/my-app:deployment-1"
+	ref, err := name.ParseReference(image)
+	require.NoError(t, err)
+
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	cfg, err := img.ConfigFile()
+	require.NoError(t, err)
+	cfg.OS, cfg.Architecture = "linux", "amd64"
+	img, err = mutate.ConfigFile(img, cfg)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img, remote.WithAuth(&authn.Basic{Username: "x", Password: "fo1_token"})))
+
+	ac := newTestChecker(t, &mock.FlapsClient{})
+	ac.ctx = config.NewContext(context.Background(), &config.Config{RegistryHost: u.Host, Tokens: tokens.Parse("fo1_token")})
+
+	platforms, err := ac.imagePlatforms(image)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"linux/amd64"}, platforms)
+
+	// other registries get the local Docker credentials, not the Fly token
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	ac.ctx = config.NewContext(context.Background(), &config.Config{RegistryHost: "registry.fly.io", Tokens: tokens.Parse("fo1_token")})
+	_, err = ac.imagePlatforms(image)
+	assert.ErrorContains(t, err, "401 Unauthorized")
+}
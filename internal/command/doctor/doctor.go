@@ -55,7 +55,10 @@ func New() (cmd *cobra.Command) {
 		},
 	)
 
-	cmd.AddCommand(diag.New())
+	cmd.AddCommand(
+		diag.New(),
+		newApp(),
+	)
 
 	return
 }
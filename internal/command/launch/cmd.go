@@ -37,7 +37,20 @@ import (
 
 func New() (cmd *cobra.Command) {
 	const (
-		long  = `Create and configure a new app from source code or a Docker image.  Options passed after double dashes ("--") will be passed to the language specific scanner/dockerfile generator.`
+		long = `Create and configure a new app from source code or a Docker image.  Options passed after double dashes ("--") will be passed to the language specific scanner/dockerfile generator.
+
+Scanner plugins, executables named fly-scanner-* on the PATH or in the
+scanner_plugins_dir of the flyctl config file, are consulted before the built-in
+scanners. A plugin is run in the source directory, given as its argument, and
+prints nothing if it doesn't recognize the source, or else a JSON object such as:
+
+  {
+    "family": "Acme", "port": 8080,
+    "env": {"NAME": "value"}, "processes": {"app": "acme serve"},
+    "files": [{"path": "Dockerfile", "contents": "..."}],
+    "secrets": [{"key": "NAME", "help": "...", "generate": true}],
+    "database": "postgres", "redis": true
+  }`
 		short = `Create and configure a new app from source code or a Docker image`
 	)
 
@@ -68,6 +81,10 @@ func New() (cmd *cobra.Command) {
 			Name:        "name",
 			Description: `Name of the new app`,
 		},
+		flag.String{
+			Name:        "package",
+			Description: `Workspace package to launch, by name or path, when the source root is a pnpm, npm, yarn, Cargo or Go workspace`,
+		},
 		flag.Bool{
 			Name:        "copy-config",
 			Description: "Use the configuration file if present without prompting",
@@ -143,13 +143,13 @@ func buildManifest(ctx context.Context, parentConfig *appconfig.Config, recovera
 	if absDir, err := filepath.Abs(workingDir); err == nil {
 		workingDir = absDir
 	}
-	configPath := filepath.Join(workingDir, appconfig.DefaultConfigFileName)
 
 	var srcInfo *scanner.SourceInfo
 	srcInfo, appConfig.Build, err = determineSourceInfo(ctx, appConfig, copiedConfig, workingDir)
 	if err != nil {
 		return nil, nil, err
 	}
+	configPath := launchConfigPath(workingDir, srcInfo)
 
 	appName, appNameExplanation, err := determineAppName(ctx, parentConfig, appConfig, configPath)
 	if err != nil {
@@ -417,7 +417,6 @@ func stateFromManifest(ctx context.Context, m LaunchManifest, optionalCache *pla
 	if absDir, err := filepath.Abs(workingDir); err == nil {
 		workingDir = absDir
 	}
-	configPath := filepath.Join(workingDir, appconfig.DefaultConfigFileName)
 
 	planStep := plan.GetPlanStep(ctx)
 	if planStep == "" || planStep == "create" {
@@ -448,7 +447,7 @@ func stateFromManifest(ctx context.Context, m LaunchManifest, optionalCache *pla
 
 	return &launchState{
 		workingDir: workingDir,
-		configPath: configPath,
+		configPath: launchConfigPath(workingDir, srcInfo),
 		LaunchManifest: LaunchManifest{
 			m.Plan,
 			m.PlanSource,
@@ -546,6 +545,16 @@ func validateAppName(appName string) error {
 	return nil
 }
 
+// launchConfigPath returns the path of the config of the app launched from workingDir:
+// that of the workspace package scanned if any, or else that of workingDir.
+func launchConfigPath(workingDir string, srcInfo *scanner.SourceInfo) string {
+	if srcInfo != nil && srcInfo.PackagePath != "" {
+		return filepath.Join(workingDir, filepath.FromSlash(srcInfo.PackagePath), appconfig.DefaultConfigFileName)
+	}
+
+	return filepath.Join(workingDir, appconfig.DefaultConfigFileName)
+}
+
 // determineAppName determines the app name from the config file or directory name
 func determineAppName(ctx context.Context, parentConfig *appconfig.Config, appConfig *appconfig.Config, configPath string) (string, string, error) {
 	delimiter := "-"
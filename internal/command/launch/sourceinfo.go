@@ -4,14 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/cavaliergopher/grab/v3"
 	"github.com/logrusorgru/aurora"
+	"github.com/samber/lo"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command/launch/plan"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/iostreams"
 	"github.com/superfly/flyctl/scanner"
 )
@@ -26,6 +33,7 @@ func determineSourceInfo(ctx context.Context, appConfig *appconfig.Config, copyC
 		ExistingPort: appConfig.InternalPort(),
 		Mode:         "launch",
 		Colorize:     io.ColorScheme(),
+		PluginsDir:   config.FromContext(ctx).ScannerPluginsDir,
 	}
 	// Detect if --copy-config and --now flags are set. If so, limited set of
 	// fly.toml file updates. Helpful for deploying PRs when the project is
@@ -73,7 +81,18 @@ func determineSourceInfo(ctx context.Context, appConfig *appconfig.Config, copyC
 		fmt.Fprintln(io.Out, "Scanning source code")
 	}
 
-	srcInfo, err = scanner.Scan(workingDir, scannerConfig)
+	monorepo, pkg, err := determineMonorepoPackage(ctx, workingDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pkg != nil {
+		if planStep == "" || planStep == "generate" {
+			fmt.Fprintf(io.Out, "Launching %s from the %s workspace in %s\n", aurora.Green(pkg.Name), monorepo.Tool, workingDir)
+		}
+		srcInfo, err = scanner.ScanMonorepoPackage(monorepo, pkg, scannerConfig)
+	} else {
+		srcInfo, err = scanner.Scan(workingDir, scannerConfig)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -118,11 +137,59 @@ func determineSourceInfo(ctx context.Context, appConfig *appconfig.Config, copyC
 			Builder:    srcInfo.Builder,
 			Buildpacks: srcInfo.Buildpacks,
 		}
+	} else if srcInfo.PackagePath != "" {
+		// fly.toml goes to the package, next to its Dockerfile, while the workspace
+		// root remains the build context
+		build.Dockerfile = "Dockerfile"
+		if helpers.FileExists(filepath.Join(workingDir, filepath.FromSlash(srcInfo.PackagePath), ".dockerignore")) ||
+			slices.ContainsFunc(srcInfo.Files, func(f scanner.SourceFile) bool { return f.Path == path.Join(srcInfo.PackagePath, ".dockerignore") }) {
+			build.Ignorefile = ".dockerignore"
+		}
 	}
 
 	return srcInfo, build, nil
 }
 
+// determineMonorepoPackage returns the workspace of workingDir and the package of it
+// to launch, chosen with --package or prompted for, if workingDir is the root of a
+// workspace. Workspaces with a Dockerfile at their root are launched as a whole unless
+// --package is given.
+func determineMonorepoPackage(ctx context.Context, workingDir string) (*scanner.Monorepo, *scanner.MonorepoPackage, error) {
+	name := flag.GetString(ctx, "package")
+	if name == "" && helpers.FileExists(filepath.Join(workingDir, "Dockerfile")) {
+		return nil, nil, nil
+	}
+
+	monorepo, err := scanner.DetectMonorepo(workingDir)
+	switch {
+	case err != nil:
+		return nil, nil, err
+	case monorepo == nil && name != "":
+		return nil, nil, fmt.Errorf("--package was given, but %s isn't the root of a pnpm, npm, yarn, Cargo or Go workspace", workingDir)
+	case monorepo == nil:
+		return nil, nil, nil
+	case name != "":
+		pkg, err := monorepo.Package(name)
+
+		return monorepo, pkg, err
+	}
+
+	var index int
+	msg := fmt.Sprintf("This is a %s workspace, which package do you want to launch?", monorepo.Tool)
+	options := lo.Map(monorepo.Packages, func(pkg scanner.MonorepoPackage, _ int) string {
+		return fmt.Sprintf("%s (%s)", pkg.Name, pkg.Path)
+	})
+	if err := prompt.Select(ctx, &index, msg, "", options...); err != nil {
+		if prompt.IsNonInteractive(err) {
+			return nil, nil, fmt.Errorf("%s is a %s workspace, choose the package to launch with --package: %s", workingDir, monorepo.Tool, strings.Join(monorepo.PackageNames(), ", "))
+		}
+
+		return nil, nil, err
+	}
+
+	return monorepo, &monorepo.Packages[index], nil
+}
+
 func articleFor(w string) string {
 	var article = "a"
 	if matched, _ := regexp.MatchString(`^[aeiou]`, strings.ToLower(w)); matched {
@@ -0,0 +1,67 @@
+package launch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/flag/flagctx"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/scanner"
+)
+
+func newDetermineMonorepoPackageCtx(t *testing.T, pkg string) context.Context {
+	t.Helper()
+
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.String("package", "", "")
+	if pkg != "" {
+		require.NoError(t, flagSet.Set("package", pkg))
+	}
+
+	return flagctx.NewContext(ctx, flagSet)
+}
+
+func TestDetermineMonorepoPackage(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"pnpm-workspace.yaml":   "packages:\n  - apps/*\n",
+		"apps/api/package.json": `{"name": "@acme/api"}`,
+		"apps/web/package.json": `{"name": "@acme/web"}`,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+
+	monorepo, pkg, err := determineMonorepoPackage(newDetermineMonorepoPackageCtx(t, "apps/web"), dir)
+	require.NoError(t, err)
+	assert.Equal(t, "pnpm", monorepo.Tool)
+	assert.Equal(t, "@acme/web", pkg.Name)
+
+	_, _, err = determineMonorepoPackage(newDetermineMonorepoPackageCtx(t, ""), dir)
+	assert.ErrorContains(t, err, "choose the package to launch with --package: @acme/api, @acme/web")
+
+	_, _, err = determineMonorepoPackage(newDetermineMonorepoPackageCtx(t, "api"), t.TempDir())
+	assert.ErrorContains(t, err, "isn't the root of")
+
+	// a Dockerfile at the root launches the workspace as a whole
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), nil, 0o644))
+	monorepo, pkg, err = determineMonorepoPackage(newDetermineMonorepoPackageCtx(t, ""), dir)
+	require.NoError(t, err)
+	assert.Nil(t, monorepo)
+	assert.Nil(t, pkg)
+}
+
+func TestLaunchConfigPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("/src", "fly.toml"), launchConfigPath("/src", nil))
+	assert.Equal(t, filepath.Join("/src", "fly.toml"), launchConfigPath("/src", &scanner.SourceInfo{}))
+	assert.Equal(t, filepath.Join("/src", "apps", "web", "fly.toml"), launchConfigPath("/src", &scanner.SourceInfo{PackagePath: "apps/web"}))
+}
@@ -61,6 +61,34 @@ func TestLoadAppConfigIfPresent(t *testing.T) {
 			t.Fatal("expected the explicit config to be added to the context")
 		}
 	})
+
+	t.Run("FLY_ENVIRONMENT only applies to commands with an environment flag", func(t *testing.T) {
+		t.Setenv("FLY_ENVIRONMENT", "staging")
+
+		configPath := filepath.Join(t.TempDir(), appconfig.DefaultConfigFileName)
+		if err := os.WriteFile(configPath, []byte("app = \"test-app\"\n\n[environments.staging]\napp = \"test-app-staging\"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := loadAppConfigTestContext(t, configPath)
+		loadedCtx, err := LoadAppConfigIfPresent(ctx)
+		if err != nil {
+			t.Fatalf("LoadAppConfigIfPresent() error = %v", err)
+		}
+		if got := appconfig.ConfigFromContext(loadedCtx).AppName; got != "test-app" {
+			t.Fatalf("app name = %q, want the base config's %q", got, "test-app")
+		}
+
+		ctx = loadAppConfigTestContext(t, configPath)
+		flag.FromContext(ctx).String(flagnames.Environment, "", "")
+		loadedCtx, err = LoadAppConfigIfPresent(ctx)
+		if err != nil {
+			t.Fatalf("LoadAppConfigIfPresent() error = %v", err)
+		}
+		if got := appconfig.ConfigFromContext(loadedCtx).AppName; got != "test-app-staging" {
+			t.Fatalf("app name = %q, want the staging overlay's %q", got, "test-app-staging")
+		}
+	})
 }
 
 func loadAppConfigTestContext(t *testing.T, configPath string) context.Context {
@@ -0,0 +1,196 @@
+package logs
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/superfly/flyctl/logs"
+
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/render"
+)
+
+const (
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+)
+
+var csvHeader = []string{"timestamp", "level", "region", "instance", "provider", "message"}
+
+// filterFlags are the flags newFilter reads.
+func filterFlags() flag.Set {
+	return flag.Set{
+		flag.ProcessGroup("Filter by process group"),
+		flag.String{
+			Name:        "since",
+			Description: "Only include logs after this time or duration ago, such as 2006-01-02T15:04:05Z or 1h",
+		},
+		flag.String{
+			Name:        "until",
+			Description: "Only include logs before this time or duration ago, such as 2006-01-02T15:04:05Z or 10m",
+		},
+		flag.String{
+			Name:        "level",
+			Description: "Only include logs of this level or above, such as warn",
+		},
+		flag.String{
+			Name:        "grep",
+			Description: "Only include logs whose message matches this regular expression",
+		},
+	}
+}
+
+// newFilter returns the filter of the filtering flags, for the logs of the given apps.
+func newFilter(ctx context.Context, flapsClient flapsutil.FlapsClient, appNames []string, now time.Time) (*logs.Filter, error) {
+	var (
+		filter = &logs.Filter{Level: flag.GetString(ctx, "level")}
+		err    error
+	)
+
+	if since := flag.GetString(ctx, "since"); since != "" {
+		if filter.Since, err = logs.ParseTime(since, now); err != nil {
+			return nil, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if until := flag.GetString(ctx, "until"); until != "" {
+		if filter.Until, err = logs.ParseTime(until, now); err != nil {
+			return nil, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return nil, errors.New("--until must be after --since")
+	}
+
+	if filter.Level != "" {
+		if err := logs.ValidateLevel(filter.Level); err != nil {
+			return nil, err
+		}
+	}
+
+	if grep := flag.GetString(ctx, "grep"); grep != "" {
+		if filter.Grep, err = regexp.Compile(grep); err != nil {
+			return nil, fmt.Errorf("invalid --grep: %w", err)
+		}
+	}
+
+	if group := flag.GetProcessGroup(ctx); group != "" {
+		filter.Instances = map[string]bool{}
+		for _, appName := range appNames {
+			machines, err := flapsClient.ListActive(ctx, appName)
+			if err != nil {
+				return nil, fmt.Errorf("could not get a list of machines: %w", err)
+			}
+
+			for _, machine := range machines {
+				if machine.ProcessGroup() == group {
+					filter.Instances[machine.ID] = true
+				}
+			}
+		}
+		if len(filter.Instances) == 0 {
+			return nil, fmt.Errorf("%s has no machines in process group %s", describeApps(appNames), group)
+		}
+	}
+
+	return filter, nil
+}
+
+func describeApps(appNames []string) string {
+	if len(appNames) == 1 {
+		return "app " + appNames[0]
+	}
+
+	return "none of apps " + strings.Join(appNames, ", ")
+}
+
+// newEntryPrinter returns a func printing log entries to w in the given format, which
+// streams may call concurrently. tag, set when printing the logs of several apps,
+// prefixes entries printed as text.
+func newEntryPrinter(w io.Writer, format string, jsonOutput bool, tag func(appEntry) string) (func(appEntry) error, error) {
+	var print func(appEntry) error
+
+	switch {
+	case format != "" && jsonOutput:
+		return nil, errors.New("--format can't be used with --json")
+	case format == formatNDJSON:
+		enc := json.NewEncoder(w)
+		print = func(entry appEntry) error {
+			return enc.Encode(entry)
+		}
+	case format == formatCSV:
+		cw := csv.NewWriter(w)
+		header := csvHeader
+		if tag != nil {
+			header = append([]string{"app", "process_group"}, header...)
+		}
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+		print = func(entry appEntry) error {
+			record := []string{entry.Timestamp, entry.Level, entry.Region, entry.Instance, entry.Meta.Event.Provider, entry.Message}
+			if tag != nil {
+				record = append([]string{entry.App, entry.ProcessGroup}, record...)
+			}
+
+			// flushed entry by entry, so that tailed logs show up as they come
+			cw.Write(record) //nolint:errcheck
+			cw.Flush()
+
+			return cw.Error()
+		}
+	case format != "":
+		tmpl, err := template.New("format").Parse(format)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --format: %w", err)
+		}
+		print = func(entry appEntry) error {
+			if err := tmpl.Execute(w, entry); err != nil {
+				return err
+			}
+			if !strings.HasSuffix(format, "\n") {
+				_, err := io.WriteString(w, "\n")
+
+				return err
+			}
+
+			return nil
+		}
+	case jsonOutput:
+		print = func(entry appEntry) error {
+			return render.JSON(w, entry)
+		}
+	default:
+		print = func(entry appEntry) error {
+			if tag != nil {
+				if _, err := io.WriteString(w, tag(entry)); err != nil {
+					return err
+				}
+			}
+
+			return render.LogEntry(w, entry.LogEntry,
+				render.HideAllocID(),
+				render.RemoveNewlines(),
+				render.HideRegion(),
+			)
+		}
+	}
+
+	var mu sync.Mutex
+
+	return func(entry appEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return print(entry)
+	}, nil
+}
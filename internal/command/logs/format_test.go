@@ -0,0 +1,87 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+
+	"github.com/superfly/flyctl/internal/mock"
+	"github.com/superfly/flyctl/logs"
+)
+
+func TestNewFilter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	client := &mock.FlapsClient{
+		ListActiveFunc: func(_ context.Context, _ string) ([]*fly.Machine, error) {
+			return []*fly.Machine{
+				{ID: "web-1", Config: &fly.MachineConfig{Metadata: map[string]string{fly.MachineConfigMetadataKeyFlyProcessGroup: "web"}}},
+				{ID: "worker-1", Config: &fly.MachineConfig{Metadata: map[string]string{fly.MachineConfigMetadataKeyFlyProcessGroup: "worker"}}},
+			}, nil
+		},
+	}
+
+	ctx := machineSelectionContext(t, client, "--since", "1h", "--level", "warn", "--grep", "time(out|d out)", "--process-group", "web")
+	filter, err := newFilter(ctx, client, []string{"test-app"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-time.Hour), filter.Since)
+	assert.Equal(t, "warn", filter.Level)
+	assert.Equal(t, map[string]bool{"web-1": true}, filter.Instances)
+	assert.True(t, filter.Grep.MatchString("request timed out"))
+
+	for _, args := range [][]string{
+		{"--since", "yesterday"},
+		{"--since", "1h", "--until", "2h"},
+		{"--level", "loud"},
+		{"--grep", "("},
+		{"--process-group", "nope"},
+	} {
+		ctx := machineSelectionContext(t, client, args...)
+		_, err := newFilter(ctx, client, []string{"test-app"}, now)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestNewEntryPrinter(t *testing.T) {
+	entry := logs.LogEntry{
+		Timestamp: "2025-01-02T15:04:05Z",
+		Level:     "info",
+		Region:    "ams",
+		Instance:  "m1",
+		Message:   `said "hi", twice`,
+	}
+
+	for _, tc := range []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "ndjson",
+			want:   `{"level":"info","instance":"m1","message":"said \"hi\", twice","region":"ams","timestamp":"2025-01-02T15:04:05Z","meta":{"Instance":"","Region":"","Event":{"Provider":""},"HTTP":{"Request":{"ID":"","Method":"","Version":""},"Response":{"status_code":0}},"Error":{"Code":0,"Message":""},"URL":{"Full":""}}}` + "\n",
+		},
+		{
+			format: "csv",
+			want:   "timestamp,level,region,instance,provider,message\n2025-01-02T15:04:05Z,info,ams,m1,,\"said \"\"hi\"\", twice\"\n",
+		},
+		{
+			format: "{{.Region}} {{.Message}}",
+			want:   "ams said \"hi\", twice\n",
+		},
+	} {
+		var buf bytes.Buffer
+		print, err := newEntryPrinter(&buf, tc.format, false, nil)
+		require.NoError(t, err, tc.format)
+		require.NoError(t, print(appEntry{LogEntry: entry}), tc.format)
+		assert.Equal(t, tc.want, buf.String(), tc.format)
+	}
+
+	_, err := newEntryPrinter(&bytes.Buffer{}, "{{.Nope", false, nil)
+	assert.ErrorContains(t, err, "invalid --format")
+
+	_, err = newEntryPrinter(&bytes.Buffer{}, "csv", true, nil)
+	assert.Error(t, err)
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"time"
 
 	"github.com/azazeal/pause"
@@ -19,11 +18,12 @@ import (
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flag/completion"
+	"github.com/superfly/flyctl/internal/flag/flagnames"
 	"github.com/superfly/flyctl/internal/flapsutil"
 	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/flyctl/internal/logger"
 	"github.com/superfly/flyctl/internal/prompt"
-	"github.com/superfly/flyctl/internal/render"
 )
 
 func New() (cmd *cobra.Command) {
@@ -34,21 +34,41 @@ the Fly platform.
 Logs can be filtered to a specific machine using the --machine/-m flag or
 to all machines running in a specific region using the --region/-r flag.
 
+Repeat --app/-a to view the logs of several apps at once, or combine it with
+--org/-o to view the logs of the apps of an organization whose names match
+globs, such as -o my-org -a 'api-*'. Logs of several apps are merged in time
+order and prefixed with their app, process group and machine.
+
 By default logs are continually streamed until the command is aborted.
 Use --no-tail to only fetch the logs in the buffer.
+
+Logs can be narrowed down to a time range with --since and --until, which take
+either a time such as 2006-01-02T15:04:05Z or a duration such as 1h meaning that
+long ago, to a process group with --process-group, to levels from --level up,
+and to messages matching the regular expression of --grep.
+
+Use --format ndjson or --format csv to export logs, one entry per line, or a Go
+template such as --format '{{.Timestamp}} {{.Message}}' to print chosen fields
+of each entry.
 `
 		short = "View app logs"
 	)
 
 	cmd = command.New("logs", short, long, run,
 		command.RequireSession,
-		command.RequireAppName,
+		requireAppNames,
 	)
 
 	cmd.Args = cobra.NoArgs
 
 	flag.Add(cmd,
-		flag.App(),
+		flag.StringArray{
+			Name:         flagnames.App,
+			Shorthand:    "a",
+			Description:  "Application name, or a glob of application names with --org. Repeat to view the logs of several apps",
+			CompletionFn: completion.CompleteApps,
+		},
+		flag.Org(),
 		flag.AppConfig(),
 		flag.Region(),
 		flag.JSONOutput(),
@@ -69,20 +89,37 @@ Use --no-tail to only fetch the logs in the buffer.
 			Shorthand:   "n",
 			Description: "Do not continually stream logs",
 		},
+		filterFlags(),
+		flag.String{
+			Name:        "format",
+			Description: "Print logs as ndjson, csv, or with a Go template such as '{{.Timestamp}} {{.Message}}'",
+		},
 	)
 
+	cmd.AddCommand(newShip())
+
 	return
 }
 
 func run(ctx context.Context) error {
 	client := flyutil.ClientFromContext(ctx)
 
-	regionCode := config.FromContext(ctx).Region
-	vmid, err := resolveMachineID(ctx, appconfig.NameFromContext(ctx))
+	appNames, err := resolveAppNames(ctx, client)
 	if err != nil {
 		return err
 	}
+	multi := len(appNames) > 1
+
+	var vmid string
+	if multi {
+		if flag.IsSpecified(ctx, "machine") || flag.GetBool(ctx, "select") {
+			return errors.New("--machine and --select can't be used with several apps")
+		}
+	} else if vmid, err = resolveMachineID(ctx, appNames[0]); err != nil {
+		return err
+	}
 
+	regionCode := config.FromContext(ctx).Region
 	// When filtering by machine ID, ignore region filter since machine IDs are globally unique.
 	// This prevents region-locked NATS subscriptions when running on Fly.io machines where
 	// FLY_REGION is set, allowing logs to be retrieved from machines in any region.
@@ -90,38 +127,92 @@ func run(ctx context.Context) error {
 		regionCode = ""
 	}
 
-	opts := &logs.LogOptions{
-		AppName:    appconfig.NameFromContext(ctx),
-		RegionCode: regionCode,
-		VMID:       vmid,
-		NoTail:     flag.GetBool(ctx, "no-tail"),
+	flapsClient := flapsutil.ClientFromContext(ctx)
+
+	filter, err := newFilter(ctx, flapsClient, appNames, time.Now())
+	if err != nil {
+		return err
 	}
 
-	flapsClient := flapsutil.ClientFromContext(ctx)
+	io := iostreams.FromContext(ctx)
+	var tagger func(appEntry) string
+	if multi {
+		tagger = newTagger(io.ColorScheme(), appNames)
+	}
+	print, err := newEntryPrinter(io.Out, flag.GetString(ctx, "format"), config.FromContext(ctx).JSONOutput, tagger)
+	if err != nil {
+		return err
+	}
 
 	var eg *errgroup.Group
 	eg, ctx = errgroup.WithContext(ctx)
 
-	var streams []<-chan logs.LogEntry
-	if opts.NoTail {
-		streams = []<-chan logs.LogEntry{
-			poll(ctx, eg, client, opts),
+	var streams []<-chan appEntry
+	for _, appName := range appNames {
+		opts := &logs.LogOptions{
+			AppName:    appName,
+			RegionCode: regionCode,
+			VMID:       vmid,
+			NoTail:     flag.GetBool(ctx, "no-tail"),
+			Filter:     filter,
 		}
-	} else {
-		pollingCtx, cancelPolling := context.WithCancel(ctx)
-		streams = []<-chan logs.LogEntry{
-			poll(pollingCtx, eg, client, opts),
-			nats(ctx, eg, client, flapsClient, opts, cancelPolling),
+
+		// A single app's logs aren't tagged, so that they print as they always have
+		var tag func(logs.LogEntry) appEntry
+		if multi {
+			if tag, err = appTagger(ctx, flapsClient, appName); err != nil {
+				return err
+			}
+		} else {
+			tag = func(entry logs.LogEntry) appEntry { return appEntry{LogEntry: entry} }
+		}
+
+		if opts.NoTail {
+			streams = append(streams, tagEntries(poll(ctx, eg, client, opts), tag))
+		} else {
+			pollingCtx, cancelPolling := context.WithCancel(ctx)
+			streams = append(streams,
+				tagEntries(poll(pollingCtx, eg, client, opts), tag),
+				tagEntries(nats(ctx, eg, client, flapsClient, opts, cancelPolling), tag),
+			)
 		}
 	}
 
+	entries := mergeStreams(ctx, streams...)
+	if multi {
+		entries = orderEntries(ctx, entries, reorderWindow)
+	}
+
 	eg.Go(func() error {
-		return printStreams(ctx, streams...)
+		return printEntries(ctx, print, entries)
 	})
 
 	return eg.Wait()
 }
 
+// requireAppNames is the preparer of the repeatable --app flag of fly logs. When it's
+// given once, without --org, it sets the app name like command.RequireAppName does.
+// Other selections of apps are resolved by resolveAppNames.
+func requireAppNames(ctx context.Context) (context.Context, error) {
+	names := flag.GetStringArray(ctx, flagnames.App)
+
+	switch {
+	case flag.GetString(ctx, flagnames.Org) != "":
+		return appconfig.WithName(ctx, ""), nil
+	case len(names) == 0:
+		return command.RequireAppName(ctx)
+	case len(names) == 1:
+		ctx, err := command.LoadAppConfigIfPresent(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return appconfig.WithName(ctx, names[0]), nil
+	default:
+		return appconfig.WithName(ctx, ""), nil
+	}
+}
+
 func resolveMachineID(ctx context.Context, appName string) (string, error) {
 	machineID := flag.GetString(ctx, "machine")
 	if !flag.GetBool(ctx, "select") {
@@ -206,45 +297,17 @@ func nats(ctx context.Context, eg *errgroup.Group, client flyutil.Client, flapsC
 	return c
 }
 
-func printStreams(ctx context.Context, streams ...<-chan logs.LogEntry) error {
-	var eg *errgroup.Group
-	eg, ctx = errgroup.WithContext(ctx)
-
-	out := iostreams.FromContext(ctx).Out
-	json := config.FromContext(ctx).JSONOutput
-
-	for _, stream := range streams {
-
-		eg.Go(func() error {
-			return printStream(ctx, out, stream, json)
-		})
-	}
-
-	return eg.Wait()
-}
-
-func printStream(ctx context.Context, w io.Writer, stream <-chan logs.LogEntry, json bool) error {
+func printEntries(ctx context.Context, print func(appEntry) error, entries <-chan appEntry) error {
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case entry, ok := <-stream:
+		case entry, ok := <-entries:
 			if !ok {
 				return nil
 			}
 
-			var err error
-			if json {
-				err = render.JSON(w, entry)
-			} else {
-				err = render.LogEntry(w, entry,
-					render.HideAllocID(),
-					render.RemoveNewlines(),
-					render.HideRegion(),
-				)
-			}
-
-			if err != nil {
+			if err := print(entry); err != nil {
 				return err
 			}
 		}
@@ -0,0 +1,243 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/logs"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flag/flagnames"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/flyutil"
+)
+
+// reorderWindow is how long the logs of several apps wait to be printed in time order
+// with the logs of the other apps.
+const reorderWindow = time.Second
+
+// appEntry is a log entry, with the app and process group it comes from when viewing
+// the logs of several apps.
+type appEntry struct {
+	App          string `json:"app,omitempty"`
+	ProcessGroup string `json:"process_group,omitempty"`
+	logs.LogEntry
+}
+
+// resolveAppNames returns the apps to view the logs of: those of --app, or with --org
+// those of the organization matching the globs of --app, or the app of the context.
+func resolveAppNames(ctx context.Context, client flyutil.Client) ([]string, error) {
+	var (
+		names   = flag.GetStringArray(ctx, flagnames.App)
+		orgSlug = flag.GetString(ctx, flagnames.Org)
+	)
+
+	if orgSlug == "" {
+		if len(names) == 0 {
+			return []string{appconfig.NameFromContext(ctx)}, nil
+		}
+
+		var unique []string
+		for _, name := range names {
+			if !slices.Contains(unique, name) {
+				unique = append(unique, name)
+			}
+		}
+
+		return unique, nil
+	}
+
+	if len(names) == 0 {
+		names = []string{"*"}
+	}
+	for _, pattern := range names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid app name glob %q: %w", pattern, err)
+		}
+	}
+
+	org, err := client.GetOrganizationBySlug(ctx, orgSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving organization %s: %w", orgSlug, err)
+	}
+	apps, err := client.GetAppsForOrganization(ctx, org.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing the apps of %s: %w", orgSlug, err)
+	}
+
+	var matches []string
+	for _, app := range apps {
+		if slices.ContainsFunc(names, func(pattern string) bool {
+			matched, _ := path.Match(pattern, app.Name)
+
+			return matched
+		}) {
+			matches = append(matches, app.Name)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no app of %s matches %s", orgSlug, strings.Join(names, ", "))
+	}
+	slices.Sort(matches)
+
+	return matches, nil
+}
+
+// appTagger returns a func tagging the entries of appName with the app and the process
+// group of their machine, as of now.
+func appTagger(ctx context.Context, flapsClient flapsutil.FlapsClient, appName string) (func(logs.LogEntry) appEntry, error) {
+	machines, err := flapsClient.ListActive(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("could not get a list of the machines of %s: %w", appName, err)
+	}
+
+	groups := make(map[string]string, len(machines))
+	for _, machine := range machines {
+		groups[machine.ID] = machine.ProcessGroup()
+	}
+
+	return func(entry logs.LogEntry) appEntry {
+		return appEntry{App: appName, ProcessGroup: groups[entry.Instance], LogEntry: entry}
+	}, nil
+}
+
+func tagEntries(stream <-chan logs.LogEntry, tag func(logs.LogEntry) appEntry) <-chan appEntry {
+	out := make(chan appEntry)
+
+	go func() {
+		defer close(out)
+
+		for entry := range stream {
+			out <- tag(entry)
+		}
+	}()
+
+	return out
+}
+
+// newTagger returns a func prefixing the entries of each app with their app, process
+// group and machine, in a color of their app.
+func newTagger(colorize *iostreams.ColorScheme, appNames []string) func(appEntry) string {
+	palette := []func(string) string{colorize.Cyan, colorize.Magenta, colorize.Yellow, colorize.Blue, colorize.Green, colorize.Purple}
+
+	colors := make(map[string]func(string) string, len(appNames))
+	for i, name := range appNames {
+		colors[name] = palette[i%len(palette)]
+	}
+
+	return func(entry appEntry) string {
+		if entry.App == "" {
+			return ""
+		}
+
+		tag := entry.App
+		if entry.ProcessGroup != "" {
+			tag += "/" + entry.ProcessGroup
+		}
+		if entry.Instance != "" {
+			tag += "/" + entry.Instance
+		}
+
+		return colors[entry.App]("["+tag+"]") + " "
+	}
+}
+
+// orderEntries returns the entries of in in time order, as far as entries arriving
+// within window of one another go. Entries are printed window after they arrive.
+func orderEntries(ctx context.Context, in <-chan appEntry, window time.Duration) <-chan appEntry {
+	out := make(chan appEntry)
+
+	type pending struct {
+		entry   appEntry
+		arrived time.Time
+		at      time.Time
+	}
+
+	var (
+		mu      sync.Mutex
+		buffer  []pending
+		done    = make(chan struct{})
+		release = func(before time.Time) (ready []appEntry) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			var due []pending
+			buffer = slices.DeleteFunc(buffer, func(p pending) bool {
+				if before.IsZero() || p.arrived.Before(before) {
+					due = append(due, p)
+
+					return true
+				}
+
+				return false
+			})
+			slices.SortStableFunc(due, func(a, b pending) int { return a.at.Compare(b.at) })
+
+			for _, p := range due {
+				ready = append(ready, p.entry)
+			}
+
+			return
+		}
+	)
+
+	go func() {
+		defer close(done)
+
+		for entry := range in {
+			// entries of unparseable times are ordered as of their arrival
+			at, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+			now := time.Now()
+			if err != nil {
+				at = now
+			}
+
+			mu.Lock()
+			buffer = append(buffer, pending{entry, now, at})
+			mu.Unlock()
+		}
+	}()
+
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(window / 4)
+		defer ticker.Stop()
+
+		emit := func(entries []appEntry) bool {
+			for _, entry := range entries {
+				select {
+				case out <- entry:
+				case <-ctx.Done():
+					return false
+				}
+			}
+
+			return true
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				emit(release(time.Time{}))
+
+				return
+			case now := <-ticker.C:
+				if !emit(release(now.Add(-window))) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,119 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/logs"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/mock"
+)
+
+func TestResolveAppNames(t *testing.T) {
+	client := &mock.Client{
+		GetOrganizationBySlugFunc: func(_ context.Context, slug string) (*fly.Organization, error) {
+			return &fly.Organization{ID: "org-" + slug, Slug: slug}, nil
+		},
+		GetAppsForOrganizationFunc: func(_ context.Context, orgID string) ([]fly.App, error) {
+			require.Equal(t, "org-acme", orgID)
+
+			return []fly.App{{Name: "worker"}, {Name: "api"}, {Name: "api-staging"}, {Name: "db"}}, nil
+		},
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want []string
+	}{
+		{args: nil, want: []string{"context-app"}},
+		{args: []string{"-a", "api", "-a", "worker", "-a", "api"}, want: []string{"api", "worker"}},
+		{args: []string{"--org", "acme", "-a", "api*"}, want: []string{"api", "api-staging"}},
+		{args: []string{"--org", "acme", "-a", "db", "-a", "w*"}, want: []string{"db", "worker"}},
+		{args: []string{"--org", "acme"}, want: []string{"api", "api-staging", "db", "worker"}},
+	} {
+		ctx := machineSelectionContext(t, &mock.FlapsClient{}, tc.args...)
+		ctx = appconfig.WithName(ctx, "context-app")
+
+		names, err := resolveAppNames(ctx, client)
+		require.NoError(t, err, tc.args)
+		assert.Equal(t, tc.want, names, tc.args)
+	}
+
+	for _, args := range [][]string{
+		{"--org", "acme", "-a", "nope*"},
+		{"--org", "acme", "-a", "[api"},
+	} {
+		ctx := machineSelectionContext(t, &mock.FlapsClient{}, args...)
+		_, err := resolveAppNames(ctx, client)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestNewTagger(t *testing.T) {
+	tag := newTagger(iostreams.NewColorScheme(false, false, false), []string{"api", "worker"})
+
+	assert.Equal(t, "[api/web/m1] ", tag(appEntry{App: "api", ProcessGroup: "web", LogEntry: logs.LogEntry{Instance: "m1"}}))
+	assert.Equal(t, "[worker/m2] ", tag(appEntry{App: "worker", LogEntry: logs.LogEntry{Instance: "m2"}}))
+	assert.Empty(t, tag(appEntry{LogEntry: logs.LogEntry{Instance: "m3"}}))
+}
+
+func TestNewEntryPrinterOfSeveralApps(t *testing.T) {
+	entry := appEntry{
+		App:          "api",
+		ProcessGroup: "web",
+		LogEntry: logs.LogEntry{
+			Timestamp: "2025-01-02T15:04:05Z",
+			Level:     "info",
+			Region:    "ams",
+			Instance:  "m1",
+			Message:   "hi",
+		},
+	}
+	tag := newTagger(iostreams.NewColorScheme(false, false, false), []string{"api"})
+
+	var buf bytes.Buffer
+	print, err := newEntryPrinter(&buf, "csv", false, tag)
+	require.NoError(t, err)
+	require.NoError(t, print(entry))
+	assert.Equal(t, "app,process_group,timestamp,level,region,instance,provider,message\napi,web,2025-01-02T15:04:05Z,info,ams,m1,,hi\n", buf.String())
+
+	buf.Reset()
+	print, err = newEntryPrinter(&buf, "{{.App}} {{.Message}}", false, tag)
+	require.NoError(t, err)
+	require.NoError(t, print(entry))
+	assert.Equal(t, "api hi\n", buf.String())
+
+	buf.Reset()
+	print, err = newEntryPrinter(&buf, "", false, tag)
+	require.NoError(t, err)
+	require.NoError(t, print(entry))
+	assert.Contains(t, buf.String(), "[api/web/m1] ")
+	assert.Contains(t, buf.String(), "hi")
+}
+
+func TestOrderEntries(t *testing.T) {
+	in := make(chan appEntry)
+	out := orderEntries(context.Background(), in, 40*time.Millisecond)
+
+	go func() {
+		defer close(in)
+
+		for _, ts := range []string{"2025-01-02T15:00:02Z", "2025-01-02T15:00:01Z", "2025-01-02T15:00:03Z"} {
+			in <- appEntry{LogEntry: logs.LogEntry{Timestamp: ts}}
+		}
+	}()
+
+	var got []string
+	for entry := range out {
+		got = append(got, entry.Timestamp)
+	}
+	assert.Equal(t, []string{"2025-01-02T15:00:01Z", "2025-01-02T15:00:02Z", "2025-01-02T15:00:03Z"}, got)
+}
@@ -0,0 +1,57 @@
+package machine
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/tokens"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/internal/inmem"
+	"github.com/superfly/flyctl/internal/logger"
+	"github.com/superfly/flyctl/internal/task"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestMachineStop(t *testing.T) {
+	t.Setenv("FLY_ACCESS_TOKEN", "test-token")
+	t.Chdir(t.TempDir())
+
+	server := inmem.NewServer()
+	server.CreateApp(&fly.App{Name: "my-app", Organization: fly.Organization{Slug: "my-org"}})
+	flapsClient := server.FlapsClient("my-app")
+
+	ctx := context.Background()
+	started, err := flapsClient.Launch(ctx, "my-app", fly.LaunchMachineInput{Region: "ord", Config: &fly.MachineConfig{}})
+	require.NoError(t, err)
+	require.Equal(t, fly.MachineStateStarted, started.State)
+
+	var out bytes.Buffer
+	ctx = iostreams.NewContext(ctx, &iostreams.IOStreams{Out: &out, ErrOut: &out})
+	ctx = task.NewWithContext(ctx)
+	ctx = logger.NewContext(ctx, logger.New(&out, logger.Info, false))
+	ctx = config.NewContext(ctx, &config.Config{Tokens: tokens.Parse("test-token"), LastLogin: time.Now()})
+	ctx = flyutil.NewContextWithClient(ctx, server.Client())
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	// commands record their metrics once per process, so only one runs
+	cmd := New()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"stop", started.ID, "--app", "my-app", "--wait-timeout", "5s"})
+	require.NoError(t, cmd.ExecuteContext(ctx), out.String())
+
+	assert.Contains(t, out.String(), started.ID+" has been successfully stopped")
+	stopped, err := flapsClient.Get(ctx, "my-app", started.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fly.MachineStateStopped, stopped.State)
+	// the lease taken for the stop was released
+	_, err = flapsClient.FindLease(ctx, "my-app", started.ID)
+	assert.Error(t, err)
+}
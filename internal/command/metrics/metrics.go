@@ -12,15 +12,18 @@ import (
 
 func New() (cmd *cobra.Command) {
 	const (
-		short = "Commands that handle sending any metrics to flyctl-metrics"
-		long  = short + "\n"
+		short = "Query the Prometheus metrics of apps"
+		long  = `Query the Prometheus metrics Fly.io collects for the apps of an organization,
+such as the CPU and memory usage of their Machines or the requests they serve.
+`
 		usage = "metrics <command>"
 	)
 
 	cmd = command.New(usage, short, long, nil)
-	cmd.Hidden = true
 
 	cmd.AddCommand(
+		newQuery(),
+		newTop(),
 		newSend(),
 	)
 
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/internal/prompt"
+)
+
+// newPrometheusAPI returns a client of the Prometheus API of the organization.
+func newPrometheusAPI(ctx context.Context, orgSlug string) (promv1.API, error) {
+	cfg := config.FromContext(ctx)
+
+	client, err := promapi.NewClient(promapi.Config{
+		Address: strings.TrimSuffix(cfg.PrometheusBaseURL, "/") + "/" + orgSlug,
+		RoundTripper: &authTransport{
+			authorization: cfg.Tokens.GraphQLHeader(),
+			next:          promapi.DefaultRoundTripper,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed creating the Prometheus client: %w", err)
+	}
+
+	return promv1.NewAPI(client), nil
+}
+
+// authTransport sets the Authorization header of requests, with the scheme of the token:
+// FlyV1 for macaroons, Bearer otherwise.
+type authTransport struct {
+	authorization string
+	next          http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", t.authorization)
+
+	return t.next.RoundTrip(req)
+}
+
+// orgSlug returns the organization to query: the one of --org, or of the app, or the one
+// the user picks.
+func orgSlug(ctx context.Context) (string, error) {
+	if slug := flag.GetOrg(ctx); slug != "" {
+		return slug, nil
+	}
+
+	if appName := appconfig.NameFromContext(ctx); appName != "" {
+		app, err := flyutil.ClientFromContext(ctx).GetAppBasic(ctx, appName)
+		if err != nil {
+			return "", fmt.Errorf("failed retrieving app %s: %w", appName, err)
+		}
+
+		return app.Organization.Slug, nil
+	}
+
+	org, err := prompt.Org(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return org.Slug, nil
+}
+
+const sparks = "▁▂▃▄▅▆▇█"
+
+// sparkline draws values as a line of at most width bars, averaging neighbouring values
+// when there are more of them. Missing values are drawn as blanks.
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		buckets := make([]float64, width)
+		for i := range buckets {
+			from, to := i*len(values)/width, (i+1)*len(values)/width
+
+			var sum float64
+			var n int
+			for _, v := range values[from:to] {
+				if !math.IsNaN(v) {
+					sum += v
+					n++
+				}
+			}
+			buckets[i] = math.NaN()
+			if n > 0 {
+				buckets[i] = sum / float64(n)
+			}
+		}
+		values = buckets
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+	}
+
+	bars := []rune(sparks)
+
+	var sb strings.Builder
+	for _, v := range values {
+		switch {
+		case math.IsNaN(v):
+			sb.WriteRune(' ')
+		case hi == lo:
+			sb.WriteRune(bars[0])
+		default:
+			sb.WriteRune(bars[int((v-lo)/(hi-lo)*float64(len(bars)-1)+0.5)])
+		}
+	}
+
+	return sb.String()
+}
+
+func formatValue(v float64) string {
+	if math.Abs(v) >= 1000 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
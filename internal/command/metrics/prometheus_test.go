@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/fly-go/tokens"
+
+	"github.com/superfly/flyctl/internal/config"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▃▅█", sparkline([]float64{0, 1, 2, 3.5}, 10))
+	assert.Equal(t, "▁ █", sparkline([]float64{1, math.NaN(), 5}, 10))
+	assert.Equal(t, "▁▁", sparkline([]float64{2, 2}, 10))
+	assert.Equal(t, "▁█", sparkline([]float64{0, 2, 8, 10}, 2))
+}
+
+func TestRenderValue(t *testing.T) {
+	var out bytes.Buffer
+	err := renderValue(&out, model.Vector{
+		{Metric: model.Metric{"instance": "b"}, Value: 2.5},
+		{Metric: model.Metric{"instance": "a"}, Value: 12345.6},
+	}, nil)
+	require.NoError(t, err)
+	assert.Less(t, strings.Index(out.String(), `{instance="a"}`), strings.Index(out.String(), `{instance="b"}`))
+	assert.Contains(t, out.String(), "12346")
+	assert.Contains(t, out.String(), "2.5")
+
+	start := time.Unix(1000, 0)
+	out.Reset()
+	err = renderValue(&out, model.Matrix{
+		{
+			Metric: model.Metric{"instance": "a"},
+			Values: []model.SamplePair{
+				{Timestamp: model.TimeFromUnix(1000), Value: 1},
+				{Timestamp: model.TimeFromUnix(1030), Value: 3},
+			},
+		},
+	}, &promv1.Range{Start: start, End: start.Add(30 * time.Second), Step: 10 * time.Second})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "▁  █")
+}
+
+// startPrometheus serves canned instant query results, by query, for org my-org.
+func startPrometheus(t *testing.T, results map[string]model.Vector) context.Context {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/my-org/api/v1/query" || r.Header.Get("Authorization") != "Bearer fo1_token" {
+			http.Error(w, "not found", http.StatusNotFound)
+
+			return
+		}
+
+		result, ok := results[r.FormValue("query")]
+		if !ok {
+			result = model.Vector{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"status": "success",
+			"data":   map[string]any{"resultType": "vector", "result": result},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	return config.NewContext(context.Background(), &config.Config{
+		PrometheusBaseURL: srv.URL,
+		Tokens:            tokens.Parse("fo1_token"),
+	})
+}
+
+func TestRenderTop(t *testing.T) {
+	sample := func(instance string, value model.SampleValue) *model.Sample {
+		return &model.Sample{Metric: model.Metric{"instance": model.LabelValue(instance), "region": "ams"}, Value: value}
+	}
+	query := func(i int) string {
+		return strings.ReplaceAll(topQueries[i].query, "%[1]q", `"my-app"`)
+	}
+
+	ctx := startPrometheus(t, map[string]model.Vector{
+		query(0): {sample("idle", 1.5), sample("busy", 87.25)},
+		query(1): {sample("idle", 256<<20), sample("busy", 900<<20)},
+		query(2): {sample("idle", 1<<30), sample("busy", 1<<30)},
+		query(3): {sample("busy", 42)},
+	})
+
+	api, err := newPrometheusAPI(ctx, "my-org")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, renderTop(ctx, &out, api, "my-app", time.Now()))
+
+	lines := strings.Split(out.String(), "\n")
+	require.GreaterOrEqual(t, len(lines), 3)
+	assert.Contains(t, lines[1], "busy")
+	assert.Contains(t, lines[1], "87.2%")
+	assert.Contains(t, lines[1], "900 MiB / 1.0 GiB")
+	assert.Contains(t, lines[1], "42")
+	assert.Contains(t, lines[2], "idle")
+	assert.Contains(t, lines[2], "1.5%")
+	assert.Contains(t, lines[2], "-")
+
+	out.Reset()
+	require.NoError(t, renderTop(ctx, &out, api, "other-app", time.Now()))
+	assert.Equal(t, "No Machine of other-app reported metrics recently\n", out.String())
+}
+
+func TestPrometheusAuthorization(t *testing.T) {
+	var authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	for token, want := range map[string]string{
+		"fo1_token":      "Bearer fo1_token",
+		"fm2_macaroon":   "FlyV1 fm2_macaroon",
+		"FlyV1 fm2_more": "FlyV1 fm2_more",
+	} {
+		ctx := config.NewContext(context.Background(), &config.Config{
+			PrometheusBaseURL: srv.URL,
+			Tokens:            tokens.Parse(token),
+		})
+
+		api, err := newPrometheusAPI(ctx, "my-org")
+		require.NoError(t, err)
+		api.Query(ctx, "up", time.Time{})
+		assert.Equal(t, want, authorization, token)
+	}
+}
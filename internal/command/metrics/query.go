@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/iostreams"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+)
+
+// sparklineWidth is the number of bars of the sparklines of range queries.
+const sparklineWidth = 40
+
+func newQuery() (cmd *cobra.Command) {
+	const (
+		short = "Run a PromQL query against an organization's metrics"
+		long  = `Run a PromQL query against the Prometheus metrics of an organization: the one
+given with --org, or the one of the app.
+
+Without --range, the query is evaluated now and each series is shown with its
+value. With --range, the query is evaluated over that much time up to now, and
+each series is shown as a sparkline with its minimum, maximum and last values.
+`
+		usage = "query <promql>"
+	)
+
+	cmd = command.New(usage, short, long, runQuery,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Org(),
+		flag.JSONOutput(),
+		flag.Duration{
+			Name:        "range",
+			Description: "Evaluate the query over this much time up to now, such as 1h",
+		},
+		flag.Duration{
+			Name:        "step",
+			Description: "Resolution of --range queries. Defaults to a 60th of the range",
+		},
+	)
+
+	return
+}
+
+func runQuery(ctx context.Context) error {
+	var (
+		query     = flag.FirstArg(ctx)
+		queryTime = time.Now()
+		rng       = flag.GetDuration(ctx, "range")
+		step      = flag.GetDuration(ctx, "step")
+	)
+
+	if rng < 0 || step < 0 {
+		return errors.New("--range and --step must be positive")
+	}
+
+	slug, err := orgSlug(ctx)
+	if err != nil {
+		return err
+	}
+
+	api, err := newPrometheusAPI(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	var (
+		value    model.Value
+		warnings promv1.Warnings
+		r        *promv1.Range
+	)
+	if rng == 0 {
+		value, warnings, err = api.Query(ctx, query, queryTime)
+	} else {
+		if step == 0 {
+			step = max(rng/60, time.Second)
+		}
+		r = &promv1.Range{
+			Start: queryTime.Add(-rng),
+			End:   queryTime,
+			Step:  step,
+		}
+		value, warnings, err = api.QueryRange(ctx, query, *r)
+	}
+	if err != nil {
+		return fmt.Errorf("failed querying metrics: %w", err)
+	}
+
+	io := iostreams.FromContext(ctx)
+	for _, warning := range warnings {
+		fmt.Fprintln(io.ErrOut, io.ColorScheme().Yellow("Warning: "+warning))
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, value)
+	}
+
+	return renderValue(io.Out, value, r)
+}
+
+// renderValue renders the result of a query, evaluated over r for range queries.
+func renderValue(w io.Writer, value model.Value, r *promv1.Range) error {
+	switch value := value.(type) {
+	case *model.Scalar:
+		fmt.Fprintln(w, formatValue(float64(value.Value)))
+	case *model.String:
+		fmt.Fprintln(w, value.Value)
+	case model.Vector:
+		if len(value) == 0 {
+			fmt.Fprintln(w, "No series matched the query")
+
+			return nil
+		}
+
+		slices.SortFunc(value, func(a, b *model.Sample) int { return compareMetrics(a.Metric, b.Metric) })
+
+		rows := make([][]string, 0, len(value))
+		for _, sample := range value {
+			rows = append(rows, []string{sample.Metric.String(), formatValue(float64(sample.Value))})
+		}
+
+		return render.Table(w, "", rows, "Series", "Value")
+	case model.Matrix:
+		if len(value) == 0 {
+			fmt.Fprintln(w, "No series matched the query")
+
+			return nil
+		}
+
+		slices.SortFunc(value, func(a, b *model.SampleStream) int { return compareMetrics(a.Metric, b.Metric) })
+
+		rows := make([][]string, 0, len(value))
+		for _, stream := range value {
+			rows = append(rows, streamRow(stream, r))
+		}
+
+		return render.Table(w, "", rows, "Series", "Trend", "Min", "Max", "Last")
+	default:
+		return fmt.Errorf("unsupported result type %s", value.Type())
+	}
+
+	return nil
+}
+
+func compareMetrics(a, b model.Metric) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case b.Before(a):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// streamRow summarizes a series of a range query. Its sparkline spans the whole range,
+// with blanks where the series has no samples.
+func streamRow(stream *model.SampleStream, r *promv1.Range) []string {
+	row := []string{stream.Metric.String(), "", "-", "-", "-"}
+	if len(stream.Values) == 0 {
+		return row
+	}
+
+	values := make([]float64, 0, len(stream.Values))
+	for _, pair := range stream.Values {
+		values = append(values, float64(pair.Value))
+	}
+	row[2] = formatValue(slices.Min(values))
+	row[3] = formatValue(slices.Max(values))
+	row[4] = formatValue(values[len(values)-1])
+
+	if r != nil && r.Step > 0 {
+		steps := make([]float64, int(r.End.Sub(r.Start)/r.Step)+1)
+		for i := range steps {
+			steps[i] = math.NaN()
+		}
+		for _, pair := range stream.Values {
+			if i := int(pair.Timestamp.Time().Sub(r.Start) / r.Step); i >= 0 && i < len(steps) {
+				steps[i] = float64(pair.Value)
+			}
+		}
+		values = steps
+	}
+	row[1] = sparkline(values, sparklineWidth)
+
+	return row
+}
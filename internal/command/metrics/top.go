@@ -0,0 +1,235 @@
+package metrics
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/azazeal/pause"
+	"github.com/dustin/go-humanize"
+	"github.com/inancgumus/screen"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/superfly/flyctl/iostreams"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+)
+
+func newTop() (cmd *cobra.Command) {
+	const (
+		short = "Show the busiest Machines of an app"
+		long  = `Show the CPU usage, memory usage and request rate of each Machine of an app,
+busiest first. In interactive sessions, the view refreshes every --rate seconds
+until interrupted.
+`
+	)
+
+	cmd = command.New("top", short, long, runTop,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Int{
+			Name:        "rate",
+			Description: "Refresh rate, in seconds",
+			Default:     5,
+		},
+	)
+
+	return
+}
+
+func runTop(ctx context.Context) (err error) {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		appName  = appconfig.NameFromContext(ctx)
+	)
+
+	sleep := flag.GetInt(ctx, "rate")
+	if sleep < 1 || sleep > 3600 {
+		return errors.New("--rate must be in the [1, 3600] range")
+	}
+
+	slug, err := orgSlug(ctx)
+	if err != nil {
+		return err
+	}
+
+	api, err := newPrometheusAPI(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	if !io.IsInteractive() {
+		return renderTop(ctx, io.Out, api, appName, time.Now())
+	}
+
+	var buf bytes.Buffer
+	for err == nil {
+		buf.Reset()
+
+		now := time.Now()
+		if err = renderTop(ctx, &buf, api, appName, now); err != nil {
+			break
+		}
+
+		header := fmt.Sprintf("%s %s %s\n\n", colorize.Bold(appName), "at:", colorize.Bold(now.UTC().Format("15:04:05")))
+
+		screen.Clear()
+		screen.MoveTopLeft()
+
+		fmt.Fprint(io.Out, header)
+		buf.WriteTo(io.Out)
+
+		pause.For(ctx, time.Duration(sleep)*time.Second)
+	}
+
+	// Interrupted with Ctrl-C
+	if errors.Is(ctx.Err(), context.Canceled) {
+		err = nil
+	}
+
+	return
+}
+
+// machineUsage is what fly metrics top shows of a Machine. Fields are NaN when the
+// Machine didn't report them.
+type machineUsage struct {
+	id, region  string
+	cpu         float64
+	memoryUsed  float64
+	memoryTotal float64
+	requests    float64
+}
+
+// topQueries compute the fields of machineUsage by Machine. The CPU counter is in
+// hundredths of seconds, so its rate over the number of CPUs is a percentage.
+var topQueries = []struct {
+	query string
+	field func(*machineUsage) *float64
+}{
+	{
+		`sum by (instance, region) (rate(fly_instance_cpu{app=%[1]q, mode!="idle"}[1m])) / count by (instance, region) (fly_instance_cpu{app=%[1]q, mode="idle"})`,
+		func(u *machineUsage) *float64 { return &u.cpu },
+	},
+	{
+		`fly_instance_memory_mem_total{app=%[1]q} - fly_instance_memory_mem_available{app=%[1]q}`,
+		func(u *machineUsage) *float64 { return &u.memoryUsed },
+	},
+	{
+		`fly_instance_memory_mem_total{app=%[1]q}`,
+		func(u *machineUsage) *float64 { return &u.memoryTotal },
+	},
+	{
+		`sum by (instance, region) (rate(fly_app_http_responses_count{app=%[1]q}[1m]))`,
+		func(u *machineUsage) *float64 { return &u.requests },
+	},
+}
+
+func renderTop(ctx context.Context, w io.Writer, api promv1.API, appName string, now time.Time) error {
+	results := make([]model.Vector, len(topQueries))
+
+	eg, ctx := errgroup.WithContext(ctx)
+	for i, q := range topQueries {
+		eg.Go(func() error {
+			value, _, err := api.Query(ctx, fmt.Sprintf(q.query, appName), now)
+			if err != nil {
+				return fmt.Errorf("failed querying metrics: %w", err)
+			}
+
+			vector, ok := value.(model.Vector)
+			if !ok {
+				return fmt.Errorf("unexpected result type %s", value.Type())
+			}
+			results[i] = vector
+
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	byID := map[string]*machineUsage{}
+	for i, vector := range results {
+		for _, sample := range vector {
+			id := string(sample.Metric["instance"])
+
+			usage := byID[id]
+			if usage == nil {
+				usage = &machineUsage{
+					id:          id,
+					region:      string(sample.Metric["region"]),
+					cpu:         math.NaN(),
+					memoryUsed:  math.NaN(),
+					memoryTotal: math.NaN(),
+					requests:    math.NaN(),
+				}
+				byID[id] = usage
+			}
+			*topQueries[i].field(usage) = float64(sample.Value)
+		}
+	}
+
+	if len(byID) == 0 {
+		fmt.Fprintf(w, "No Machine of %s reported metrics recently\n", appName)
+
+		return nil
+	}
+
+	usages := make([]*machineUsage, 0, len(byID))
+	for _, usage := range byID {
+		usages = append(usages, usage)
+	}
+	slices.SortFunc(usages, func(a, b *machineUsage) int {
+		return cmp.Or(cmp.Compare(orZero(b.cpu), orZero(a.cpu)), cmp.Compare(a.id, b.id))
+	})
+
+	rows := make([][]string, 0, len(usages))
+	for _, usage := range usages {
+		rows = append(rows, usage.row())
+	}
+
+	return render.Table(w, "", rows, "Machine", "Region", "CPU", "Memory", "Req/s")
+}
+
+func (u *machineUsage) row() []string {
+	cpu, memory, requests := "-", "-", "-"
+	if !math.IsNaN(u.cpu) {
+		cpu = fmt.Sprintf("%.1f%%", u.cpu)
+	}
+	if !math.IsNaN(u.memoryUsed) && !math.IsNaN(u.memoryTotal) {
+		memory = fmt.Sprintf("%s / %s", humanize.IBytes(uint64(u.memoryUsed)), humanize.IBytes(uint64(u.memoryTotal)))
+	}
+	if !math.IsNaN(u.requests) {
+		requests = formatValue(u.requests)
+	}
+
+	return []string{u.id, u.region, cpu, memory, requests}
+}
+
+func orZero(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+
+	return v
+}
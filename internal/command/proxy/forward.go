@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/proxy"
+	"golang.org/x/sync/errgroup"
+)
+
+// runForwardProxies runs SOCKS5 and HTTP CONNECT proxies to the org's private network on
+// the given addresses, skipping empty ones, until ctx is cancelled.
+func runForwardProxies(ctx context.Context, agentclient *agent.Client, dialer agent.Dialer, orgSlug, network, socks5Addr, httpAddr string) error {
+	io := iostreams.FromContext(ctx)
+
+	resolve := func(ctx context.Context, addr string) (string, error) {
+		return agentclient.Resolve(ctx, orgSlug, addr, network)
+	}
+
+	var proxies []*proxy.ForwardProxy
+	for _, listen := range []struct{ protocol, addr string }{{proxy.ProtocolSOCKS5, socks5Addr}, {proxy.ProtocolHTTP, httpAddr}} {
+		protocol, addr := listen.protocol, listen.addr
+		if addr == "" {
+			continue
+		}
+
+		listener, err := net.Listen("tcp", bindAddr(ctx, addr))
+		if err != nil {
+			for _, p := range proxies {
+				p.Listener.Close()
+			}
+
+			return err
+		}
+
+		fmt.Fprintf(io.Out, "Running a %s proxy to the private network of %s on %s\n", protocolName(protocol), orgSlug, listener.Addr())
+
+		proxies = append(proxies, &proxy.ForwardProxy{
+			Protocol: protocol,
+			Listener: listener,
+			Resolve:  resolve,
+			Dial:     dialer.DialContext,
+		})
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, p := range proxies {
+		eg.Go(func() error {
+			return p.Serve(ctx)
+		})
+	}
+
+	return eg.Wait()
+}
+
+// bindAddr defaults the host of addr to the --bind-addr flag.
+func bindAddr(ctx context.Context, addr string) string {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		return net.JoinHostPort(flag.GetBindAddr(ctx), port)
+	}
+
+	return addr
+}
+
+func protocolName(protocol string) string {
+	if protocol == proxy.ProtocolSOCKS5 {
+		return "SOCKS5"
+	}
+
+	return "HTTP CONNECT"
+}
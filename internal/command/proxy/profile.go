@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// defaultProfilePath is where fly proxy up looks up its forwards by default.
+const defaultProfilePath = ".fly/proxies.toml"
+
+// profile lists named forwards to open together.
+type profile struct {
+	Forwards []*forward `toml:"forwards"`
+}
+
+// forward proxies a local address to a port of an app's Machines.
+type forward struct {
+	Name string `toml:"name"`
+	App  string `toml:"app"`
+	// Local is the port to listen on, optionally with the address to bind to.
+	Local string `toml:"local"`
+	// RemotePort defaults to the local port.
+	RemotePort int `toml:"remote_port,omitempty"`
+	// Machine or Region, at most one of them, narrows the Machines of the app to proxy to.
+	Machine string `toml:"machine,omitempty"`
+	Region  string `toml:"region,omitempty"`
+}
+
+func loadProfile(path string) (*profile, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &profile{}
+	if err := toml.Unmarshal(buf, p); err != nil {
+		return nil, fmt.Errorf("failed to parse proxy profile %s: %w", path, err)
+	}
+	if len(p.Forwards) == 0 {
+		return nil, fmt.Errorf("proxy profile %s has no forwards", path)
+	}
+
+	seen := map[string]bool{}
+	for _, f := range p.Forwards {
+		switch {
+		case f.Name == "":
+			return nil, fmt.Errorf("a forward of proxy profile %s has no name", path)
+		case seen[f.Name]:
+			return nil, fmt.Errorf("forward %s is listed more than once in proxy profile %s", f.Name, path)
+		case f.App == "":
+			return nil, fmt.Errorf("forward %s has no app", f.Name)
+		case f.Machine != "" && f.Region != "":
+			return nil, fmt.Errorf("forward %s sets both a machine and a region, pick one", f.Name)
+		}
+		seen[f.Name] = true
+
+		if _, err := f.localPort(); err != nil {
+			return nil, fmt.Errorf("forward %s: %w", f.Name, err)
+		}
+		if f.RemotePort < 0 || f.RemotePort > 65535 {
+			return nil, fmt.Errorf("forward %s: invalid remote port %d", f.Name, f.RemotePort)
+		}
+	}
+
+	return p, nil
+}
+
+// selectForwards returns the forwards with the given names, or all of them when names is
+// empty.
+func (p *profile) selectForwards(names []string) ([]*forward, error) {
+	if len(names) == 0 {
+		return p.Forwards, nil
+	}
+
+	selected := make([]*forward, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(p.Forwards, func(f *forward) bool { return f.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("no forward named %s in the proxy profile", name)
+		}
+		if !slices.Contains(selected, p.Forwards[i]) {
+			selected = append(selected, p.Forwards[i])
+		}
+	}
+
+	return selected, nil
+}
+
+// bindAddr returns the address the forward binds to, defaulting to defaultAddr.
+func (f *forward) bindAddr(defaultAddr string) string {
+	if host, _, err := net.SplitHostPort(f.Local); err == nil && host != "" {
+		return host
+	}
+
+	return defaultAddr
+}
+
+func (f *forward) localPort() (string, error) {
+	port := f.Local
+	if _, p, err := net.SplitHostPort(f.Local); err == nil {
+		port = p
+	}
+
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return "", fmt.Errorf("invalid local port %q", f.Local)
+	}
+
+	return port, nil
+}
+
+func (f *forward) remotePort() string {
+	if f.RemotePort != 0 {
+		return strconv.Itoa(f.RemotePort)
+	}
+
+	port, _ := f.localPort()
+
+	return port
+}
+
+// remoteHost is the private DNS name of the Machines the forward proxies to.
+func (f *forward) remoteHost() string {
+	switch {
+	case f.Machine != "":
+		return fmt.Sprintf("%s.vm.%s.internal", f.Machine, f.App)
+	case f.Region != "":
+		return fmt.Sprintf("%s.%s.internal", f.Region, f.App)
+	default:
+		return f.App + ".internal"
+	}
+}
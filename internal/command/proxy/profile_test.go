@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProfile(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "proxies.toml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+
+	return path
+}
+
+func TestLoadProfile(t *testing.T) {
+	p, err := loadProfile(writeProfile(t, `
+[[forwards]]
+name = "db"
+app = "my-db"
+local = "15432"
+remote_port = 5432
+region = "ams"
+
+[[forwards]]
+name = "admin"
+app = "admin-api"
+local = "0.0.0.0:8080"
+machine = "148e272b"
+
+[[forwards]]
+name = "metrics"
+app = "metrics"
+local = "9091"
+`))
+	require.NoError(t, err)
+	require.Len(t, p.Forwards, 3)
+
+	db, admin, metrics := p.Forwards[0], p.Forwards[1], p.Forwards[2]
+
+	assert.Equal(t, "127.0.0.1", db.bindAddr("127.0.0.1"))
+	assert.Equal(t, "5432", db.remotePort())
+	assert.Equal(t, "ams.my-db.internal", db.remoteHost())
+
+	port, err := admin.localPort()
+	require.NoError(t, err)
+	assert.Equal(t, "8080", port)
+	assert.Equal(t, "0.0.0.0", admin.bindAddr("127.0.0.1"))
+	assert.Equal(t, "8080", admin.remotePort())
+	assert.Equal(t, "148e272b.vm.admin-api.internal", admin.remoteHost())
+
+	assert.Equal(t, "metrics.internal", metrics.remoteHost())
+
+	selected, err := p.selectForwards([]string{"metrics", "db", "metrics"})
+	require.NoError(t, err)
+	assert.Equal(t, []*forward{metrics, db}, selected)
+
+	selected, err = p.selectForwards(nil)
+	require.NoError(t, err)
+	assert.Len(t, selected, 3)
+
+	_, err = p.selectForwards([]string{"redis"})
+	assert.ErrorContains(t, err, "no forward named redis")
+}
+
+func TestLoadProfileInvalid(t *testing.T) {
+	cases := map[string]string{
+		"":                          "has no forwards",
+		"[[forwards]]\napp = \"a\"": "has no name",
+		"[[forwards]]\nname = \"a\"\napp = \"a\"\nlocal = \"1\"\n[[forwards]]\nname = \"a\"\napp = \"b\"\nlocal = \"2\"": "listed more than once",
+		"[[forwards]]\nname = \"a\"\nlocal = \"1\"":                                               "has no app",
+		"[[forwards]]\nname = \"a\"\napp = \"a\"\nlocal = \"db\"":                                 "invalid local port",
+		"[[forwards]]\nname = \"a\"\napp = \"a\"\nlocal = \"1\"\nmachine = \"m\"\nregion = \"r\"": "both a machine and a region",
+	}
+
+	for contents, want := range cases {
+		_, err := loadProfile(writeProfile(t, contents))
+		assert.ErrorContains(t, err, want, contents)
+	}
+}
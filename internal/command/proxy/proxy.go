@@ -22,14 +22,31 @@ import (
 func New() *cobra.Command {
 	var (
 		long = strings.Trim(`Proxies connections to a Fly Machine through a WireGuard tunnel. By default,
-connects to the first Machine address returned by an internal DNS query on the app.`, "\n")
+connects to the first Machine address returned by an internal DNS query on the app.
+
+With --socks5 or --http-proxy, runs a SOCKS5 or HTTP CONNECT proxy instead, which
+lets any client reach any host of the organization's private network, such as
+my-app.internal or my-app.flycast, through a single local port.
+
+With --udp, relays datagrams sent to a local UDP port instead, keeping a session
+per client that closes once idle for --udp-idle-timeout.
+
+To open several forwards at once, list them in a profile and run fly proxy up.`, "\n")
 		short = `Proxies connections to a Fly Machine.`
 	)
 
 	cmd := command.New("proxy <local:remote> [remote_host]", short, long, run,
 		command.RequireSession, command.LoadAppNameIfPresent)
 
-	cmd.Args = cobra.RangeArgs(1, 2)
+	cmd.AddCommand(newUp())
+
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("socks5") || cmd.Flags().Changed("http-proxy") {
+			return cobra.NoArgs(cmd, args)
+		}
+
+		return cobra.RangeArgs(1, 2)(cmd, args)
+	}
 
 	flag.Add(cmd,
 		flag.App(),
@@ -57,6 +74,23 @@ connects to the first Machine address returned by an internal DNS query on the a
 			Default:     false,
 			Description: "Watches stdin and terminates once it gets closed",
 		},
+		flag.Bool{
+			Name:        "udp",
+			Description: "Relay UDP datagrams instead of TCP connections",
+		},
+		flag.Duration{
+			Name:        "udp-idle-timeout",
+			Default:     proxy.DefaultUDPIdleTimeout,
+			Description: "With --udp, how long to keep the session of a client that stopped sending datagrams",
+		},
+		flag.String{
+			Name:        "socks5",
+			Description: "Run a SOCKS5 proxy to the private network on this address, such as :1080. Addresses without a host bind to --bind-addr",
+		},
+		flag.String{
+			Name:        "http-proxy",
+			Description: "Run an HTTP CONNECT proxy to the private network on this address, such as :8080. Addresses without a host bind to --bind-addr",
+		},
 	)
 
 	return cmd
@@ -120,6 +154,14 @@ func run(ctx context.Context) (err error) {
 		return err
 	}
 
+	if flag.GetBool(ctx, "watch-stdin") {
+		ctx = watchStdinAndAbortOnClose(ctx)
+	}
+
+	if socks5, httpProxy := flag.GetString(ctx, "socks5"), flag.GetString(ctx, "http-proxy"); socks5 != "" || httpProxy != "" {
+		return runForwardProxies(ctx, agentclient, dialer, orgSlug, *network, socks5, httpProxy)
+	}
+
 	ports := strings.Split(args[0], ":")
 
 	params := &proxy.ConnectParams{
@@ -130,6 +172,8 @@ func run(ctx context.Context) (err error) {
 		Dialer:           dialer,
 		PromptInstance:   promptInstance,
 		Network:          *network,
+		UDP:              flag.GetBool(ctx, "udp"),
+		UDPIdleTimeout:   flag.GetDuration(ctx, "udp-idle-timeout"),
 	}
 
 	if len(args) > 1 {
@@ -138,10 +182,6 @@ func run(ctx context.Context) (err error) {
 		params.RemoteHost = fmt.Sprintf("%s.internal", appName)
 	}
 
-	if flag.GetBool(ctx, "watch-stdin") {
-		ctx = watchStdinAndAbortOnClose(ctx)
-	}
-
 	return proxy.Connect(ctx, params)
 }
 
@@ -0,0 +1,250 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flag/flagnames"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/proxy"
+	"github.com/superfly/flyctl/wg"
+)
+
+func newUp() *cobra.Command {
+	const (
+		short = "Open the forwards of a proxy profile"
+		long  = `Open the forwards listed in a proxy profile, all of them or only the named
+ones, through the agent, and keep them open until interrupted. Tunnels that drop
+are reestablished on the next connection.
+
+A profile lists named forwards from a local port to a port of an app:
+
+  [[forwards]]
+  name = "db"
+  app = "my-db"
+  local = "5432"          # or "0.0.0.0:5432"
+  remote_port = 5432      # defaults to the local port
+  region = "ams"          # or machine = "<machine id>", both optional`
+	)
+
+	cmd := command.New("up [name...]", short, long, runUp,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.ArbitraryArgs
+
+	flag.Add(cmd,
+		flag.String{
+			Name:        "profile",
+			Default:     defaultProfilePath,
+			Description: "Path of the proxy profile",
+		},
+		flag.String{
+			Name:        flagnames.BindAddr,
+			Shorthand:   "b",
+			Default:     "127.0.0.1",
+			Description: "Local address to bind forwards without one to",
+		},
+		flag.Bool{
+			Name:        "quiet",
+			Shorthand:   "q",
+			Description: "Don't print progress indicators for WireGuard",
+		},
+	)
+
+	return cmd
+}
+
+func runUp(ctx context.Context) error {
+	var (
+		io     = iostreams.FromContext(ctx)
+		client = flyutil.ClientFromContext(ctx)
+	)
+
+	p, err := loadProfile(flag.GetString(ctx, "profile"))
+	if err != nil {
+		return err
+	}
+	forwards, err := p.selectForwards(flag.Args(ctx))
+	if err != nil {
+		return err
+	}
+
+	agentclient, err := agent.Establish(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	// Forwards to apps of the same org and network share a tunnel
+	type tunnelKey struct{ org, network string }
+	var (
+		tunnels = map[tunnelKey]*reconnectingDialer{}
+		servers = make([]*proxy.Server, 0, len(forwards))
+		rows    = make([][]string, 0, len(forwards))
+	)
+	defer func() {
+		for _, server := range servers {
+			server.Listener.Close()
+		}
+	}()
+
+	for _, f := range forwards {
+		app, err := client.GetAppBasic(ctx, f.App)
+		if err != nil {
+			return fmt.Errorf("forward %s: %w", f.Name, err)
+		}
+		network, err := client.GetAppNetwork(ctx, f.App)
+		if err != nil {
+			return fmt.Errorf("forward %s: %w", f.Name, err)
+		}
+
+		key := tunnelKey{app.Organization.Slug, *network}
+		tunnel := tunnels[key]
+		if tunnel == nil {
+			tunnel = &reconnectingDialer{
+				connect: func(ctx context.Context, reestablish bool) (agent.Dialer, error) {
+					if reestablish {
+						if _, err := agentclient.Reestablish(ctx, key.org, key.network); err != nil {
+							return nil, err
+						}
+					}
+
+					return agentclient.ConnectToTunnel(ctx, key.org, key.network, flag.GetBool(ctx, "quiet"))
+				},
+				probe: func(ctx context.Context) error {
+					return agentclient.Probe(ctx, key.org, key.network)
+				},
+				onReconnect: func() {
+					fmt.Fprintf(io.ErrOut, "Reconnected the tunnel to %s\n", key.org)
+				},
+			}
+			if err := tunnel.start(ctx); err != nil {
+				return err
+			}
+			tunnels[key] = tunnel
+		}
+
+		localPort, _ := f.localPort()
+		bindAddr := f.bindAddr(flag.GetBindAddr(ctx))
+		server, err := proxy.NewServer(ctx, &proxy.ConnectParams{
+			AppName:          f.App,
+			OrganizationSlug: key.org,
+			Dialer:           tunnel,
+			BindAddr:         bindAddr,
+			Ports:            []string{localPort, f.remotePort()},
+			RemoteHost:       f.remoteHost(),
+			Network:          key.network,
+		})
+		if err != nil {
+			return fmt.Errorf("forward %s: %w", f.Name, err)
+		}
+		servers = append(servers, server)
+
+		rows = append(rows, []string{f.Name, f.App, net.JoinHostPort(bindAddr, localPort), server.Addr, key.org})
+	}
+
+	if err := render.Table(io.Out, "Forwards", rows, "Name", "App", "Local", "Remote", "Org"); err != nil {
+		return err
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, server := range servers {
+		eg.Go(func() error {
+			return server.ProxyServer(ctx)
+		})
+	}
+
+	return eg.Wait()
+}
+
+// reconnectingDialer dials through an org's tunnel, and reestablishes the tunnel when a
+// dial fails because it dropped.
+type reconnectingDialer struct {
+	connect func(ctx context.Context, reestablish bool) (agent.Dialer, error)
+	// probe tells a dropped tunnel from an unreachable target.
+	probe       func(ctx context.Context) error
+	onReconnect func()
+
+	mu     sync.Mutex
+	dialer agent.Dialer
+}
+
+func (d *reconnectingDialer) start(ctx context.Context) (err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.dialer, err = d.connect(ctx, false)
+
+	return
+}
+
+func (d *reconnectingDialer) current() agent.Dialer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.dialer
+}
+
+func (d *reconnectingDialer) State() *wg.WireGuardState {
+	return d.current().State()
+}
+
+func (d *reconnectingDialer) Config() *wg.Config {
+	return d.current().Config()
+}
+
+func (d *reconnectingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := d.current()
+
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err == nil || ctx.Err() != nil {
+		return conn, err
+	}
+
+	reconnected, rerr := d.reconnect(ctx, dialer)
+	switch {
+	case rerr != nil:
+		return nil, rerr
+	case reconnected == nil:
+		return nil, err
+	}
+
+	return reconnected.DialContext(ctx, network, addr)
+}
+
+// reconnect reestablishes the tunnel failed was dialing through, unless a concurrent dial
+// already did. It returns a nil dialer when the tunnel is up.
+func (d *reconnectingDialer) reconnect(ctx context.Context, failed agent.Dialer) (agent.Dialer, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.dialer != failed {
+		return d.dialer, nil
+	}
+	if d.probe(ctx) == nil {
+		return nil, nil
+	}
+
+	dialer, err := d.connect(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed reconnecting the tunnel: %w", err)
+	}
+	d.dialer = dialer
+	if d.onReconnect != nil {
+		d.onReconnect()
+	}
+
+	return dialer, nil
+}
+
+var _ agent.Dialer = (*reconnectingDialer)(nil)
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/wg"
+)
+
+type fakeDialer struct {
+	dropped bool
+}
+
+func (d *fakeDialer) State() *wg.WireGuardState { return nil }
+
+func (d *fakeDialer) Config() *wg.Config { return nil }
+
+func (d *fakeDialer) DialContext(_ context.Context, _, addr string) (net.Conn, error) {
+	if d.dropped || addr == "down:80" {
+		return nil, errors.New("connection refused")
+	}
+
+	client, server := net.Pipe()
+	server.Close()
+
+	return client, nil
+}
+
+func TestReconnectingDialer(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		tunnel     = &fakeDialer{}
+		connects   []bool
+		reconnects int
+	)
+
+	d := &reconnectingDialer{
+		connect: func(_ context.Context, reestablish bool) (agent.Dialer, error) {
+			connects = append(connects, reestablish)
+			tunnel = &fakeDialer{}
+
+			return tunnel, nil
+		},
+		probe: func(context.Context) error {
+			if tunnel.dropped {
+				return errors.New("tunnel down")
+			}
+
+			return nil
+		},
+		onReconnect: func() { reconnects++ },
+	}
+	require.NoError(t, d.start(ctx))
+
+	conn, err := d.DialContext(ctx, "tcp", "up:80")
+	require.NoError(t, err)
+	conn.Close()
+
+	// Unreachable targets don't reestablish a healthy tunnel
+	_, err = d.DialContext(ctx, "tcp", "down:80")
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Equal(t, []bool{false}, connects)
+
+	tunnel.dropped = true
+	conn, err = d.DialContext(ctx, "tcp", "up:80")
+	require.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, []bool{false, true}, connects)
+	assert.Equal(t, 1, reconnects)
+	assert.Same(t, tunnel, d.current())
+}
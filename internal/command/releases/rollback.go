@@ -0,0 +1,147 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/appsecrets"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newRollback() *cobra.Command {
+	const (
+		long = `Roll back the application to a previous release by deploying that release's
+image and configuration again, using the configured deploy strategy.
+
+Without a version, the most recent successful release before the current one is used.
+
+The secrets minimum version is pinned to the one the release was deployed with.
+Releases that didn't record it, such as those deployed by older flyctl versions,
+are rolled back with the latest secrets version pinned instead.
+`
+		short = "Roll back to a previous release"
+		usage = "rollback [VERSION]"
+	)
+
+	cmd := command.New(usage, short, long, runRollback,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		deploy.CommonFlags,
+		flag.App(),
+	)
+
+	return cmd
+}
+
+func runRollback(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+		client  = flyutil.ClientFromContext(ctx)
+	)
+
+	releases, err := client.GetAppReleasesMachines(ctx, appName, "", appconfig.RecentReleases)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app releases %s: %w", appName, err)
+	}
+
+	current, target, err := rollbackTarget(releases, flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+	if target.ImageRef == "" {
+		return fmt.Errorf("release v%d has no image to roll back to", target.Version)
+	}
+
+	cfg, secretsVersion, err := appconfig.FromRelease(ctx, appName, target.Version)
+	if err != nil {
+		return err
+	}
+	// Deploy the release's image as is rather than building anything
+	cfg.Build = &appconfig.Build{Image: target.ImageRef}
+
+	fmt.Fprintf(io.Out, "Rolling back %s from v%d to v%d (%s)\n", appName, current.Version, target.Version, target.ImageRef)
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirm(ctx, fmt.Sprintf("Deploy v%d again?", target.Version)); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	if secretsVersion != nil {
+		err = appsecrets.SetMinvers(ctx, appName, *secretsVersion)
+	} else {
+		fmt.Fprintf(io.ErrOut, "Release v%d didn't record its secrets version, the latest secrets are used\n", target.Version)
+		err = appsecrets.Sync(ctx, flapsutil.ClientFromContext(ctx), appName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to pin the secrets version: %w", err)
+	}
+
+	ctx = appconfig.WithConfig(ctx, cfg)
+
+	return deploy.DeployWithConfig(ctx, cfg, 0, flag.GetYes(ctx))
+}
+
+// rollbackTarget returns the current release and the release to roll back to. Without a
+// version, that's the latest successful release older than the current one.
+func rollbackTarget(releases []fly.Release, version string) (current, target *fly.Release, err error) {
+	if len(releases) == 0 {
+		return nil, nil, fmt.Errorf("the app has no releases to roll back to")
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Version > releases[j].Version
+	})
+	current = &releases[0]
+
+	if version != "" {
+		v, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(version), "v"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid release version %q", version)
+		}
+		if v == current.Version {
+			return nil, nil, fmt.Errorf("v%d is already the current release", v)
+		}
+		for i := range releases {
+			if releases[i].Version == v {
+				return current, &releases[i], nil
+			}
+		}
+
+		return nil, nil, fmt.Errorf("could not find release v%d among the %d most recent releases", v, len(releases))
+	}
+
+	for i := range releases[1:] {
+		if r := &releases[i+1]; r.Status == "complete" {
+			return current, r, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("there is no successful release before v%d to roll back to", current.Version)
+}
@@ -0,0 +1,46 @@
+package releases
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+)
+
+func TestRollbackTarget(t *testing.T) {
+	releases := []fly.Release{
+		{Version: 3, Status: "complete", ImageRef: "img:3"},
+		{Version: 5, Status: "failed", ImageRef: "img:5"},
+		{Version: 4, Status: "failed", ImageRef: "img:4"},
+		{Version: 6, Status: "complete", ImageRef: "img:6"},
+	}
+
+	current, target, err := rollbackTarget(releases, "")
+	require.NoError(t, err)
+	assert.Equal(t, 6, current.Version)
+	assert.Equal(t, 3, target.Version)
+
+	_, target, err = rollbackTarget(releases, "v4")
+	require.NoError(t, err)
+	assert.Equal(t, "img:4", target.ImageRef)
+
+	_, target, err = rollbackTarget(releases, "5")
+	require.NoError(t, err)
+	assert.Equal(t, 5, target.Version)
+
+	_, _, err = rollbackTarget(releases, "6")
+	assert.ErrorContains(t, err, "already the current release")
+
+	_, _, err = rollbackTarget(releases, "v1")
+	assert.ErrorContains(t, err, "could not find release v1")
+
+	_, _, err = rollbackTarget(releases, "latest")
+	assert.ErrorContains(t, err, "invalid release version")
+
+	_, _, err = rollbackTarget([]fly.Release{{Version: 3, Status: "complete"}, {Version: 2, Status: "failed"}}, "")
+	assert.ErrorContains(t, err, "no successful release before v3")
+
+	_, _, err = rollbackTarget(nil, "")
+	assert.Error(t, err)
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/superfly/flyctl/internal/command/dashboard"
 	"github.com/superfly/flyctl/internal/command/deploy"
 	"github.com/superfly/flyctl/internal/command/destroy"
+	"github.com/superfly/flyctl/internal/command/dev"
 	"github.com/superfly/flyctl/internal/command/dig"
 	"github.com/superfly/flyctl/internal/command/docs"
 	"github.com/superfly/flyctl/internal/command/doctor"
@@ -152,6 +153,7 @@ func New() *cobra.Command {
 		group(storage.New(), "dbs_and_extensions"),
 		metrics.New(),
 		synthetics.New(),
+		dev.New(),
 		curl.New(),    // TODO: deprecate
 		open.New(),    // TODO: deprecate
 		create.New(),  // TODO: deprecate
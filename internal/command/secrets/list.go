@@ -118,6 +118,14 @@ func runList(ctx context.Context) (err error) {
 	return nil
 }
 
+// ListWithStatus returns the secrets of an app with their deployment status. ok is false
+// when the status can't be computed, such as for apps with too many machines.
+func ListWithStatus(ctx context.Context, flapsClient flapsutil.FlapsClient, uiexClient uiexutil.Client, appName string) (secrets []SecretWithStatus, ok bool, err error) {
+	_, secrets, _, _, ok, err = buildSecretRows(ctx, flapsClient, uiexClient, appName)
+
+	return secrets, ok, err
+}
+
 func buildSecretRows(ctx context.Context, flapsClient flapsutil.FlapsClient, uiexClient uiexutil.Client, appName string) ([][]string, []SecretWithStatus, int, int, bool, error) {
 	secrets, err := appsecrets.List(ctx, flapsClient, appName)
 	if err != nil {
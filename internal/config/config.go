@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io/fs"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -23,6 +24,7 @@ const (
 	flapsBaseURLEnvKey         = "FLY_FLAPS_BASE_URL"
 	metricsBaseURLEnvKey       = "FLY_METRICS_BASE_URL"
 	syntheticsBaseURLEnvKey    = "FLY_SYNTHETICS_BASE_URL"
+	prometheusBaseURLEnvKey    = "FLY_PROMETHEUS_BASE_URL"
 	AccessTokenEnvKey          = "FLY_ACCESS_TOKEN"
 	AccessTokenFileKey         = "access_token"
 	MetricsTokenEnvKey         = "FLY_METRICS_TOKEN"
@@ -51,6 +53,7 @@ const (
 	defaultRegistryHost      = "registry.fly.io"
 	defaultMetricsBaseURL    = "https://flyctl-metrics.fly.dev"
 	defaultSyntheticsBaseURL = "https://flynthetics.fly.dev"
+	defaultPrometheusBaseURL = "https://api.fly.io/prometheus"
 )
 
 // Config wraps the functionality of the configuration file.
@@ -71,6 +74,9 @@ type Config struct {
 	// SyntheticsBaseURL denotes the base URL of the synthetics API.
 	SyntheticsBaseURL string
 
+	// PrometheusBaseURL denotes the base URL of the organizations' Prometheus API.
+	PrometheusBaseURL string
+
 	// RegistryHost denotes the docker registry host.
 	RegistryHost string
 
@@ -113,6 +119,10 @@ type Config struct {
 
 	// LastLogin denotes the timestamp of the last successful login.
 	LastLogin time.Time
+
+	// ScannerPluginsDir denotes a directory of scanner plugins for fly launch to
+	// consult, in addition to those on the PATH.
+	ScannerPluginsDir string
 }
 
 func Load(ctx context.Context, path string) (*Config, error) {
@@ -122,6 +132,7 @@ func Load(ctx context.Context, path string) (*Config, error) {
 		RegistryHost:      defaultRegistryHost,
 		MetricsBaseURL:    defaultMetricsBaseURL,
 		SyntheticsBaseURL: defaultSyntheticsBaseURL,
+		PrometheusBaseURL: defaultPrometheusBaseURL,
 		Tokens:            new(tokens.Tokens),
 	}
 
@@ -171,6 +182,7 @@ func (cfg *Config) applyEnv() {
 		cfg.SyntheticsAgent = env.IsTruthy(SyntheticsAgentEnvKey)
 	}
 	cfg.SyntheticsBaseURL = env.FirstOrDefault(cfg.SyntheticsBaseURL, syntheticsBaseURLEnvKey)
+	cfg.PrometheusBaseURL = env.FirstOrDefault(cfg.PrometheusBaseURL, prometheusBaseURLEnvKey)
 }
 
 // applyFile sets the properties of cfg which may be set via configuration file
@@ -187,6 +199,8 @@ func (cfg *Config) applyFile(path string) (err error) {
 		SyntheticsAgent        bool      `yaml:"synthetics_agent"`
 		DisableManagedBuilders bool      `yaml:"disable_managed_builders"`
 		LastLogin              time.Time `yaml:"last_login"`
+		ScannerPluginsDir      string    `yaml:"scanner_plugins_dir"`
+		PrometheusBaseURL      string    `yaml:"prometheus_base_url"`
 	}
 	w.SendMetrics = true
 	w.AutoUpdate = true
@@ -201,6 +215,15 @@ func (cfg *Config) applyFile(path string) (err error) {
 		cfg.SyntheticsAgent = w.SyntheticsAgent
 		cfg.DisableManagedBuilders = w.DisableManagedBuilders
 		cfg.LastLogin = w.LastLogin
+		if w.PrometheusBaseURL != "" {
+			cfg.PrometheusBaseURL = w.PrometheusBaseURL
+		}
+
+		// relative to the directory of the config file
+		cfg.ScannerPluginsDir = w.ScannerPluginsDir
+		if cfg.ScannerPluginsDir != "" && !filepath.IsAbs(cfg.ScannerPluginsDir) {
+			cfg.ScannerPluginsDir = filepath.Join(filepath.Dir(path), cfg.ScannerPluginsDir)
+		}
 	}
 
 	return
@@ -169,3 +169,51 @@ func boolToYAML(b bool) string {
 
 	return "false"
 }
+
+// TestScannerPluginsDir tests that a relative plugins directory is relative to the config file
+func TestScannerPluginsDir(t *testing.T) {
+	ctx := flagctx.NewContext(context.Background(), pflag.NewFlagSet("test", pflag.ContinueOnError))
+
+	for _, tt := range []struct {
+		configValue string
+		expected    func(tmpDir string) string
+	}{
+		{configValue: "", expected: func(string) string { return "" }},
+		{configValue: "scanners", expected: func(tmpDir string) string { return filepath.Join(tmpDir, "scanners") }},
+		{configValue: "/opt/fly-scanners", expected: func(string) string { return "/opt/fly-scanners" }},
+	} {
+		tmpDir := t.TempDir()
+		configPath := filepath.Join(tmpDir, FileName)
+		require.NoError(t, os.WriteFile(configPath, []byte("scanner_plugins_dir: "+tt.configValue+"\n"), 0644))
+
+		cfg, err := Load(ctx, configPath)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected(tmpDir), cfg.ScannerPluginsDir)
+	}
+}
+
+// TestPrometheusBaseURLPrecedence tests that the env var overrides the config file, which overrides the default
+func TestPrometheusBaseURLPrecedence(t *testing.T) {
+	ctx := flagctx.NewContext(context.Background(), pflag.NewFlagSet("test", pflag.ContinueOnError))
+
+	for _, tt := range []struct {
+		configValue string
+		envValue    string
+		expected    string
+	}{
+		{expected: defaultPrometheusBaseURL},
+		{configValue: "https://prom.example.com", expected: "https://prom.example.com"},
+		{configValue: "https://prom.example.com", envValue: "https://env.example.com", expected: "https://env.example.com"},
+	} {
+		if tt.envValue != "" {
+			t.Setenv(prometheusBaseURLEnvKey, tt.envValue)
+		}
+
+		configPath := filepath.Join(t.TempDir(), FileName)
+		require.NoError(t, os.WriteFile(configPath, []byte("prometheus_base_url: "+tt.configValue+"\n"), 0644))
+
+		cfg, err := Load(ctx, configPath)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, cfg.PrometheusBaseURL)
+	}
+}
@@ -174,6 +174,21 @@ func GetAppConfigFilePath(ctx context.Context) string {
 	}
 }
 
+// GetEnvironment returns the app config environment selected with the environment flag
+// or the FLY_ENVIRONMENT environment variable. Commands which don't declare the
+// environment flag don't select any environment.
+func GetEnvironment(ctx context.Context) string {
+	if FromContext(ctx).Lookup(flagnames.Environment) == nil {
+		return ""
+	}
+
+	if environment := GetString(ctx, flagnames.Environment); environment != "" {
+		return environment
+	}
+
+	return env.First("FLY_ENVIRONMENT")
+}
+
 // GetBindAddr is shorthand for GetString(ctx, BindAddr).
 func GetBindAddr(ctx context.Context) string {
 	return GetString(ctx, flagnames.BindAddr)
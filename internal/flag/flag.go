@@ -244,14 +244,15 @@ func (ss StringSlice) addTo(cmd *cobra.Command) {
 
 // StringArray wraps the set of string array flags.
 type StringArray struct {
-	Name        string
-	Shorthand   string
-	Description string
-	Default     []string
-	ConfName    string
-	EnvName     string
-	Hidden      bool
-	Aliases     []string
+	Name         string
+	Shorthand    string
+	Description  string
+	Default      []string
+	ConfName     string
+	EnvName      string
+	Hidden       bool
+	Aliases      []string
+	CompletionFn func(ctx context.Context, cmd *cobra.Command, args []string, partial string) ([]string, error)
 }
 
 func (ss StringArray) addTo(cmd *cobra.Command) {
@@ -274,6 +275,14 @@ func (ss StringArray) addTo(cmd *cobra.Command) {
 	if err != nil {
 		panic(err)
 	}
+
+	// Completion
+	if ss.CompletionFn != nil {
+		err := cmd.RegisterFlagCompletionFunc(ss.Name, completion.Adapt(ss.CompletionFn))
+		if err != nil {
+			panic(err)
+		}
+	}
 }
 
 // Duration wraps the set of duration flags.
@@ -375,6 +384,14 @@ func AppConfig() String {
 	}
 }
 
+// Environment returns a string flag selecting the environment overlay of the app config.
+func Environment() String {
+	return String{
+		Name:        flagnames.Environment,
+		Description: "Environment whose overlay to merge over the app configuration file, from its [environments] section or a fly.<environment>.toml file (also FLY_ENVIRONMENT)",
+	}
+}
+
 // Image returns a Docker image config string flag.
 func Image() String {
 	return String{
@@ -539,6 +556,13 @@ func NoCache() Bool {
 	}
 }
 
+func ForceBuild() Bool {
+	return Bool{
+		Name:        "force-build",
+		Description: "Build the image even if an image built from the same source is already in the registry",
+	}
+}
+
 func BuildSecret() StringArray {
 	return StringArray{
 		Name:        "build-secret",
@@ -642,7 +666,7 @@ func CompressionLevel() Int {
 func Strategy() String {
 	return String{
 		Name:        "strategy",
-		Description: "The strategy for replacing running instances. Options are canary, rolling, bluegreen, or immediate. The default strategy is rolling.",
+		Description: "The strategy for replacing running instances. Options are canary, rolling, bluegreen, immediate, or progressive. The default strategy is rolling.",
 	}
 }
 
@@ -31,6 +31,9 @@ const (
 	// AppConfigFilePath denotes the name of the app config file path flag.
 	AppConfigFilePath = "config"
 
+	// Environment denotes the name of the app config environment flag.
+	Environment = "environment"
+
 	// Image denotes the name of the image flag.
 	Image = "image"
 
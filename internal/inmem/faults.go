@@ -0,0 +1,88 @@
+package inmem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/superfly/fly-go/flaps"
+)
+
+// Fault makes matching flaps calls fail or stall instead of reaching the server.
+type Fault struct {
+	// Method is the name of the FlapsClient method, e.g. "Update" or "AcquireLease".
+	Method string
+	// MachineID restricts the fault to calls on a single machine.
+	MachineID string
+	// After is the number of matching calls to let through before the fault kicks in.
+	After int
+	// Times is the number of calls to fail, zero fails all of them.
+	Times int
+	// Delay stalls the call, or until its context is done, before anything else happens.
+	Delay time.Duration
+	// Err is returned instead of running the call, which goes through when nil.
+	Err error
+
+	calls int
+}
+
+// InjectFault registers a fault for the next calls matching it.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all the injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// fault runs the first injected fault matching a call, if any.
+func (s *Server) fault(ctx context.Context, method, machineID string) error {
+	s.mu.Lock()
+	var match *Fault
+	for _, f := range s.faults {
+		if f.Method != method || (f.MachineID != "" && f.MachineID != machineID) {
+			continue
+		}
+		f.calls++
+		if f.calls <= f.After || (f.Times > 0 && f.calls > f.After+f.Times) {
+			continue
+		}
+		match = f
+		break
+	}
+	s.mu.Unlock()
+
+	if match == nil {
+		return nil
+	}
+
+	if match.Delay > 0 {
+		select {
+		case <-time.After(match.Delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return match.Err
+}
+
+// NewFlapsError returns an error shaped like the ones the flaps client returns for
+// responses with the given status code.
+func NewFlapsError(status int, format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	body, _ := json.Marshal(map[string]string{"error": msg})
+
+	return &flaps.FlapsError{
+		OriginalError:      errors.New(msg),
+		ResponseStatusCode: status,
+		ResponseBody:       body,
+	}
+}
@@ -2,11 +2,18 @@ package inmem
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/flapsutil"
 )
 
@@ -24,281 +31,1073 @@ func NewFlapsClient(server *Server, appName string) *FlapsClient {
 	}
 }
 
-func (m *FlapsClient) AcquireLease(ctx context.Context, appName, machineID string, ttl *int) (*fly.MachineLease, error) {
-	panic("TODO")
+// machineOp runs fn on a stored machine under the server lock, after injected faults.
+func (m *FlapsClient) machineOp(ctx context.Context, method, appName, machineID string, fn func(machine *fly.Machine) error) error {
+	if err := m.server.fault(ctx, method, machineID); err != nil {
+		return err
+	}
+
+	m.server.mu.Lock()
+	defer m.server.mu.Unlock()
+
+	machine, err := m.server.machine(appName, machineID)
+	if err != nil {
+		return err
+	}
+
+	return fn(machine)
+}
+
+// volumeOp runs fn on a stored volume under the server lock, after injected faults.
+func (m *FlapsClient) volumeOp(ctx context.Context, method, appName, volumeID string, fn func(volume *fly.Volume) error) error {
+	if err := m.server.fault(ctx, method, ""); err != nil {
+		return err
+	}
+
+	m.server.mu.Lock()
+	defer m.server.mu.Unlock()
+
+	volume, err := m.server.volume(appName, volumeID)
+	if err != nil {
+		return err
+	}
+
+	return fn(volume)
+}
+
+// serverOp runs fn under the server lock, after injected faults.
+func (m *FlapsClient) serverOp(ctx context.Context, method string, fn func() error) error {
+	if err := m.server.fault(ctx, method, ""); err != nil {
+		return err
+	}
+
+	m.server.mu.Lock()
+	defer m.server.mu.Unlock()
+
+	return fn()
+}
+
+func (m *FlapsClient) AcquireLease(ctx context.Context, appName, machineID string, ttl *int) (out *fly.MachineLease, err error) {
+	err = m.machineOp(ctx, "AcquireLease", appName, machineID, func(machine *fly.Machine) error {
+		if err := m.server.checkLease(machineID, ""); err != nil {
+			return err
+		}
+
+		seconds := 30
+		if ttl != nil {
+			seconds = *ttl
+		}
+		out = m.server.acquireLease(machineID, seconds).machineLease()
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) AssignIP(ctx context.Context, appName string, req flaps.AssignIPRequest) (res *flaps.IPAssignment, err error) {
-	panic("TODO")
+	err = m.serverOp(ctx, "AssignIP", func() error {
+		if _, ok := m.server.apps[appName]; !ok {
+			return NewFlapsError(http.StatusNotFound, "app not found: %q", appName)
+		}
+
+		m.server.ipSeq++
+		ip := flaps.IPAssignment{
+			Region:      req.Region,
+			ServiceName: req.ServiceName,
+			CreatedAt:   m.server.now(),
+		}
+		switch req.Type {
+		case "v4":
+			ip.IP = fmt.Sprintf("137.66.%d.%d", m.server.ipSeq/256, m.server.ipSeq%256)
+		case "shared_v4":
+			ip.IP = "66.241.124.1"
+			ip.Shared = true
+		case "private_v6":
+			ip.IP = fmt.Sprintf("fdaa:0:1:0:1::%x", m.server.ipSeq)
+		default:
+			ip.IP = fmt.Sprintf("2a09:8280:1::%x", m.server.ipSeq)
+		}
+		if ip.Region == "" {
+			ip.Region = "global"
+		}
+		m.server.ips[appName] = append(m.server.ips[appName], ip)
+		res = &ip
+
+		return nil
+	})
+
+	return res, err
 }
 
-func (m *FlapsClient) CheckCertificate(ctx context.Context, appName, hostname string) (*fly.CertificateDetailResponse, error) {
-	panic("TODO")
+func (m *FlapsClient) CheckCertificate(ctx context.Context, appName, hostname string) (out *fly.CertificateDetailResponse, err error) {
+	return m.GetCertificate(ctx, appName, hostname)
 }
 
 func (m *FlapsClient) Cordon(ctx context.Context, appName, machineID string, nonce string) (err error) {
-	panic("TODO")
+	return m.machineOp(ctx, "Cordon", appName, machineID, func(machine *fly.Machine) error {
+		if err := m.server.checkLease(machineID, nonce); err != nil {
+			return err
+		}
+		machine.Cordoned = true
+
+		return nil
+	})
 }
 
-func (m *FlapsClient) CreateApp(ctx context.Context, req flaps.CreateAppRequest) (*flaps.App, error) {
-	panic("TODO")
+func (m *FlapsClient) CreateApp(ctx context.Context, req flaps.CreateAppRequest) (out *flaps.App, err error) {
+	err = m.serverOp(ctx, "CreateApp", func() error {
+		if _, ok := m.server.apps[req.Name]; ok {
+			return NewFlapsError(http.StatusUnprocessableEntity, "app name already exists: %q", req.Name)
+		}
+
+		app := &fly.App{
+			ID:           req.Name,
+			Name:         req.Name,
+			Network:      req.Network,
+			Status:       "pending",
+			Organization: fly.Organization{Slug: req.Org},
+		}
+		m.server.apps[req.Name] = app
+		out = flapsApp(app, 0, 0)
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) CreateACMECertificate(ctx context.Context, appName string, req fly.CreateCertificateRequest) (*fly.CertificateDetailResponse, error) {
-	panic("TODO")
+	return m.createCertificate(ctx, "CreateACMECertificate", appName, req.Hostname, false)
 }
 
-func (m *FlapsClient) CreateVolume(ctx context.Context, appName string, req fly.CreateVolumeRequest) (*fly.Volume, error) {
-	panic("TODO")
+func (m *FlapsClient) CreateVolume(ctx context.Context, appName string, req fly.CreateVolumeRequest) (out *fly.Volume, err error) {
+	err = m.serverOp(ctx, "CreateVolume", func() error {
+		if _, ok := m.server.apps[appName]; !ok {
+			return NewFlapsError(http.StatusNotFound, "app not found: %q", appName)
+		}
+
+		size := 1
+		if req.SizeGb != nil {
+			size = *req.SizeGb
+		}
+		if req.SourceVolumeID != nil {
+			source, err := m.server.volume(appName, *req.SourceVolumeID)
+			if err != nil {
+				return err
+			}
+			size = max(size, source.SizeGb)
+			if req.Region == "" {
+				req.Region = source.Region
+			}
+		}
+		if req.Region == "" {
+			return NewFlapsError(http.StatusBadRequest, "a region is required to create a volume")
+		}
+
+		zone, err := m.server.volumeZone(appName, req)
+		if err != nil {
+			return err
+		}
+
+		m.server.volumeSeq++
+		volume := &fly.Volume{
+			ID:                fmt.Sprintf("vol_%014x", m.server.volumeSeq),
+			Name:              req.Name,
+			State:             "created",
+			SizeGb:            size,
+			Region:            req.Region,
+			Zone:              zone,
+			Encrypted:         req.Encrypted == nil || *req.Encrypted,
+			CreatedAt:         m.server.now(),
+			SnapshotRetention: 5,
+			AutoBackupEnabled: req.AutoBackupEnabled == nil || *req.AutoBackupEnabled,
+		}
+		if req.SnapshotRetention != nil {
+			volume.SnapshotRetention = *req.SnapshotRetention
+		}
+		m.server.volumes[appName] = append(m.server.volumes[appName], volume)
+		out = helpers.Clone(volume)
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) CreateVolumeSnapshot(ctx context.Context, appName, volumeId string) error {
-	panic("TODO")
+	return m.volumeOp(ctx, "CreateVolumeSnapshot", appName, volumeId, func(volume *fly.Volume) error {
+		m.server.volumeSeq++
+		m.server.snapshots[volume.ID] = append(m.server.snapshots[volume.ID], fly.VolumeSnapshot{
+			ID:            fmt.Sprintf("vs_%014x", m.server.volumeSeq),
+			Size:          volume.SizeGb << 30,
+			VolumeSize:    volume.SizeGb,
+			CreatedAt:     m.server.now(),
+			Status:        "created",
+			RetentionDays: &volume.SnapshotRetention,
+		})
+
+		return nil
+	})
 }
 
 func (m *FlapsClient) DeleteApp(ctx context.Context, name string) error {
-	panic("TODO")
+	return m.serverOp(ctx, "DeleteApp", func() error {
+		if _, ok := m.server.apps[name]; !ok {
+			return NewFlapsError(http.StatusNotFound, "app not found: %q", name)
+		}
+
+		for _, machine := range m.server.machines[name] {
+			delete(m.server.leases, machine.ID)
+		}
+		for _, volume := range m.server.volumes[name] {
+			delete(m.server.snapshots, volume.ID)
+		}
+		delete(m.server.apps, name)
+		delete(m.server.machines, name)
+		delete(m.server.volumes, name)
+		delete(m.server.secrets, name)
+		delete(m.server.ips, name)
+		delete(m.server.certs, name)
+
+		return nil
+	})
 }
 
 func (m *FlapsClient) DeleteACMECertificate(ctx context.Context, appName, hostname string) error {
-	panic("TODO")
+	return m.deleteCertificate(ctx, "DeleteACMECertificate", appName, hostname)
 }
 
 func (m *FlapsClient) DeleteCertificate(ctx context.Context, appName, hostname string) error {
-	panic("TODO")
+	return m.deleteCertificate(ctx, "DeleteCertificate", appName, hostname)
 }
 
 func (m *FlapsClient) DeleteCustomCertificate(ctx context.Context, appName, hostname string) error {
-	panic("TODO")
+	return m.deleteCertificate(ctx, "DeleteCustomCertificate", appName, hostname)
 }
 
 func (m *FlapsClient) DeleteMetadata(ctx context.Context, appName, machineID, key string) error {
-	panic("TODO")
+	return m.machineOp(ctx, "DeleteMetadata", appName, machineID, func(machine *fly.Machine) error {
+		delete(machine.Config.Metadata, key)
+
+		return nil
+	})
 }
 
-func (m *FlapsClient) DeleteAppSecret(ctx context.Context, appName, name string) (*fly.DeleteAppSecretResp, error) {
-	panic("TODO")
+func (m *FlapsClient) DeleteAppSecret(ctx context.Context, appName, name string) (out *fly.DeleteAppSecretResp, err error) {
+	err = m.serverOp(ctx, "DeleteAppSecret", func() error {
+		secrets := m.server.appSecrets(appName)
+		if _, ok := secrets.values[name]; !ok {
+			return NewFlapsError(http.StatusNotFound, "secret not found: %q", name)
+		}
+
+		delete(secrets.values, name)
+		secrets.version++
+		out = &fly.DeleteAppSecretResp{Version: secrets.version}
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) DeleteIPAssignment(ctx context.Context, appName, ip string) (err error) {
-	panic("TODO")
+	return m.serverOp(ctx, "DeleteIPAssignment", func() error {
+		i := slices.IndexFunc(m.server.ips[appName], func(a flaps.IPAssignment) bool {
+			return a.IP == ip
+		})
+		if i < 0 {
+			return NewFlapsError(http.StatusNotFound, "ip assignment not found: %q", ip)
+		}
+		m.server.ips[appName] = slices.Delete(m.server.ips[appName], i, i+1)
+
+		return nil
+	})
 }
 
 func (m *FlapsClient) DeleteSecretKey(ctx context.Context, appName, name string) error {
-	panic("TODO")
+	return m.serverOp(ctx, "DeleteSecretKey", func() error {
+		secrets := m.server.appSecrets(appName)
+		if _, ok := secrets.keys[name]; !ok {
+			return NewFlapsError(http.StatusNotFound, "secret key not found: %q", name)
+		}
+
+		delete(secrets.keys, name)
+		secrets.version++
+
+		return nil
+	})
 }
 
-func (m *FlapsClient) DeleteVolume(ctx context.Context, appName, volumeId string) (*fly.Volume, error) {
-	panic("TODO")
+func (m *FlapsClient) DeleteVolume(ctx context.Context, appName, volumeId string) (out *fly.Volume, err error) {
+	err = m.volumeOp(ctx, "DeleteVolume", appName, volumeId, func(volume *fly.Volume) error {
+		if volume.IsAttached() {
+			return NewFlapsError(http.StatusPreconditionFailed, "volume %s is attached to machine %s", volume.ID, *volume.AttachedMachine)
+		}
+
+		m.server.volumes[appName] = slices.DeleteFunc(m.server.volumes[appName], func(v *fly.Volume) bool {
+			return v.ID == volume.ID
+		})
+		delete(m.server.snapshots, volume.ID)
+		volume.State = "pending_destroy"
+		out = helpers.Clone(volume)
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) Destroy(ctx context.Context, appName string, input fly.RemoveMachineInput, nonce string) (err error) {
-	panic("TODO")
+	return m.machineOp(ctx, "Destroy", appName, input.ID, func(machine *fly.Machine) error {
+		if err := m.server.checkLease(machine.ID, nonce); err != nil {
+			return err
+		}
+		if machine.State == fly.MachineStateStarted && !input.Kill {
+			return NewFlapsError(http.StatusPreconditionFailed, "unable to destroy machine %s, not currently stopped, suspended or failed", machine.ID)
+		}
+
+		m.server.detachVolumes(appName, machine.ID)
+		delete(m.server.leases, machine.ID)
+		m.server.transition(machine, "destroy", fly.MachineStateDestroyed)
+		m.server.machines[appName] = slices.DeleteFunc(m.server.machines[appName], func(other *fly.Machine) bool {
+			return other.ID == machine.ID
+		})
+
+		return nil
+	})
 }
 
-func (m *FlapsClient) Exec(ctx context.Context, appName, machineID string, in *fly.MachineExecRequest) (*fly.MachineExecResponse, error) {
-	panic("TODO")
+func (m *FlapsClient) Exec(ctx context.Context, appName, machineID string, in *fly.MachineExecRequest) (out *fly.MachineExecResponse, err error) {
+	err = m.machineOp(ctx, "Exec", appName, machineID, func(machine *fly.Machine) error {
+		if machine.State != fly.MachineStateStarted {
+			return NewFlapsError(http.StatusPreconditionFailed, "machine %s is not started", machine.ID)
+		}
+		out = &fly.MachineExecResponse{}
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) ExtendVolume(ctx context.Context, appName, volumeId string, size_gb int) (*fly.Volume, bool, error) {
-	panic("TODO")
+func (m *FlapsClient) ExtendVolume(ctx context.Context, appName, volumeId string, size_gb int) (out *fly.Volume, needsRestart bool, err error) {
+	err = m.volumeOp(ctx, "ExtendVolume", appName, volumeId, func(volume *fly.Volume) error {
+		if size_gb <= volume.SizeGb {
+			return NewFlapsError(http.StatusBadRequest, "volume %s can only be extended beyond its current size of %dGB", volume.ID, volume.SizeGb)
+		}
+
+		volume.SizeGb = size_gb
+		needsRestart = volume.IsAttached()
+		out = helpers.Clone(volume)
+
+		return nil
+	})
+
+	return out, needsRestart, err
 }
 
-func (m *FlapsClient) FindLease(ctx context.Context, appName, machineID string) (*fly.MachineLease, error) {
-	panic("TODO")
+func (m *FlapsClient) FindLease(ctx context.Context, appName, machineID string) (out *fly.MachineLease, err error) {
+	err = m.machineOp(ctx, "FindLease", appName, machineID, func(machine *fly.Machine) error {
+		l := m.server.activeLease(machineID)
+		if l == nil {
+			return NewFlapsError(http.StatusNotFound, "lease not found for machine %s", machineID)
+		}
+		out = l.machineLease()
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) GenerateSecretKey(ctx context.Context, appName, name string, typ string) (*fly.SetSecretKeyResp, error) {
-	panic("TODO")
+	value := make([]byte, 32)
+	if _, err := rand.Read(value); err != nil {
+		return nil, err
+	}
+
+	return m.setSecretKey(ctx, "GenerateSecretKey", appName, name, typ, value)
 }
 
-func (m *FlapsClient) Get(ctx context.Context, appName, machineID string) (*fly.Machine, error) {
-	return m.server.GetMachine(ctx, appName, machineID)
+func (m *FlapsClient) Get(ctx context.Context, appName, machineID string) (out *fly.Machine, err error) {
+	err = m.machineOp(ctx, "Get", appName, machineID, func(machine *fly.Machine) error {
+		out = helpers.Clone(machine)
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) GetApp(ctx context.Context, name string) (*flaps.App, error) {
-	m.server.mu.Lock()
-	defer m.server.mu.Unlock()
+func (m *FlapsClient) GetApp(ctx context.Context, name string) (out *flaps.App, err error) {
+	err = m.serverOp(ctx, "GetApp", func() error {
+		app := m.server.apps[name]
+		if app == nil {
+			return NewFlapsError(http.StatusNotFound, "app not found: %q", name)
+		}
+		out = flapsApp(app, len(m.server.machines[name]), len(m.server.volumes[name]))
 
-	app := m.server.apps[name]
-	if app == nil {
-		return nil, fmt.Errorf("app not found: %q", name)
-	}
+		return nil
+	})
 
-	return &flaps.App{
-		Name: app.Name,
-		Organization: flaps.AppOrganizationInfo{
-			Slug: app.Organization.Slug,
-		},
-	}, nil
+	return out, err
 }
 
-func (m *FlapsClient) GetAllVolumes(ctx context.Context, appName string) ([]fly.Volume, error) {
-	panic("TODO")
+func (m *FlapsClient) GetAllVolumes(ctx context.Context, appName string) (out []fly.Volume, err error) {
+	err = m.serverOp(ctx, "GetAllVolumes", func() error {
+		for _, volume := range m.server.volumes[appName] {
+			out = append(out, *helpers.Clone(volume))
+		}
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) GetCertificate(ctx context.Context, appName, hostname string) (*fly.CertificateDetailResponse, error) {
-	panic("TODO")
+func (m *FlapsClient) GetCertificate(ctx context.Context, appName, hostname string) (out *fly.CertificateDetailResponse, err error) {
+	err = m.serverOp(ctx, "GetCertificate", func() error {
+		cert := m.server.certs[appName][hostname]
+		if cert == nil {
+			return NewFlapsError(http.StatusNotFound, "certificate not found: %q", hostname)
+		}
+		out = cert.detail()
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) GetIPAssignments(ctx context.Context, appName string) (res *flaps.ListIPAssignmentsResponse, err error) {
-	return &flaps.ListIPAssignmentsResponse{}, nil
+	err = m.serverOp(ctx, "GetIPAssignments", func() error {
+		res = &flaps.ListIPAssignmentsResponse{IPs: slices.Clone(m.server.ips[appName])}
+
+		return nil
+	})
+
+	return res, err
 }
 
-func (m *FlapsClient) GetMany(ctx context.Context, appName string, machineIDs []string) ([]*fly.Machine, error) {
-	panic("TODO")
+func (m *FlapsClient) GetMany(ctx context.Context, appName string, machineIDs []string) (out []*fly.Machine, err error) {
+	err = m.serverOp(ctx, "GetMany", func() error {
+		for _, id := range machineIDs {
+			machine, err := m.server.machine(appName, id)
+			if err != nil {
+				return err
+			}
+			out = append(out, helpers.Clone(machine))
+		}
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) GetMetadata(ctx context.Context, appName, machineID string) (map[string]string, error) {
-	panic("TODO")
+func (m *FlapsClient) GetMetadata(ctx context.Context, appName, machineID string) (out map[string]string, err error) {
+	err = m.machineOp(ctx, "GetMetadata", appName, machineID, func(machine *fly.Machine) error {
+		out = make(map[string]string, len(machine.Config.Metadata))
+		for k, v := range machine.Config.Metadata {
+			out[k] = v
+		}
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) GetPlacements(ctx context.Context, req *flaps.GetPlacementsRequest) ([]flaps.RegionPlacement, error) {
-	panic("TODO")
+func (m *FlapsClient) GetPlacements(ctx context.Context, req *flaps.GetPlacementsRequest) (out []flaps.RegionPlacement, err error) {
+	err = m.serverOp(ctx, "GetPlacements", func() error {
+		for _, region := range strings.Split(req.Region, ",") {
+			if region = strings.TrimSpace(region); region == "" || region == "any" {
+				continue
+			}
+			out = append(out, flaps.RegionPlacement{
+				Region:      region,
+				Count:       int(req.Count),
+				Concurrency: 1,
+			})
+		}
+		if len(out) == 0 {
+			out = append(out, flaps.RegionPlacement{Region: nearestRegion, Count: int(req.Count), Concurrency: 1})
+		}
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) GetProcesses(ctx context.Context, appName, machineID string) (fly.MachinePsResponse, error) {
-	return nil, nil // TODO
+func (m *FlapsClient) GetProcesses(ctx context.Context, appName, machineID string) (out fly.MachinePsResponse, err error) {
+	err = m.machineOp(ctx, "GetProcesses", appName, machineID, func(machine *fly.Machine) error {
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) GetRegions(ctx context.Context) (*flaps.RegionData, error) {
-	panic("TODO")
+func (m *FlapsClient) GetRegions(ctx context.Context) (out *flaps.RegionData, err error) {
+	err = m.serverOp(ctx, "GetRegions", func() error {
+		out = &flaps.RegionData{
+			Regions: slices.Clone(regions),
+			Nearest: nearestRegion,
+		}
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) GetVolume(ctx context.Context, appName, volumeId string) (*fly.Volume, error) {
-	panic("TODO")
+func (m *FlapsClient) GetVolume(ctx context.Context, appName, volumeId string) (out *fly.Volume, err error) {
+	err = m.volumeOp(ctx, "GetVolume", appName, volumeId, func(volume *fly.Volume) error {
+		out = helpers.Clone(volume)
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) GetVolumeSnapshots(ctx context.Context, appName, volumeId string) ([]fly.VolumeSnapshot, error) {
-	panic("TODO")
+func (m *FlapsClient) GetVolumeSnapshots(ctx context.Context, appName, volumeId string) (out []fly.VolumeSnapshot, err error) {
+	err = m.volumeOp(ctx, "GetVolumeSnapshots", appName, volumeId, func(volume *fly.Volume) error {
+		out = slices.Clone(m.server.snapshots[volume.ID])
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) GetVolumes(ctx context.Context, appName string) ([]fly.Volume, error) {
-	panic("TODO")
+func (m *FlapsClient) GetVolumes(ctx context.Context, appName string) (out []fly.Volume, err error) {
+	err = m.serverOp(ctx, "GetVolumes", func() error {
+		for _, volume := range m.server.volumes[appName] {
+			out = append(out, *helpers.Clone(volume))
+		}
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) CreateCustomCertificate(ctx context.Context, appName string, req fly.ImportCertificateRequest) (*fly.CertificateDetailResponse, error) {
-	panic("TODO")
+	return m.createCertificate(ctx, "CreateCustomCertificate", appName, req.Hostname, true)
 }
 
 func (m *FlapsClient) Kill(ctx context.Context, appName, machineID string) (err error) {
-	panic("TODO")
+	return m.machineOp(ctx, "Kill", appName, machineID, func(machine *fly.Machine) error {
+		m.server.transition(machine, "exit", fly.MachineStateStopped)
+
+		return nil
+	})
 }
 
 func (m *FlapsClient) Launch(ctx context.Context, appName string, builder fly.LaunchMachineInput) (out *fly.Machine, err error) {
-	return m.server.Launch(ctx, appName, builder.Name, builder.Region, builder.Config)
-}
+	if err := m.server.fault(ctx, "Launch", ""); err != nil {
+		return nil, err
+	}
 
-func (m *FlapsClient) List(ctx context.Context, appName, state string) ([]*fly.Machine, error) {
-	panic("TODO")
+	return m.server.Launch(ctx, appName, builder)
 }
 
-func (m *FlapsClient) ListActive(ctx context.Context, appName string) ([]*fly.Machine, error) {
-	m.server.mu.Lock()
-	defer m.server.mu.Unlock()
+func (m *FlapsClient) List(ctx context.Context, appName, state string) (out []*fly.Machine, err error) {
+	err = m.serverOp(ctx, "List", func() error {
+		states := strings.Split(state, ",")
+		for _, machine := range m.server.machines[appName] {
+			if state == "" || slices.Contains(states, machine.State) {
+				out = append(out, helpers.Clone(machine))
+			}
+		}
+
+		return nil
+	})
+
+	return out, err
+}
 
-	var a []*fly.Machine
-	for _, machine := range m.server.machines[appName] {
-		if !machine.IsReleaseCommandMachine() && !machine.IsFlyAppsConsole() && machine.IsActive() {
-			a = append(a, machine)
+func (m *FlapsClient) ListActive(ctx context.Context, appName string) (a []*fly.Machine, err error) {
+	err = m.serverOp(ctx, "ListActive", func() error {
+		for _, machine := range m.server.machines[appName] {
+			if !machine.IsReleaseCommandMachine() && !machine.IsFlyAppsConsole() && machine.IsActive() {
+				a = append(a, helpers.Clone(machine))
+			}
 		}
-	}
 
-	return a, nil
+		return nil
+	})
+
+	return a, err
 }
 
-func (m *FlapsClient) ListApps(ctx context.Context, req flaps.ListAppsRequest) ([]flaps.App, error) {
-	panic("TODO")
+func (m *FlapsClient) ListApps(ctx context.Context, req flaps.ListAppsRequest) (out []flaps.App, err error) {
+	err = m.serverOp(ctx, "ListApps", func() error {
+		for _, app := range m.server.apps {
+			if req.OrgSlug == "" || app.Organization.Slug == req.OrgSlug {
+				out = append(out, *flapsApp(app, len(m.server.machines[app.Name]), len(m.server.volumes[app.Name])))
+			}
+		}
+		slices.SortFunc(out, func(a, b flaps.App) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) ListAppSecrets(ctx context.Context, appName string, version *uint64, showSecrets bool) ([]fly.AppSecret, error) {
-	panic("TODO")
+func (m *FlapsClient) ListAppSecrets(ctx context.Context, appName string, version *uint64, showSecrets bool) (out []fly.AppSecret, err error) {
+	err = m.serverOp(ctx, "ListAppSecrets", func() error {
+		if err := m.server.checkSecretsVersion(appName, version); err != nil {
+			return err
+		}
+
+		for name, secret := range m.server.appSecrets(appName).values {
+			out = append(out, secret.appSecret(name, showSecrets))
+		}
+		slices.SortFunc(out, func(a, b fly.AppSecret) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) ListCertificates(ctx context.Context, appName string, opts *flaps.ListCertificatesOpts) (*fly.ListCertificatesResponse, error) {
-	panic("TODO")
+func (m *FlapsClient) ListCertificates(ctx context.Context, appName string, opts *flaps.ListCertificatesOpts) (out *fly.ListCertificatesResponse, err error) {
+	err = m.serverOp(ctx, "ListCertificates", func() error {
+		out = &fly.ListCertificatesResponse{}
+		for _, cert := range m.server.certs[appName] {
+			if opts != nil && opts.Filter != "" && !strings.Contains(cert.hostname, opts.Filter) {
+				continue
+			}
+			detail := cert.detail()
+			out.Certificates = append(out.Certificates, fly.CertificateSummary{
+				Hostname:             cert.hostname,
+				Status:               detail.Status,
+				Configured:           detail.Configured,
+				AcmeRequested:        detail.AcmeRequested,
+				HasCustomCertificate: cert.custom,
+				HasFlyCertificate:    !cert.custom,
+				CreatedAt:            cert.createdAt,
+				UpdatedAt:            cert.createdAt,
+			})
+		}
+		slices.SortFunc(out.Certificates, func(a, b fly.CertificateSummary) int {
+			return strings.Compare(a.Hostname, b.Hostname)
+		})
+		out.TotalCount = len(out.Certificates)
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) ListFlyAppsMachines(ctx context.Context, appName string) (machines []*fly.Machine, releaseCmdMachine *fly.Machine, err error) {
-	m.server.mu.Lock()
-	defer m.server.mu.Unlock()
-
-	machines = make([]*fly.Machine, 0)
-	for _, machine := range m.server.machines[appName] {
-		if machine.IsFlyAppsPlatform() && machine.IsActive() && !machine.IsFlyAppsReleaseCommand() && !machine.IsFlyAppsConsole() {
-			machines = append(machines, machine)
-		} else if machine.IsFlyAppsReleaseCommand() {
-			releaseCmdMachine = machine
+	err = m.serverOp(ctx, "ListFlyAppsMachines", func() error {
+		machines = make([]*fly.Machine, 0)
+		for _, machine := range m.server.machines[appName] {
+			if machine.IsFlyAppsPlatform() && machine.IsActive() && !machine.IsFlyAppsReleaseCommand() && !machine.IsFlyAppsConsole() {
+				machines = append(machines, helpers.Clone(machine))
+			} else if machine.IsFlyAppsReleaseCommand() {
+				releaseCmdMachine = helpers.Clone(machine)
+			}
 		}
-	}
 
-	return machines, releaseCmdMachine, nil
+		return nil
+	})
+
+	return machines, releaseCmdMachine, err
 }
 
-func (m *FlapsClient) ListSecretKeys(ctx context.Context, appName string, version *uint64) ([]fly.SecretKey, error) {
-	panic("TODO")
+func (m *FlapsClient) ListSecretKeys(ctx context.Context, appName string, version *uint64) (out []fly.SecretKey, err error) {
+	err = m.serverOp(ctx, "ListSecretKeys", func() error {
+		if err := m.server.checkSecretsVersion(appName, version); err != nil {
+			return err
+		}
+
+		for _, key := range m.server.appSecrets(appName).keys {
+			out = append(out, key)
+		}
+		slices.SortFunc(out, func(a, b fly.SecretKey) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) NewRequest(ctx context.Context, method, path string, in any, headers map[string][]string) (*http.Request, error) {
-	panic("TODO")
+	return nil, fmt.Errorf("raw flaps requests are not supported by the in-memory server: %s %s", method, path)
 }
 
-func (m *FlapsClient) RefreshLease(ctx context.Context, appName, machineID string, ttl *int, nonce string) (*fly.MachineLease, error) {
-	panic("TODO")
+func (m *FlapsClient) RefreshLease(ctx context.Context, appName, machineID string, ttl *int, nonce string) (out *fly.MachineLease, err error) {
+	err = m.machineOp(ctx, "RefreshLease", appName, machineID, func(machine *fly.Machine) error {
+		l := m.server.leases[machineID]
+		switch {
+		case l == nil:
+			return NewFlapsError(http.StatusNotFound, "lease not found for machine %s", machineID)
+		case l.nonce != nonce:
+			return m.server.checkLease(machineID, nonce)
+		}
+
+		seconds := 30
+		if ttl != nil {
+			seconds = *ttl
+		}
+		l.expiresAt = m.server.now().Add(time.Duration(seconds) * time.Second)
+		out = l.machineLease()
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) ReleaseLease(ctx context.Context, appName, machineID, nonce string) error {
-	panic("TODO")
+	return m.machineOp(ctx, "ReleaseLease", appName, machineID, func(machine *fly.Machine) error {
+		l := m.server.activeLease(machineID)
+		switch {
+		case l == nil:
+			return NewFlapsError(http.StatusNotFound, "lease not found for machine %s", machineID)
+		case l.nonce != nonce:
+			return m.server.checkLease(machineID, nonce)
+		}
+		delete(m.server.leases, machineID)
+
+		return nil
+	})
 }
 
 func (m *FlapsClient) Restart(ctx context.Context, appName string, in fly.RestartMachineInput, nonce string) (err error) {
-	panic("TODO")
+	return m.machineOp(ctx, "Restart", appName, in.ID, func(machine *fly.Machine) error {
+		if err := m.server.checkLease(machine.ID, nonce); err != nil {
+			return err
+		}
+		if machine.State != fly.MachineStateStarted {
+			return NewFlapsError(http.StatusPreconditionFailed, "machine %s is not started", machine.ID)
+		}
+		m.server.transition(machine, "restart", fly.MachineStateStarted)
+
+		return nil
+	})
 }
 
 func (m *FlapsClient) SetMetadata(ctx context.Context, appName, machineID, key, value string) error {
-	panic("TODO")
+	return m.machineOp(ctx, "SetMetadata", appName, machineID, func(machine *fly.Machine) error {
+		if machine.Config.Metadata == nil {
+			machine.Config.Metadata = make(map[string]string)
+		}
+		machine.Config.Metadata[key] = value
+
+		return nil
+	})
 }
 
-func (m *FlapsClient) SetAppSecret(ctx context.Context, appName, name string, value string) (*fly.SetAppSecretResp, error) {
-	panic("TODO")
+func (m *FlapsClient) SetAppSecret(ctx context.Context, appName, name string, value string) (out *fly.SetAppSecretResp, err error) {
+	err = m.serverOp(ctx, "SetAppSecret", func() error {
+		secrets := m.server.appSecrets(appName)
+		secrets.set(name, value, m.server.now())
+		secrets.version++
+		out = &fly.SetAppSecretResp{
+			AppSecret: secrets.values[name].appSecret(name, false),
+			Version:   secrets.version,
+		}
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) SetSecretKey(ctx context.Context, appName, name string, typ string, value []byte) (*fly.SetSecretKeyResp, error) {
-	panic("TODO")
+	return m.setSecretKey(ctx, "SetSecretKey", appName, name, typ, value)
 }
 
 func (m *FlapsClient) Start(ctx context.Context, appName, machineID string, nonce string) (out *fly.MachineStartResponse, err error) {
-	panic("TODO")
+	err = m.machineOp(ctx, "Start", appName, machineID, func(machine *fly.Machine) error {
+		if err := m.server.checkLease(machine.ID, nonce); err != nil {
+			return err
+		}
+
+		out = &fly.MachineStartResponse{Status: "success", PreviousState: machine.State}
+		m.server.transition(machine, "start", fly.MachineStateStarted)
+
+		return nil
+	})
+
+	return out, err
 }
 
 func (m *FlapsClient) Stop(ctx context.Context, appName string, in fly.StopMachineInput, nonce string) (err error) {
-	panic("TODO")
+	return m.machineOp(ctx, "Stop", appName, in.ID, func(machine *fly.Machine) error {
+		if err := m.server.checkLease(machine.ID, nonce); err != nil {
+			return err
+		}
+		m.server.transition(machine, "stop", fly.MachineStateStopped)
+
+		return nil
+	})
 }
 
 func (m *FlapsClient) Suspend(ctx context.Context, appName, machineID, nonce string) error {
-	panic("TODO")
+	return m.machineOp(ctx, "Suspend", appName, machineID, func(machine *fly.Machine) error {
+		if err := m.server.checkLease(machine.ID, nonce); err != nil {
+			return err
+		}
+		if machine.State != fly.MachineStateStarted {
+			return NewFlapsError(http.StatusPreconditionFailed, "machine %s is not started", machine.ID)
+		}
+		m.server.transition(machine, "suspend", fly.MachineStateSuspended)
+
+		return nil
+	})
 }
 
 func (m *FlapsClient) Uncordon(ctx context.Context, appName, machineID string, nonce string) (err error) {
-	panic("TODO")
+	return m.machineOp(ctx, "Uncordon", appName, machineID, func(machine *fly.Machine) error {
+		if err := m.server.checkLease(machineID, nonce); err != nil {
+			return err
+		}
+		machine.Cordoned = false
+
+		return nil
+	})
 }
 
 func (m *FlapsClient) Update(ctx context.Context, appName string, builder fly.LaunchMachineInput, nonce string) (out *fly.Machine, err error) {
-	panic("TODO")
+	err = m.machineOp(ctx, "Update", appName, builder.ID, func(machine *fly.Machine) error {
+		if err := m.server.checkLease(machine.ID, nonce); err != nil {
+			return err
+		}
+		if err := m.server.checkSecretsVersion(appName, builder.MinSecretsVersion); err != nil {
+			return err
+		}
+
+		if builder.Region != "" && builder.Region != machine.Region {
+			return NewFlapsError(http.StatusBadRequest, "machine %s can't be moved from region %s to %s", machine.ID, machine.Region, builder.Region)
+		}
+		config := helpers.Clone(builder.Config)
+		if err := m.server.attachVolumes(appName, machine.ID, machine.Region, config); err != nil {
+			return err
+		}
+
+		machine.Config = config
+		m.server.newVersion(machine)
+		state := fly.MachineStateStarted
+		if builder.SkipLaunch {
+			state = fly.MachineStateStopped
+		}
+		m.server.transition(machine, "update", state)
+		out = helpers.Clone(machine)
+		out.LeaseNonce = nonce
+
+		return nil
+	})
+
+	return out, err
+}
+
+func (m *FlapsClient) UpdateAppSecrets(ctx context.Context, appName string, values map[string]*string) (out *fly.UpdateAppSecretsResp, err error) {
+	err = m.serverOp(ctx, "UpdateAppSecrets", func() error {
+		secrets := m.server.appSecrets(appName)
+		for name, value := range values {
+			if value == nil {
+				delete(secrets.values, name)
+			} else {
+				secrets.set(name, *value, m.server.now())
+			}
+		}
+		secrets.version++
+
+		out = &fly.UpdateAppSecretsResp{Version: secrets.version}
+		for name, secret := range secrets.values {
+			out.Secrets = append(out.Secrets, secret.appSecret(name, false))
+		}
+		slices.SortFunc(out.Secrets, func(a, b fly.AppSecret) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) UpdateAppSecrets(ctx context.Context, appName string, values map[string]*string) (*fly.UpdateAppSecretsResp, error) {
-	panic("TODO")
+func (m *FlapsClient) UpdateVolume(ctx context.Context, appName, volumeId string, req fly.UpdateVolumeRequest) (out *fly.Volume, err error) {
+	err = m.volumeOp(ctx, "UpdateVolume", appName, volumeId, func(volume *fly.Volume) error {
+		if req.SnapshotRetention != nil {
+			volume.SnapshotRetention = *req.SnapshotRetention
+		}
+		if req.AutoBackupEnabled != nil {
+			volume.AutoBackupEnabled = *req.AutoBackupEnabled
+		}
+		out = helpers.Clone(volume)
+
+		return nil
+	})
+
+	return out, err
 }
 
-func (m *FlapsClient) UpdateVolume(ctx context.Context, appName, volumeId string, req fly.UpdateVolumeRequest) (*fly.Volume, error) {
-	panic("TODO")
+// Wait returns once the machine settles, which is right away since machines here change
+// state as soon as they're asked to. flaps doesn't expose the states and timeout of its
+// options, so they're ignored: use WaitForState to wait for given states. Machines that
+// are gone return a not found error.
+func (m *FlapsClient) Wait(ctx context.Context, appName string, machineID string, _ ...flaps.WaitOption) error {
+	if err := m.server.fault(ctx, "Wait", machineID); err != nil {
+		return err
+	}
+
+	_, err := m.Get(ctx, appName, machineID)
+
+	return err
 }
 
-func (m *FlapsClient) Wait(ctx context.Context, appName string, machineID string, waitOpts ...flaps.WaitOption) (err error) {
-	return nil
+// WaitForState polls the machine until it reaches one of the states, and times out like
+// flaps does.
+func (m *FlapsClient) WaitForState(ctx context.Context, appName, machineID string, timeout time.Duration, states ...string) error {
+	if err := m.server.fault(ctx, "Wait", machineID); err != nil {
+		return err
+	}
+
+	return m.server.waitForState(ctx, m, appName, machineID, states, timeout)
 }
 
 func (m *FlapsClient) WaitForApp(ctx context.Context, name string) error {
-	panic("TODO")
+	return m.serverOp(ctx, "WaitForApp", func() error {
+		app := m.server.apps[name]
+		if app == nil {
+			return NewFlapsError(http.StatusNotFound, "app not found: %q", name)
+		}
+		app.Status = "deployed"
+
+		return nil
+	})
+}
+
+func (m *FlapsClient) createCertificate(ctx context.Context, method, appName, hostname string, custom bool) (out *fly.CertificateDetailResponse, err error) {
+	err = m.serverOp(ctx, method, func() error {
+		if _, ok := m.server.apps[appName]; !ok {
+			return NewFlapsError(http.StatusNotFound, "app not found: %q", appName)
+		}
+
+		if m.server.certs[appName] == nil {
+			m.server.certs[appName] = make(map[string]*certificate)
+		}
+		cert := &certificate{hostname: hostname, custom: custom, createdAt: m.server.now()}
+		m.server.certs[appName][hostname] = cert
+		out = cert.detail()
+
+		return nil
+	})
+
+	return out, err
+}
+
+func (m *FlapsClient) deleteCertificate(ctx context.Context, method, appName, hostname string) error {
+	return m.serverOp(ctx, method, func() error {
+		if m.server.certs[appName][hostname] == nil {
+			return NewFlapsError(http.StatusNotFound, "certificate not found: %q", hostname)
+		}
+		delete(m.server.certs[appName], hostname)
+
+		return nil
+	})
+}
+
+func (m *FlapsClient) setSecretKey(ctx context.Context, method, appName, name, typ string, value []byte) (out *fly.SetSecretKeyResp, err error) {
+	err = m.serverOp(ctx, method, func() error {
+		secrets := m.server.appSecrets(appName)
+		key := fly.SecretKey{Name: name, Type: typ}
+		secrets.keys[name] = key
+		secrets.version++
+		out = &fly.SetSecretKeyResp{SecretKey: key, Version: secrets.version}
+
+		return nil
+	})
+
+	return out, err
+}
+
+func flapsApp(app *fly.App, machines, volumes int) *flaps.App {
+	return &flaps.App{
+		ID:           app.ID,
+		Name:         app.Name,
+		Network:      app.Network,
+		Status:       app.Status,
+		MachineCount: int64(machines),
+		VolumeCount:  int64(volumes),
+		Organization: flaps.AppOrganizationInfo{
+			Name: app.Organization.Name,
+			Slug: app.Organization.Slug,
+		},
+	}
+}
+
+func (s *appSecrets) set(name, value string, now time.Time) {
+	if existing := s.values[name]; existing != nil {
+		existing.value = value
+		existing.updatedAt = now
+		return
+	}
+	s.values[name] = &secret{value: value, createdAt: now, updatedAt: now}
+}
+
+func (s *secret) appSecret(name string, showSecrets bool) fly.AppSecret {
+	digest := sha256.Sum256([]byte(s.value))
+	createdAt := s.createdAt.UTC().Format(time.RFC3339)
+	updatedAt := s.updatedAt.UTC().Format(time.RFC3339)
+
+	secret := fly.AppSecret{
+		Name:      name,
+		Digest:    hex.EncodeToString(digest[:8]),
+		CreatedAt: &createdAt,
+		UpdatedAt: &updatedAt,
+	}
+	if showSecrets {
+		secret.Value = &s.value
+	}
+
+	return secret
+}
+
+func (c *certificate) detail() *fly.CertificateDetailResponse {
+	source := "fly"
+	if c.custom {
+		source = "custom"
+	}
+
+	return &fly.CertificateDetailResponse{
+		Hostname:      c.hostname,
+		Configured:    true,
+		AcmeRequested: !c.custom,
+		Status:        "Ready",
+		Certificates: []fly.CertificateDetail{{
+			Source:    source,
+			Status:    "active",
+			CreatedAt: &c.createdAt,
+		}},
+	}
+}
+
+// nearestRegion is the region the in-memory server pretends to be closest to.
+const nearestRegion = "iad"
+
+var regions = []fly.Region{
+	{Code: "ams", Name: "Amsterdam, Netherlands"},
+	{Code: "cdg", Name: "Paris, France"},
+	{Code: "iad", Name: "Ashburn, Virginia (US)"},
+	{Code: "lax", Name: "Los Angeles, California (US)"},
+	{Code: "nrt", Name: "Tokyo, Japan"},
+	{Code: "ord", Name: "Chicago, Illinois (US)"},
+	{Code: "syd", Name: "Sydney, Australia"},
 }
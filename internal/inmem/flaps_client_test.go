@@ -0,0 +1,258 @@
+package inmem
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/flaps"
+)
+
+func newTestFlapsClient(t *testing.T) (*Server, *FlapsClient) {
+	t.Helper()
+
+	server := NewServer()
+	server.CreateApp(&fly.App{Name: "my-app", Organization: fly.Organization{Slug: "my-org"}})
+
+	return server, server.FlapsClient("my-app")
+}
+
+func requireStatus(t *testing.T, err error, status int) {
+	t.Helper()
+
+	var flapsErr *flaps.FlapsError
+	require.ErrorAs(t, err, &flapsErr)
+	assert.Equal(t, status, flapsErr.ResponseStatusCode)
+}
+
+func TestFlapsClientMachineLifecycle(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestFlapsClient(t)
+
+	machine, err := client.Launch(ctx, "my-app", fly.LaunchMachineInput{Region: "ord", Config: &fly.MachineConfig{Image: "image:1"}})
+	require.NoError(t, err)
+	assert.Equal(t, fly.MachineStateStarted, machine.State)
+	version := machine.InstanceID
+
+	require.NoError(t, client.Stop(ctx, "my-app", fly.StopMachineInput{ID: machine.ID}, ""))
+	started, err := client.Start(ctx, "my-app", machine.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, fly.MachineStateStopped, started.PreviousState)
+
+	require.NoError(t, client.Suspend(ctx, "my-app", machine.ID, ""))
+	require.NoError(t, client.Cordon(ctx, "my-app", machine.ID, ""))
+
+	updated, err := client.Update(ctx, "my-app", fly.LaunchMachineInput{ID: machine.ID, Config: &fly.MachineConfig{Image: "image:2"}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, fly.MachineStateStarted, updated.State)
+	assert.Equal(t, "image:2", updated.Config.Image)
+	assert.NotEqual(t, version, updated.InstanceID)
+	assert.True(t, updated.Cordoned)
+
+	got, err := client.Get(ctx, "my-app", machine.ID)
+	require.NoError(t, err)
+	var events []string
+	for _, event := range got.Events {
+		events = append(events, event.Type+":"+event.Status)
+	}
+	assert.Equal(t, []string{"update:started", "suspend:suspended", "start:started", "stop:stopped", "start:started", "launch:created"}, events)
+
+	err = client.Destroy(ctx, "my-app", fly.RemoveMachineInput{ID: machine.ID}, "")
+	requireStatus(t, err, http.StatusPreconditionFailed)
+	require.NoError(t, client.Destroy(ctx, "my-app", fly.RemoveMachineInput{ID: machine.ID, Kill: true}, ""))
+
+	_, err = client.Get(ctx, "my-app", machine.ID)
+	requireStatus(t, err, http.StatusNotFound)
+	requireStatus(t, client.Wait(ctx, "my-app", machine.ID), http.StatusNotFound)
+}
+
+func TestFlapsClientLeases(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestFlapsClient(t)
+
+	now := time.Now()
+	server.SetClock(func() time.Time { return now })
+
+	machine, err := client.Launch(ctx, "my-app", fly.LaunchMachineInput{Region: "ord", Config: &fly.MachineConfig{}, LeaseTTL: 10})
+	require.NoError(t, err)
+	require.NotEmpty(t, machine.LeaseNonce)
+
+	_, err = client.AcquireLease(ctx, "my-app", machine.ID, new(30))
+	requireStatus(t, err, http.StatusConflict)
+	requireStatus(t, client.Stop(ctx, "my-app", fly.StopMachineInput{ID: machine.ID}, "other"), http.StatusConflict)
+	require.NoError(t, client.Stop(ctx, "my-app", fly.StopMachineInput{ID: machine.ID}, machine.LeaseNonce))
+
+	lease, err := client.FindLease(ctx, "my-app", machine.ID)
+	require.NoError(t, err)
+	assert.Equal(t, machine.LeaseNonce, lease.Data.Nonce)
+
+	// Refreshing keeps the nonce and pushes back the expiry
+	now = now.Add(8 * time.Second)
+	lease, err = client.RefreshLease(ctx, "my-app", machine.ID, new(10), machine.LeaseNonce)
+	require.NoError(t, err)
+	assert.Equal(t, machine.LeaseNonce, lease.Data.Nonce)
+	assert.Equal(t, now.Add(10*time.Second).Unix(), lease.Data.ExpiresAt)
+
+	// Expired leases don't get in the way anymore
+	now = now.Add(11 * time.Second)
+	_, err = client.FindLease(ctx, "my-app", machine.ID)
+	requireStatus(t, err, http.StatusNotFound)
+
+	lease, err = client.AcquireLease(ctx, "my-app", machine.ID, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, machine.LeaseNonce, lease.Data.Nonce)
+	requireStatus(t, client.ReleaseLease(ctx, "my-app", machine.ID, machine.LeaseNonce), http.StatusConflict)
+	require.NoError(t, client.ReleaseLease(ctx, "my-app", machine.ID, lease.Data.Nonce))
+	require.NoError(t, client.Stop(ctx, "my-app", fly.StopMachineInput{ID: machine.ID}, ""))
+}
+
+func TestFlapsClientVolumes(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestFlapsClient(t)
+
+	var zones []string
+	for range zonesPerRegion {
+		volume, err := client.CreateVolume(ctx, "my-app", fly.CreateVolumeRequest{Name: "data", Region: "ord"})
+		require.NoError(t, err)
+		assert.NotContains(t, zones, volume.Zone)
+		zones = append(zones, volume.Zone)
+	}
+
+	_, err := client.CreateVolume(ctx, "my-app", fly.CreateVolumeRequest{Name: "data", Region: "ord"})
+	requireStatus(t, err, http.StatusPreconditionFailed)
+
+	volume, err := client.CreateVolume(ctx, "my-app", fly.CreateVolumeRequest{Name: "data", Region: "ord", RequireUniqueZone: new(false)})
+	require.NoError(t, err)
+	assert.Contains(t, zones, volume.Zone)
+
+	machine, err := client.Launch(ctx, "my-app", fly.LaunchMachineInput{
+		Region: "ord",
+		Config: &fly.MachineConfig{Mounts: []fly.MachineMount{{Volume: volume.ID, Path: "/data"}}},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Launch(ctx, "my-app", fly.LaunchMachineInput{
+		Region: "ord",
+		Config: &fly.MachineConfig{Mounts: []fly.MachineMount{{Volume: volume.ID, Path: "/data"}}},
+	})
+	requireStatus(t, err, http.StatusPreconditionFailed)
+
+	_, err = client.DeleteVolume(ctx, "my-app", volume.ID)
+	requireStatus(t, err, http.StatusPreconditionFailed)
+
+	extended, needsRestart, err := client.ExtendVolume(ctx, "my-app", volume.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10, extended.SizeGb)
+	assert.True(t, needsRestart)
+
+	require.NoError(t, client.CreateVolumeSnapshot(ctx, "my-app", volume.ID))
+	snapshots, err := client.GetVolumeSnapshots(ctx, "my-app", volume.ID)
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+
+	require.NoError(t, client.Destroy(ctx, "my-app", fly.RemoveMachineInput{ID: machine.ID, Kill: true}, ""))
+	_, err = client.DeleteVolume(ctx, "my-app", volume.ID)
+	require.NoError(t, err)
+
+	volumes, err := client.GetVolumes(ctx, "my-app")
+	require.NoError(t, err)
+	assert.Len(t, volumes, zonesPerRegion)
+}
+
+func TestFlapsClientSecrets(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestFlapsClient(t)
+
+	set, err := client.SetAppSecret(ctx, "my-app", "A", "1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), set.Version)
+
+	updated, err := client.UpdateAppSecrets(ctx, "my-app", map[string]*string{"A": nil, "B": new("2")})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), updated.Version)
+
+	secrets, err := client.ListAppSecrets(ctx, "my-app", &updated.Version, true)
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "B", secrets[0].Name)
+	assert.Equal(t, "2", *secrets[0].Value)
+
+	_, err = client.ListAppSecrets(ctx, "my-app", new(uint64(3)), false)
+	requireStatus(t, err, http.StatusBadRequest)
+
+	// Machines can't be launched with secrets that don't exist yet
+	_, err = client.Launch(ctx, "my-app", fly.LaunchMachineInput{Region: "ord", Config: &fly.MachineConfig{}, MinSecretsVersion: new(uint64(3))})
+	requireStatus(t, err, http.StatusBadRequest)
+
+	key, err := client.GenerateSecretKey(ctx, "my-app", "key", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), key.Version)
+	_, err = client.Launch(ctx, "my-app", fly.LaunchMachineInput{Region: "ord", Config: &fly.MachineConfig{}, MinSecretsVersion: new(uint64(3))})
+	require.NoError(t, err)
+}
+
+func TestFlapsClientFaults(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestFlapsClient(t)
+
+	m1, err := client.Launch(ctx, "my-app", fly.LaunchMachineInput{Region: "ord", Config: &fly.MachineConfig{}})
+	require.NoError(t, err)
+	m2, err := client.Launch(ctx, "my-app", fly.LaunchMachineInput{Region: "ord", Config: &fly.MachineConfig{}})
+	require.NoError(t, err)
+
+	server.InjectFault(Fault{
+		Method:    "Update",
+		MachineID: m2.ID,
+		After:     1,
+		Times:     1,
+		Err:       NewFlapsError(http.StatusServiceUnavailable, "flyd unavailable"),
+	})
+
+	update := func(id string) error {
+		_, err := client.Update(ctx, "my-app", fly.LaunchMachineInput{ID: id, Config: &fly.MachineConfig{}}, "")
+		return err
+	}
+	require.NoError(t, update(m1.ID))
+	require.NoError(t, update(m2.ID))
+	requireStatus(t, update(m2.ID), http.StatusServiceUnavailable)
+	require.NoError(t, update(m2.ID))
+
+	server.InjectFault(Fault{Method: "Wait", Delay: time.Minute})
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.Wait(waitCtx, "my-app", m1.ID), context.DeadlineExceeded)
+
+	server.ClearFaults()
+	require.NoError(t, client.Wait(ctx, "my-app", m1.ID))
+}
+
+func TestFlapsClientWait(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestFlapsClient(t)
+
+	machine, err := client.Launch(ctx, "my-app", fly.LaunchMachineInput{Region: "ord", Config: &fly.MachineConfig{}})
+	require.NoError(t, err)
+	assert.Equal(t, fly.HostStatusOk, machine.HostStatus)
+
+	require.NoError(t, client.Wait(ctx, "my-app", machine.ID))
+	require.NoError(t, client.Stop(ctx, "my-app", fly.StopMachineInput{ID: machine.ID}, ""))
+	// the options of flaps are ignored, a settled machine is what's waited for
+	require.NoError(t, client.Wait(ctx, "my-app", machine.ID, flaps.WithWaitStates(fly.MachineStateStarted)))
+	require.NoError(t, client.WaitForState(ctx, "my-app", machine.ID, time.Second, fly.MachineStateStopped))
+
+	start := time.Now()
+	err = client.WaitForState(ctx, "my-app", machine.ID, time.Second, fly.MachineStateStarted)
+	requireStatus(t, err, http.StatusRequestTimeout)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+
+	// a machine started while waiting ends the wait
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = client.Start(ctx, "my-app", machine.ID, "")
+	}()
+	require.NoError(t, client.WaitForState(ctx, "my-app", machine.ID, 10*time.Second, fly.MachineStateStarted))
+}
@@ -0,0 +1,359 @@
+package inmem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/flaps"
+)
+
+// FlapsHandler serves the Machines API paths used by the flaps client, so that the
+// fly binary can run against the server by pointing FLY_FLAPS_BASE_URL at it.
+func (s *Server) FlapsHandler() http.Handler {
+	var (
+		c   = s.FlapsClient("")
+		mux = http.NewServeMux()
+	)
+
+	// Apps
+	mux.HandleFunc("POST /v1/apps", func(w http.ResponseWriter, r *http.Request) {
+		var in flaps.CreateAppRequest
+		if decode(w, r, &in) {
+			reply(w)(c.CreateApp(r.Context(), in))
+		}
+	})
+	mux.HandleFunc("GET /v1/apps", func(w http.ResponseWriter, r *http.Request) {
+		apps, err := c.ListApps(r.Context(), flaps.ListAppsRequest{OrgSlug: r.URL.Query().Get("org_slug")})
+		reply(w)(map[string][]flaps.App{"apps": apps}, err)
+	})
+	mux.HandleFunc("GET /v1/apps/{app}", func(w http.ResponseWriter, r *http.Request) {
+		reply(w)(c.GetApp(r.Context(), r.PathValue("app")))
+	})
+	mux.HandleFunc("DELETE /v1/apps/{app}", func(w http.ResponseWriter, r *http.Request) {
+		replyErr(w, c.DeleteApp(r.Context(), r.PathValue("app")))
+	})
+
+	// Machines
+	mux.HandleFunc("POST /v1/apps/{app}/machines", func(w http.ResponseWriter, r *http.Request) {
+		var in fly.LaunchMachineInput
+		if decode(w, r, &in) {
+			reply(w)(c.Launch(r.Context(), r.PathValue("app"), in))
+		}
+	})
+	mux.HandleFunc("GET /v1/apps/{app}/machines", func(w http.ResponseWriter, r *http.Request) {
+		state := r.URL.Query().Get("state")
+		if state == "" && !r.URL.Query().Has("state") {
+			// The flaps client sends the state as the raw query string
+			state = r.URL.RawQuery
+		}
+		machines, err := c.List(r.Context(), r.PathValue("app"), state)
+		if machines == nil {
+			machines = []*fly.Machine{}
+		}
+		reply(w)(machines, err)
+	})
+	mux.HandleFunc("GET /v1/apps/{app}/machines/{id}", func(w http.ResponseWriter, r *http.Request) {
+		reply(w)(c.Get(r.Context(), r.PathValue("app"), r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var in fly.LaunchMachineInput
+		if decode(w, r, &in) {
+			in.ID = r.PathValue("id")
+			reply(w)(c.Update(r.Context(), r.PathValue("app"), in, nonce(r)))
+		}
+	})
+	mux.HandleFunc("DELETE /v1/apps/{app}/machines/{id}", func(w http.ResponseWriter, r *http.Request) {
+		kill, _ := strconv.ParseBool(r.URL.Query().Get("kill"))
+		replyErr(w, c.Destroy(r.Context(), r.PathValue("app"), fly.RemoveMachineInput{ID: r.PathValue("id"), Kill: kill}, nonce(r)))
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/start", func(w http.ResponseWriter, r *http.Request) {
+		reply(w)(c.Start(r.Context(), r.PathValue("app"), r.PathValue("id"), nonce(r)))
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/stop", func(w http.ResponseWriter, r *http.Request) {
+		var in fly.StopMachineInput
+		if decode(w, r, &in) {
+			in.ID = r.PathValue("id")
+			replyErr(w, c.Stop(r.Context(), r.PathValue("app"), in, nonce(r)))
+		}
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/restart", func(w http.ResponseWriter, r *http.Request) {
+		in := fly.RestartMachineInput{ID: r.PathValue("id"), Signal: r.URL.Query().Get("signal")}
+		in.ForceStop, _ = strconv.ParseBool(r.URL.Query().Get("force_stop"))
+		in.Timeout, _ = time.ParseDuration(r.URL.Query().Get("timeout"))
+		replyErr(w, c.Restart(r.Context(), r.PathValue("app"), in, nonce(r)))
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/suspend", func(w http.ResponseWriter, r *http.Request) {
+		replyErr(w, c.Suspend(r.Context(), r.PathValue("app"), r.PathValue("id"), nonce(r)))
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/signal", func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Signal int `json:"signal"`
+		}
+		if decode(w, r, &in) {
+			if in.Signal != 9 {
+				replyErr(w, nil)
+				return
+			}
+			replyErr(w, c.Kill(r.Context(), r.PathValue("app"), r.PathValue("id")))
+		}
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/cordon", func(w http.ResponseWriter, r *http.Request) {
+		replyErr(w, c.Cordon(r.Context(), r.PathValue("app"), r.PathValue("id"), nonce(r)))
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/uncordon", func(w http.ResponseWriter, r *http.Request) {
+		replyErr(w, c.Uncordon(r.Context(), r.PathValue("app"), r.PathValue("id"), nonce(r)))
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/exec", func(w http.ResponseWriter, r *http.Request) {
+		var in fly.MachineExecRequest
+		if decode(w, r, &in) {
+			reply(w)(c.Exec(r.Context(), r.PathValue("app"), r.PathValue("id"), &in))
+		}
+	})
+	mux.HandleFunc("GET /v1/apps/{app}/machines/{id}/ps", func(w http.ResponseWriter, r *http.Request) {
+		processes, err := c.GetProcesses(r.Context(), r.PathValue("app"), r.PathValue("id"))
+		if processes == nil {
+			processes = fly.MachinePsResponse{}
+		}
+		reply(w)(processes, err)
+	})
+	mux.HandleFunc("GET /v1/apps/{app}/machines/{id}/wait", func(w http.ResponseWriter, r *http.Request) {
+		states := r.URL.Query()["state"]
+		if len(states) == 0 {
+			states = []string{fly.MachineStateStarted}
+		}
+		timeout := 60 * time.Second
+		if seconds, err := strconv.Atoi(r.URL.Query().Get("timeout")); err == nil && seconds > 0 {
+			timeout = time.Duration(seconds) * time.Second
+		}
+		replyErr(w, s.waitForState(r.Context(), c, r.PathValue("app"), r.PathValue("id"), states, timeout))
+	})
+	mux.HandleFunc("GET /v1/apps/{app}/machines/{id}/lease", func(w http.ResponseWriter, r *http.Request) {
+		reply(w)(c.FindLease(r.Context(), r.PathValue("app"), r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/lease", func(w http.ResponseWriter, r *http.Request) {
+		var ttl *int
+		if seconds, err := strconv.Atoi(r.URL.Query().Get("ttl")); err == nil {
+			ttl = &seconds
+		}
+		if n := nonce(r); n != "" {
+			reply(w)(c.RefreshLease(r.Context(), r.PathValue("app"), r.PathValue("id"), ttl, n))
+			return
+		}
+		reply(w)(c.AcquireLease(r.Context(), r.PathValue("app"), r.PathValue("id"), ttl))
+	})
+	mux.HandleFunc("DELETE /v1/apps/{app}/machines/{id}/lease", func(w http.ResponseWriter, r *http.Request) {
+		replyErr(w, c.ReleaseLease(r.Context(), r.PathValue("app"), r.PathValue("id"), nonce(r)))
+	})
+	mux.HandleFunc("GET /v1/apps/{app}/machines/{id}/metadata", func(w http.ResponseWriter, r *http.Request) {
+		reply(w)(c.GetMetadata(r.Context(), r.PathValue("app"), r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/machines/{id}/metadata/{key}", func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Value string `json:"value"`
+		}
+		if decode(w, r, &in) {
+			replyErr(w, c.SetMetadata(r.Context(), r.PathValue("app"), r.PathValue("id"), r.PathValue("key"), in.Value))
+		}
+	})
+	mux.HandleFunc("DELETE /v1/apps/{app}/machines/{id}/metadata/{key}", func(w http.ResponseWriter, r *http.Request) {
+		replyErr(w, c.DeleteMetadata(r.Context(), r.PathValue("app"), r.PathValue("id"), r.PathValue("key")))
+	})
+
+	// Volumes
+	mux.HandleFunc("GET /v1/apps/{app}/volumes", func(w http.ResponseWriter, r *http.Request) {
+		volumes, err := c.GetAllVolumes(r.Context(), r.PathValue("app"))
+		if volumes == nil {
+			volumes = []fly.Volume{}
+		}
+		reply(w)(volumes, err)
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/volumes", func(w http.ResponseWriter, r *http.Request) {
+		var in fly.CreateVolumeRequest
+		if decode(w, r, &in) {
+			reply(w)(c.CreateVolume(r.Context(), r.PathValue("app"), in))
+		}
+	})
+	mux.HandleFunc("GET /v1/apps/{app}/volumes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		reply(w)(c.GetVolume(r.Context(), r.PathValue("app"), r.PathValue("id")))
+	})
+	mux.HandleFunc("PUT /v1/apps/{app}/volumes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var in fly.UpdateVolumeRequest
+		if decode(w, r, &in) {
+			reply(w)(c.UpdateVolume(r.Context(), r.PathValue("app"), r.PathValue("id"), in))
+		}
+	})
+	mux.HandleFunc("DELETE /v1/apps/{app}/volumes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		reply(w)(c.DeleteVolume(r.Context(), r.PathValue("app"), r.PathValue("id")))
+	})
+	mux.HandleFunc("PUT /v1/apps/{app}/volumes/{id}/extend", func(w http.ResponseWriter, r *http.Request) {
+		var in flaps.ExtendVolumeRequest
+		if decode(w, r, &in) {
+			volume, needsRestart, err := c.ExtendVolume(r.Context(), r.PathValue("app"), r.PathValue("id"), in.SizeGB)
+			reply(w)(&flaps.ExtendVolumeResponse{Volume: volume, NeedsRestart: needsRestart}, err)
+		}
+	})
+	mux.HandleFunc("GET /v1/apps/{app}/volumes/{id}/snapshots", func(w http.ResponseWriter, r *http.Request) {
+		snapshots, err := c.GetVolumeSnapshots(r.Context(), r.PathValue("app"), r.PathValue("id"))
+		if snapshots == nil {
+			snapshots = []fly.VolumeSnapshot{}
+		}
+		reply(w)(snapshots, err)
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/volumes/{id}/snapshots", func(w http.ResponseWriter, r *http.Request) {
+		replyErr(w, c.CreateVolumeSnapshot(r.Context(), r.PathValue("app"), r.PathValue("id")))
+	})
+
+	// Secrets
+	mux.HandleFunc("GET /v1/apps/{app}/secrets", func(w http.ResponseWriter, r *http.Request) {
+		showSecrets, _ := strconv.ParseBool(r.URL.Query().Get("show_secrets"))
+		secrets, err := c.ListAppSecrets(r.Context(), r.PathValue("app"), version(r), showSecrets)
+		reply(w)(&fly.ListAppSecretsResp{Secrets: secrets}, err)
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/secrets", func(w http.ResponseWriter, r *http.Request) {
+		var in fly.UpdateAppSecretsRequest
+		if decode(w, r, &in) {
+			reply(w)(c.UpdateAppSecrets(r.Context(), r.PathValue("app"), in.Values))
+		}
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/secrets/{name}", func(w http.ResponseWriter, r *http.Request) {
+		var in fly.SetAppSecretRequest
+		if decode(w, r, &in) {
+			reply(w)(c.SetAppSecret(r.Context(), r.PathValue("app"), r.PathValue("name"), in.Value))
+		}
+	})
+	mux.HandleFunc("DELETE /v1/apps/{app}/secrets/{name}", func(w http.ResponseWriter, r *http.Request) {
+		reply(w)(c.DeleteAppSecret(r.Context(), r.PathValue("app"), r.PathValue("name")))
+	})
+	mux.HandleFunc("GET /v1/apps/{app}/secretkeys", func(w http.ResponseWriter, r *http.Request) {
+		keys, err := c.ListSecretKeys(r.Context(), r.PathValue("app"), version(r))
+		reply(w)(&fly.ListSecretKeysResp{Secrets: keys}, err)
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/secretkeys/{name}", func(w http.ResponseWriter, r *http.Request) {
+		var in fly.SetSecretKeyRequest
+		if decode(w, r, &in) {
+			reply(w)(c.SetSecretKey(r.Context(), r.PathValue("app"), r.PathValue("name"), in.Type, in.Value))
+		}
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/secretkeys/{name}/generate", func(w http.ResponseWriter, r *http.Request) {
+		var in fly.SetSecretKeyRequest
+		if decode(w, r, &in) {
+			reply(w)(c.GenerateSecretKey(r.Context(), r.PathValue("app"), r.PathValue("name"), in.Type))
+		}
+	})
+	mux.HandleFunc("DELETE /v1/apps/{app}/secretkeys/{name}", func(w http.ResponseWriter, r *http.Request) {
+		replyErr(w, c.DeleteSecretKey(r.Context(), r.PathValue("app"), r.PathValue("name")))
+	})
+
+	// IP assignments
+	mux.HandleFunc("GET /v1/apps/{app}/ip_assignments", func(w http.ResponseWriter, r *http.Request) {
+		reply(w)(c.GetIPAssignments(r.Context(), r.PathValue("app")))
+	})
+	mux.HandleFunc("POST /v1/apps/{app}/ip_assignments", func(w http.ResponseWriter, r *http.Request) {
+		var in flaps.AssignIPRequest
+		if decode(w, r, &in) {
+			reply(w)(c.AssignIP(r.Context(), r.PathValue("app"), in))
+		}
+	})
+	mux.HandleFunc("DELETE /v1/apps/{app}/ip_assignments/{ip}", func(w http.ResponseWriter, r *http.Request) {
+		replyErr(w, c.DeleteIPAssignment(r.Context(), r.PathValue("app"), r.PathValue("ip")))
+	})
+
+	// Platform
+	mux.HandleFunc("GET /v1/platform/regions", func(w http.ResponseWriter, r *http.Request) {
+		reply(w)(c.GetRegions(r.Context()))
+	})
+	mux.HandleFunc("POST /v1/platform/placements", func(w http.ResponseWriter, r *http.Request) {
+		var in flaps.GetPlacementsRequest
+		if decode(w, r, &in) {
+			placements, err := c.GetPlacements(r.Context(), &in)
+			reply(w)(&flaps.GetPlacementsResponse{Regions: placements}, err)
+		}
+	})
+
+	return mux
+}
+
+// waitForState polls a machine until it reaches one of the states. Nothing changes a
+// machine's state but other requests, so the wait may well time out like it does on flaps.
+func (s *Server) waitForState(ctx context.Context, c *FlapsClient, appName, machineID string, states []string, timeout time.Duration) error {
+	deadline := time.After(timeout)
+
+	for {
+		machine, err := c.Get(ctx, appName, machineID)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(states, machine.State) {
+			return nil
+		}
+
+		select {
+		case <-deadline:
+			return NewFlapsError(http.StatusRequestTimeout, "machine %s did not reach %v in %s", machine.ID, states, timeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func nonce(r *http.Request) string {
+	return r.Header.Get(flaps.NonceHeader)
+}
+
+func version(r *http.Request) *uint64 {
+	v, err := strconv.ParseUint(r.URL.Query().Get("version"), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &v
+}
+
+// decode reads a JSON request body, replying with a bad request when it can't.
+func decode(w http.ResponseWriter, r *http.Request, in any) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+	if err := json.NewDecoder(r.Body).Decode(in); err != nil {
+		replyErr(w, NewFlapsError(http.StatusBadRequest, "invalid request body: %v", err))
+		return false
+	}
+
+	return true
+}
+
+// reply returns a function writing the result of a FlapsClient call as a response.
+func reply(w http.ResponseWriter) func(out any, err error) {
+	return func(out any, err error) {
+		if err != nil {
+			replyErr(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
+
+func replyErr(w http.ResponseWriter, err error) {
+	if err == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var flapsErr *flaps.FlapsError
+	if !errors.As(err, &flapsErr) {
+		flapsErr = NewFlapsError(http.StatusInternalServerError, "%v", err).(*flaps.FlapsError)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(flapsErr.ResponseStatusCode)
+	_, _ = w.Write(flapsErr.ResponseBody)
+}
@@ -0,0 +1,66 @@
+package inmem
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/fly-go/tokens"
+)
+
+func TestFlapsHandler(t *testing.T) {
+	ctx := context.Background()
+	server, _ := newTestFlapsClient(t)
+
+	srv := httptest.NewServer(server.FlapsHandler())
+	defer srv.Close()
+	t.Setenv("FLY_FLAPS_BASE_URL", srv.URL)
+
+	client, err := flaps.NewWithOptions(ctx, flaps.NewClientOpts{Tokens: tokens.Parse("test-token")})
+	require.NoError(t, err)
+
+	app, err := client.GetApp(ctx, "my-app")
+	require.NoError(t, err)
+	assert.Equal(t, "my-org", app.Organization.Slug)
+
+	machine, err := client.Launch(ctx, "my-app", fly.LaunchMachineInput{Region: "ord", Config: &fly.MachineConfig{Image: "image:1"}, LeaseTTL: 30})
+	require.NoError(t, err)
+	require.NoError(t, client.Wait(ctx, "my-app", machine.ID, flaps.WithWaitStates(fly.MachineStateStarted)))
+
+	_, err = client.Update(ctx, "my-app", fly.LaunchMachineInput{ID: machine.ID, Config: &fly.MachineConfig{Image: "image:2"}}, "other")
+	requireStatus(t, err, http.StatusConflict)
+
+	updated, err := client.Update(ctx, "my-app", fly.LaunchMachineInput{ID: machine.ID, Config: &fly.MachineConfig{Image: "image:2"}}, machine.LeaseNonce)
+	require.NoError(t, err)
+	assert.Equal(t, "image:2", updated.Config.Image)
+
+	require.NoError(t, client.Stop(ctx, "my-app", fly.StopMachineInput{ID: machine.ID}, machine.LeaseNonce))
+	require.NoError(t, client.Wait(ctx, "my-app", machine.ID, flaps.WithWaitStates(fly.MachineStateStopped)))
+	err = client.Wait(ctx, "my-app", machine.ID, flaps.WithWaitStates(fly.MachineStateStarted), flaps.WithWaitTimeout(time.Second))
+	requireStatus(t, err, http.StatusRequestTimeout)
+	require.NoError(t, client.ReleaseLease(ctx, "my-app", machine.ID, machine.LeaseNonce))
+
+	volume, err := client.CreateVolume(ctx, "my-app", fly.CreateVolumeRequest{Name: "data", Region: "ord", SizeGb: new(3)})
+	require.NoError(t, err)
+	volumes, err := client.GetVolumes(ctx, "my-app")
+	require.NoError(t, err)
+	require.Len(t, volumes, 1)
+	assert.Equal(t, volume.Zone, volumes[0].Zone)
+
+	secrets, err := client.UpdateAppSecrets(ctx, "my-app", map[string]*string{"A": new("1")})
+	require.NoError(t, err)
+	listed, err := client.ListAppSecrets(ctx, "my-app", &secrets.Version, true)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "1", *listed[0].Value)
+
+	require.NoError(t, client.Destroy(ctx, "my-app", fly.RemoveMachineInput{ID: machine.ID}, ""))
+	_, err = client.Get(ctx, "my-app", machine.ID)
+	requireStatus(t, err, http.StatusNotFound)
+}
@@ -3,10 +3,12 @@ package inmem
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
 	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/flaps"
 	"github.com/superfly/flyctl/helpers"
 )
 
@@ -24,18 +26,48 @@ type Server struct {
 
 	releaseSeq int                 // release id generation
 	releases   map[string]*Release // releases by id
+
+	versionSeq int               // machine version & instance id generation
+	leaseSeq   int               // lease nonce generation
+	leases     map[string]*lease // leases by machine id
+
+	volumeSeq int                                // volume & snapshot id generation
+	volumes   map[string][]*fly.Volume           // volumes by app name
+	snapshots map[string][]fly.VolumeSnapshot    // snapshots by volume id
+	secrets   map[string]*appSecrets             // secrets & secret keys by app name
+	ipSeq     int                                // ip address generation
+	ips       map[string][]flaps.IPAssignment    // ip assignments by app name
+	certs     map[string]map[string]*certificate // certificates by app name & hostname
+
+	faults []*Fault // faults injected into flaps calls
+
+	now func() time.Time
 }
 
 func NewServer() *Server {
 	return &Server{
-		apps:     make(map[string]*fly.App),
-		machines: make(map[string][]*fly.Machine),
-		images:   make(map[imageKey]*fly.Image),
-		builds:   make(map[string]*Build),
-		releases: make(map[string]*Release),
+		apps:      make(map[string]*fly.App),
+		machines:  make(map[string][]*fly.Machine),
+		images:    make(map[imageKey]*fly.Image),
+		builds:    make(map[string]*Build),
+		releases:  make(map[string]*Release),
+		leases:    make(map[string]*lease),
+		volumes:   make(map[string][]*fly.Volume),
+		snapshots: make(map[string][]fly.VolumeSnapshot),
+		secrets:   make(map[string]*appSecrets),
+		ips:       make(map[string][]flaps.IPAssignment),
+		certs:     make(map[string]map[string]*certificate),
+		now:       time.Now,
 	}
 }
 
+// SetClock replaces the clock used for lease expiry and timestamps.
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
 func (s *Server) Client() *Client {
 	return NewClient(s)
 }
@@ -144,36 +176,92 @@ func (s *Server) CreateImage(ctx context.Context, appName, imageRef string, imag
 	return nil
 }
 
-func (s *Server) Launch(ctx context.Context, appName, name, region string, config *fly.MachineConfig) (*fly.Machine, error) {
+func (s *Server) Launch(ctx context.Context, appName string, input fly.LaunchMachineInput) (*fly.Machine, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if _, ok := s.apps[appName]; !ok {
+		return nil, NewFlapsError(http.StatusNotFound, "app not found: %q", appName)
+	}
+	if err := s.checkSecretsVersion(appName, input.MinSecretsVersion); err != nil {
+		return nil, err
+	}
+
 	s.machineSeq++
 	id := s.machineSeq
 
 	machine := &fly.Machine{
-		ID:     fmt.Sprintf("%014x", id),
-		Name:   name,
-		Region: region,
-		State:  "started",
-		Config: helpers.Clone(config),
+		ID:         fmt.Sprintf("%014x", id),
+		Name:       input.Name,
+		Region:     input.Region,
+		Config:     helpers.Clone(input.Config),
+		PrivateIP:  fmt.Sprintf("fdaa:0:1:a7b:1::%x", id),
+		CreatedAt:  s.now().UTC().Format(time.RFC3339),
+		HostStatus: fly.HostStatusOk,
+	}
+	if machine.Name == "" {
+		machine.Name = fmt.Sprintf("machine-%d", id)
+	}
+	if err := s.attachVolumes(appName, machine.ID, machine.Region, machine.Config); err != nil {
+		return nil, err
+	}
+	s.newVersion(machine)
+	s.transition(machine, "launch", fly.MachineStateCreated)
+	if !input.SkipLaunch {
+		s.transition(machine, "start", fly.MachineStateStarted)
 	}
 	s.machines[appName] = append(s.machines[appName], machine)
 
-	return machine, nil
+	out := helpers.Clone(machine)
+	if input.LeaseTTL > 0 {
+		out.LeaseNonce = s.acquireLease(machine.ID, input.LeaseTTL).nonce
+	}
+
+	return out, nil
 }
 
 func (s *Server) GetMachine(ctx context.Context, appName, machineID string) (*fly.Machine, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	machine, err := s.machine(appName, machineID)
+	if err != nil {
+		return nil, err
+	}
+
+	return helpers.Clone(machine), nil
+}
+
+// machine returns the stored machine, callers must hold the lock.
+func (s *Server) machine(appName, machineID string) (*fly.Machine, error) {
 	for _, machine := range s.machines[appName] {
 		if machine.ID == machineID {
-			return helpers.Clone(machine), nil
+			return machine, nil
 		}
 	}
 
-	return nil, fmt.Errorf("machine not found: %q", machineID)
+	return nil, NewFlapsError(http.StatusNotFound, "machine not found: %q", machineID)
+}
+
+// transition moves a machine to a new state and records the event that caused it.
+func (s *Server) transition(machine *fly.Machine, typ, state string) {
+	now := s.now()
+	machine.State = state
+	machine.UpdatedAt = now.UTC().Format(time.RFC3339)
+	// Events are listed from the most recent one
+	machine.Events = append([]*fly.MachineEvent{{
+		Type:      typ,
+		Status:    state,
+		Source:    "user",
+		Timestamp: now.UnixMilli(),
+	}}, machine.Events...)
+}
+
+// newVersion gives a machine the version & instance id of a new config.
+func (s *Server) newVersion(machine *fly.Machine) {
+	s.versionSeq++
+	machine.Version = fmt.Sprintf("%026d", s.versionSeq)
+	machine.InstanceID = machine.Version
 }
 
 type Build struct {
@@ -199,3 +287,175 @@ type Release struct {
 type imageKey struct {
 	appName, imageRef string
 }
+
+type lease struct {
+	nonce     string
+	owner     string
+	expiresAt time.Time
+}
+
+type appSecrets struct {
+	version uint64
+	values  map[string]*secret
+	keys    map[string]fly.SecretKey
+}
+
+type secret struct {
+	value     string
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+type certificate struct {
+	hostname  string
+	custom    bool
+	createdAt time.Time
+}
+
+// acquireLease gives a machine a new lease, callers must hold the lock.
+func (s *Server) acquireLease(machineID string, ttl int) *lease {
+	s.leaseSeq++
+
+	l := &lease{
+		nonce:     fmt.Sprintf("%012x", s.leaseSeq),
+		owner:     DefaultUser.Email,
+		expiresAt: s.now().Add(time.Duration(ttl) * time.Second),
+	}
+	s.leases[machineID] = l
+
+	return l
+}
+
+// activeLease returns the unexpired lease of a machine, callers must hold the lock.
+func (s *Server) activeLease(machineID string) *lease {
+	l := s.leases[machineID]
+	if l == nil || !s.now().Before(l.expiresAt) {
+		return nil
+	}
+
+	return l
+}
+
+// checkLease fails with a conflict when someone else holds the machine's lease.
+func (s *Server) checkLease(machineID, nonce string) error {
+	l := s.activeLease(machineID)
+	if l == nil || l.nonce == nonce {
+		return nil
+	}
+
+	return NewFlapsError(http.StatusConflict, "machine %s lease currently held by %s, expires at %s", machineID, l.owner, l.expiresAt.UTC().Format(time.RFC3339))
+}
+
+func (l *lease) machineLease() *fly.MachineLease {
+	return &fly.MachineLease{
+		Status: "success",
+		Data: &fly.MachineLeaseData{
+			Nonce:     l.nonce,
+			ExpiresAt: l.expiresAt.Unix(),
+			Owner:     l.owner,
+		},
+	}
+}
+
+// volume returns the stored volume, callers must hold the lock.
+func (s *Server) volume(appName, volumeID string) (*fly.Volume, error) {
+	for _, volume := range s.volumes[appName] {
+		if volume.ID == volumeID {
+			return volume, nil
+		}
+	}
+
+	return nil, NewFlapsError(http.StatusNotFound, "volume not found: %q", volumeID)
+}
+
+// attachVolumes attaches the volumes mounted by a machine's config to it.
+func (s *Server) attachVolumes(appName, machineID, region string, config *fly.MachineConfig) error {
+	if config == nil {
+		return NewFlapsError(http.StatusBadRequest, "a config is required for machine %s", machineID)
+	}
+	for _, mount := range config.Mounts {
+		if mount.Volume == "" {
+			continue
+		}
+		volume, err := s.volume(appName, mount.Volume)
+		if err != nil {
+			return err
+		}
+		if volume.AttachedMachine != nil && *volume.AttachedMachine != machineID {
+			return NewFlapsError(http.StatusPreconditionFailed, "volume %s is already attached to machine %s", volume.ID, *volume.AttachedMachine)
+		}
+		if volume.Region != region {
+			return NewFlapsError(http.StatusBadRequest, "volume %s is in region %s, not %s", volume.ID, volume.Region, region)
+		}
+	}
+
+	s.detachVolumes(appName, machineID)
+	for _, mount := range config.Mounts {
+		if volume, err := s.volume(appName, mount.Volume); err == nil {
+			volume.AttachedMachine = &machineID
+		}
+	}
+
+	return nil
+}
+
+// detachVolumes detaches all the volumes attached to a machine.
+func (s *Server) detachVolumes(appName, machineID string) {
+	for _, volume := range s.volumes[appName] {
+		if volume.AttachedMachine != nil && *volume.AttachedMachine == machineID {
+			volume.AttachedMachine = nil
+		}
+	}
+}
+
+// volumeZone places a new volume on a zone of its region. Unique placement picks a
+// zone that isn't used yet by the volumes of the same name, or of the whole app.
+func (s *Server) volumeZone(appName string, req fly.CreateVolumeRequest) (string, error) {
+	unique := req.RequireUniqueZone == nil || *req.RequireUniqueZone
+	appWide := req.UniqueZoneAppWide != nil && *req.UniqueZoneAppWide
+
+	used := map[string]bool{}
+	for _, volume := range s.volumes[appName] {
+		if volume.Region == req.Region && (appWide || volume.Name == req.Name) {
+			used[volume.Zone] = true
+		}
+	}
+
+	for i := range zonesPerRegion {
+		zone := fmt.Sprintf("%s%x", req.Region, 0xa0+i)
+		if !unique || !used[zone] {
+			return zone, nil
+		}
+	}
+
+	return "", NewFlapsError(http.StatusPreconditionFailed, "insufficient resources to create a volume in a unique zone of region %s", req.Region)
+}
+
+// zonesPerRegion is the number of zones volumes can be placed on in every region.
+const zonesPerRegion = 3
+
+// appSecrets returns an app's secrets, callers must hold the lock.
+func (s *Server) appSecrets(appName string) *appSecrets {
+	secrets := s.secrets[appName]
+	if secrets == nil {
+		secrets = &appSecrets{
+			values: make(map[string]*secret),
+			keys:   make(map[string]fly.SecretKey),
+		}
+		s.secrets[appName] = secrets
+	}
+
+	return secrets
+}
+
+// checkSecretsVersion fails when an app's secrets haven't reached a minimum version yet.
+func (s *Server) checkSecretsVersion(appName string, version *uint64) error {
+	if version == nil {
+		return nil
+	}
+	if current := s.appSecrets(appName).version; *version > current {
+		return NewFlapsError(http.StatusBadRequest, "secrets version %d of app %s is not available, the latest one is %d", *version, appName, current)
+	}
+
+	return nil
+}
@@ -44,6 +44,7 @@ func WithJustCreated() WaitOption {
 type LeasableMachine interface {
 	Machine() *fly.Machine
 	HasLease() bool
+	LeaseNonce() string
 	AcquireLease(context.Context, time.Duration) error
 	RefreshLease(context.Context, time.Duration) error
 	ReleaseLease(context.Context) error
@@ -183,6 +184,41 @@ func (lm *leasableMachine) logHealthCheckStatus(ctx context.Context, status *fly
 	)
 }
 
+func (lm *leasableMachine) emitState(ctx context.Context, state, status string, since time.Time, err error) {
+	ev := statuslogger.Event{
+		Type:       statuslogger.EventMachineState,
+		MachineID:  lm.machine.ID,
+		State:      state,
+		Status:     status,
+		DurationMs: time.Since(since).Milliseconds(),
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	statuslogger.Emit(ctx, ev)
+}
+
+func (lm *leasableMachine) emitHealthChecks(ctx context.Context, status *fly.HealthCheckStatus, result string, since time.Time, err error) {
+	ev := statuslogger.Event{
+		Type:       statuslogger.EventHealthCheck,
+		MachineID:  lm.machine.ID,
+		Status:     result,
+		DurationMs: time.Since(since).Milliseconds(),
+	}
+	if status != nil {
+		ev.Checks = &statuslogger.HealthChecks{
+			Passing:  status.Passing,
+			Warning:  status.Warn,
+			Critical: status.Critical,
+			Total:    status.Total,
+		}
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	statuslogger.Emit(ctx, ev)
+}
+
 func (lm *leasableMachine) Start(ctx context.Context) error {
 	if lm.IsDestroyed() {
 		return fmt.Errorf("error cannot start machine %s that was already destroyed", lm.machine.ID)
@@ -240,6 +276,8 @@ func (lm *leasableMachine) WaitForState(ctx context.Context, desiredState string
 	if lm.showLogs {
 		lm.logStatusWaiting(ctx, desiredState)
 	}
+	waitStart := time.Now()
+	lm.emitState(ctx, desiredState, "waiting", waitStart, nil)
 	for {
 		err := lm.flapsClient.Wait(waitCtx, lm.appName, lm.Machine().ID, flaps.WithWaitStates(desiredState), flaps.WithWaitTimeout(timeout))
 		notFoundResponse := false
@@ -253,11 +291,14 @@ func (lm *leasableMachine) WaitForState(ctx context.Context, desiredState string
 		case errors.Is(waitCtx.Err(), context.Canceled):
 			return err
 		case errors.Is(waitCtx.Err(), context.DeadlineExceeded):
-			return WaitTimeoutErr{
+			err := WaitTimeoutErr{
 				machineID:    lm.machine.ID,
 				timeout:      timeout,
 				desiredState: desiredState,
 			}
+			lm.emitState(ctx, desiredState, "timeout", waitStart, err)
+
+			return err
 		case notFoundResponse && desiredState == fly.MachineStateDestroyed:
 			// We're waiting for destroyed state and the machine no longer exists - success
 		case notFoundResponse && options.justCreated:
@@ -285,6 +326,7 @@ func (lm *leasableMachine) WaitForState(ctx context.Context, desiredState string
 		if lm.showLogs {
 			lm.logStatusFinished(ctx, desiredState)
 		}
+		lm.emitState(ctx, desiredState, "reached", waitStart, nil)
 
 		return nil
 	}
@@ -391,6 +433,8 @@ func (lm *leasableMachine) WaitForHealthchecksToPass(ctx context.Context, timeou
 	}
 
 	printedFirst := false
+	waitStart := time.Now()
+	var lastStatus *fly.HealthCheckStatus
 	for {
 		updateMachine, err := lm.flapsClient.Get(waitCtx, lm.appName, lm.Machine().ID)
 		switch {
@@ -400,8 +444,10 @@ func (lm *leasableMachine) WaitForHealthchecksToPass(ctx context.Context, timeou
 			return err
 		case errors.Is(waitCtx.Err(), context.DeadlineExceeded):
 			span.RecordError(err)
+			err = fmt.Errorf("timeout reached waiting for health checks to pass for machine %s: %w", lm.Machine().ID, err)
+			lm.emitHealthChecks(ctx, lastStatus, "timeout", waitStart, err)
 
-			return fmt.Errorf("timeout reached waiting for health checks to pass for machine %s: %w", lm.Machine().ID, err)
+			return err
 		case err != nil:
 			span.RecordError(err)
 
@@ -412,6 +458,10 @@ func (lm *leasableMachine) WaitForHealthchecksToPass(ctx context.Context, timeou
 		// AllPassing() is vacuously true when Total == 0 (no results yet), so we
 		// must guard against that or we'd exit immediately on a freshly-started machine.
 		if checkStatus.Total == 0 || !checkStatus.AllPassing() {
+			if lastStatus == nil || *lastStatus != *checkStatus {
+				lm.emitHealthChecks(ctx, checkStatus, "waiting", waitStart, nil)
+			}
+			lastStatus = checkStatus
 			if lm.showLogs && (!printedFirst || lm.io.IsInteractive()) {
 				lm.logHealthCheckStatus(ctx, checkStatus)
 				printedFirst = true
@@ -426,6 +476,7 @@ func (lm *leasableMachine) WaitForHealthchecksToPass(ctx context.Context, timeou
 		if lm.showLogs {
 			lm.logHealthCheckStatus(ctx, checkStatus)
 		}
+		lm.emitHealthChecks(ctx, checkStatus, "passing", waitStart, nil)
 
 		return nil
 	}
@@ -509,6 +560,13 @@ func (lm *leasableMachine) HasLease() bool {
 	return lm.leaseNonce != ""
 }
 
+func (lm *leasableMachine) LeaseNonce() string {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	return lm.leaseNonce
+}
+
 func (lm *leasableMachine) IsDestroyed() bool {
 	return lm.destroyed
 }
@@ -8,6 +8,15 @@ import (
 )
 
 func Create(ctx context.Context, numLines int, showStatusChar bool) StatusLogger {
+	sl := create(ctx, numLines, showStatusChar)
+	if sink := EventSinkFromContext(ctx); sink != nil {
+		return &eventLogger{StatusLogger: sl, sink: sink}
+	}
+
+	return sl
+}
+
+func create(ctx context.Context, numLines int, showStatusChar bool) StatusLogger {
 
 	logNumbers := numLines > 1
 	io := iostreams.FromContext(ctx)
@@ -0,0 +1,195 @@
+package statuslogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/superfly/flyctl/internal/cmdutil"
+)
+
+// EventSchemaVersion is bumped whenever a field of Event changes meaning or is removed.
+// Adding fields or event types doesn't bump it.
+const EventSchemaVersion = 1
+
+type EventType string
+
+const (
+	// EventLog mirrors a line logged to a StatusLine.
+	EventLog EventType = "log"
+	// EventBuildStarted and EventBuildFinished bracket each image build strategy attempt.
+	EventBuildStarted  EventType = "build_started"
+	EventBuildFinished EventType = "build_finished"
+	// EventReleaseCommandOutput carries a line of output from a release command machine.
+	EventReleaseCommandOutput EventType = "release_command_output"
+	// EventMachineState is emitted when a machine is awaited for and when it reaches a state.
+	EventMachineState EventType = "machine_state"
+	// EventHealthCheck reports the health checks of a machine.
+	EventHealthCheck EventType = "health_check"
+	// EventSmokeCheckFailed is emitted when a machine fails its smoke checks.
+	EventSmokeCheckFailed EventType = "smoke_check_failed"
+	// EventRollback records whether a failed deployment is being rolled back.
+	EventRollback EventType = "rollback"
+	// EventHook is emitted when a deploy hook starts and when it finishes.
+	EventHook EventType = "hook"
+	// EventHookOutput carries a line of output from a deploy hook.
+	EventHookOutput EventType = "hook_output"
+	// EventRelease carries the release a successful deployment created, in place of the
+	// JSON line FLY_EMIT_RELEASE_JSON otherwise prints to stdout.
+	EventRelease EventType = "release"
+	// EventDeployFinished is the last event of a deployment.
+	EventDeployFinished EventType = "deploy_finished"
+)
+
+// HealthChecks is the health check tally of an EventHealthCheck event.
+type HealthChecks struct {
+	Passing  int `json:"passing"`
+	Warning  int `json:"warning"`
+	Critical int `json:"critical"`
+	Total    int `json:"total"`
+}
+
+// Event is a single line of a machine-readable deployment event stream.
+type Event struct {
+	Version   int       `json:"v"`
+	Time      time.Time `json:"time"`
+	Type      EventType `json:"type"`
+	Message   string    `json:"message,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Line      *int      `json:"line,omitempty"`
+	MachineID string    `json:"machine_id,omitempty"`
+	State     string    `json:"state,omitempty"`
+	// Command is the kind of release command, e.g. "release", for EventReleaseCommandOutput events.
+	Command string `json:"command,omitempty"`
+	// Hook and Phase identify the deploy hook of EventHook and EventHookOutput events.
+	Hook  string `json:"hook,omitempty"`
+	Phase string `json:"phase,omitempty"`
+	// Decision is "rollback" or "skip" for EventRollback events.
+	Decision string `json:"decision,omitempty"`
+	// ReleaseID, ReleaseVersion and ImageRef describe the release of EventRelease events.
+	ReleaseID      string           `json:"release_id,omitempty"`
+	ReleaseVersion int              `json:"release_version,omitempty"`
+	ImageRef       string           `json:"image_ref,omitempty"`
+	Strategy       string           `json:"strategy,omitempty"`
+	DurationMs     int64            `json:"duration_ms,omitempty"`
+	Timings        map[string]int64 `json:"timings,omitempty"`
+	Checks         *HealthChecks    `json:"checks,omitempty"`
+}
+
+// EventSink writes events as newline-delimited JSON.
+type EventSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+func NewEventSink(w io.Writer) *EventSink {
+	return &EventSink{w: w, now: time.Now}
+}
+
+// Emit writes ev on its own line, filling in its version and time.
+func (s *EventSink) Emit(ev Event) {
+	ev.Version = EventSchemaVersion
+	if ev.Time.IsZero() {
+		ev.Time = s.now().UTC()
+	}
+	ev.Message = cmdutil.StripANSI(ev.Message)
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w.Write(data)
+}
+
+type eventSinkContextKey struct{}
+
+// NewEventSinkContext derives a Context that carries sink from ctx. Status loggers
+// created from it mirror their lines to the sink.
+func NewEventSinkContext(ctx context.Context, sink *EventSink) context.Context {
+	return context.WithValue(ctx, eventSinkContextKey{}, sink)
+}
+
+// EventSinkFromContext returns the EventSink ctx carries if any, or nil.
+func EventSinkFromContext(ctx context.Context) *EventSink {
+	sink, _ := ctx.Value(eventSinkContextKey{}).(*EventSink)
+
+	return sink
+}
+
+// Emit sends ev to the EventSink ctx carries, if any.
+func Emit(ctx context.Context, ev Event) {
+	if sink := EventSinkFromContext(ctx); sink != nil {
+		sink.Emit(ev)
+	}
+}
+
+func (status Status) eventName() string {
+	switch status {
+	case StatusRunning:
+		return "running"
+	case StatusSuccess:
+		return "success"
+	case StatusFailure:
+		return "failure"
+	default:
+		return ""
+	}
+}
+
+// eventLogger wraps a StatusLogger to mirror everything logged to its lines as events.
+type eventLogger struct {
+	StatusLogger
+	sink *EventSink
+}
+
+func (l *eventLogger) Line(idx int) StatusLine {
+	return &eventLine{StatusLine: l.StatusLogger.Line(idx), sink: l.sink, lineNum: idx}
+}
+
+type eventLine struct {
+	StatusLine
+	sink    *EventSink
+	lineNum int
+}
+
+func (l *eventLine) emit(status Status, msg string, err error) {
+	ev := Event{Type: EventLog, Message: msg, Line: &l.lineNum, Status: status.eventName()}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	l.sink.Emit(ev)
+}
+
+func (l *eventLine) Log(s string) {
+	l.StatusLine.Log(s)
+	l.emit(StatusNone, s, nil)
+}
+
+func (l *eventLine) Logf(format string, args ...any) {
+	l.StatusLine.Logf(format, args...)
+	l.emit(StatusNone, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *eventLine) LogStatus(s Status, str string) {
+	l.StatusLine.LogStatus(s, str)
+	l.emit(s, str, nil)
+}
+
+func (l *eventLine) LogfStatus(s Status, format string, args ...any) {
+	l.StatusLine.LogfStatus(s, format, args...)
+	l.emit(s, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *eventLine) Failed(e error) {
+	l.StatusLine.Failed(e)
+	l.emit(StatusFailure, "", e)
+}
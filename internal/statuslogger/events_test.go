@@ -0,0 +1,70 @@
+package statuslogger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func readEvents(t *testing.T, buf *bytes.Buffer) []Event {
+	t.Helper()
+
+	var events []Event
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var ev Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &ev))
+		events = append(events, ev)
+	}
+
+	return events
+}
+
+func TestEventSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewEventSink(&buf)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sink.now = func() time.Time { return now }
+
+	Emit(context.Background(), Event{Type: EventRollback})
+	assert.Zero(t, buf.Len(), "no sink, no events")
+
+	ctx := NewEventSinkContext(context.Background(), sink)
+	Emit(ctx, Event{Type: EventMachineState, MachineID: "m1", State: "started", Status: "reached", DurationMs: 42, Message: "\x1b[1mm1\x1b[0m started"})
+
+	assert.JSONEq(t, `{"v":1,"time":"2024-01-02T03:04:05Z","type":"machine_state","message":"m1 started","status":"reached","machine_id":"m1","state":"started","duration_ms":42}`, buf.String())
+}
+
+func TestEventLogger(t *testing.T) {
+	streams, _, out, _ := iostreams.Test()
+	var buf bytes.Buffer
+	ctx := iostreams.NewContext(context.Background(), streams)
+	ctx = NewEventSinkContext(ctx, NewEventSink(&buf))
+
+	sl := Create(ctx, 2, true)
+	sl.Line(0).Logf("Updating %s", "m1")
+	sl.Line(1).LogStatus(StatusSuccess, "done")
+	sl.Line(1).Failed(errors.New("boom"))
+	sl.Destroy(false)
+
+	assert.Contains(t, out.String(), "Updating m1")
+
+	events := readEvents(t, &buf)
+	require.Len(t, events, 3)
+	assert.Equal(t, EventLog, events[0].Type)
+	assert.Equal(t, "Updating m1", events[0].Message)
+	assert.Equal(t, 0, *events[0].Line)
+	assert.Empty(t, events[0].Status)
+	assert.Equal(t, "success", events[1].Status)
+	assert.Equal(t, 1, *events[1].Line)
+	assert.Equal(t, "failure", events[2].Status)
+	assert.Equal(t, "boom", events[2].Error)
+}
@@ -0,0 +1,167 @@
+// Package workspace implements workspace files, which list the fly apps of a repository
+// and how they depend on each other so they can be deployed together.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+// DefaultFileName is the name of the workspace file looked up by default.
+const DefaultFileName = "fly.workspace.toml"
+
+// Workspace is a set of apps deployed together.
+type Workspace struct {
+	Apps []*App `toml:"apps"`
+
+	path string
+}
+
+// App is an app of a workspace.
+type App struct {
+	// Name defaults to the app name of its config.
+	Name string `toml:"name,omitempty"`
+	// ConfigPath is the path of the app's config, relative to the workspace file.
+	ConfigPath string `toml:"config"`
+	// Context is the app's build context, relative to the workspace file. It defaults to
+	// the directory of its config.
+	Context string `toml:"context,omitempty"`
+	// DependsOn are the names of the apps to deploy before this one.
+	DependsOn []string `toml:"depends_on,omitempty"`
+
+	config *appconfig.Config
+}
+
+// Config returns the app's config, as loaded with the workspace.
+func (a *App) Config() *appconfig.Config {
+	return a.config
+}
+
+// Load reads a workspace file and the configs of its apps, merged with the overlay of the
+// given environment if any.
+func Load(path, environment string) (*Workspace, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Workspace{}
+	if err := toml.Unmarshal(buf, w); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace %s: %w", path, err)
+	}
+	if w.path, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+	if len(w.Apps) == 0 {
+		return nil, fmt.Errorf("workspace %s has no apps", path)
+	}
+
+	for _, app := range w.Apps {
+		if app.ConfigPath == "" {
+			return nil, fmt.Errorf("an app of workspace %s has no config", path)
+		}
+		app.ConfigPath = w.resolve(app.ConfigPath)
+		if app.Context == "" {
+			app.Context = filepath.Dir(app.ConfigPath)
+		} else {
+			app.Context = w.resolve(app.Context)
+		}
+
+		if app.config, err = appconfig.LoadConfigForEnvironment(app.ConfigPath, environment); err != nil {
+			return nil, fmt.Errorf("failed to load the config of a workspace app: %w", err)
+		}
+		if app.Name == "" {
+			app.Name = app.config.AppName
+		}
+		if app.Name == "" {
+			return nil, fmt.Errorf("workspace app %s has no name, set one in the workspace or its config", app.ConfigPath)
+		}
+		app.config.AppName = app.Name
+	}
+
+	return w, nil
+}
+
+func (w *Workspace) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(filepath.Dir(w.path), path)
+}
+
+// Path returns the absolute path of the workspace file.
+func (w *Workspace) Path() string {
+	return w.path
+}
+
+// Stages orders the apps so each one comes after its dependencies. Apps of a stage only
+// depend on apps of earlier stages, and are sorted by name.
+func (w *Workspace) Stages() ([][]*App, error) {
+	byName := map[string]*App{}
+	for _, app := range w.Apps {
+		if _, ok := byName[app.Name]; ok {
+			return nil, fmt.Errorf("app %s is listed more than once in the workspace", app.Name)
+		}
+		byName[app.Name] = app
+	}
+
+	pending := map[string]int{}
+	dependents := map[string][]*App{}
+	for _, app := range w.Apps {
+		for _, dep := range app.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("app %s depends on %s, which isn't in the workspace", app.Name, dep)
+			}
+			pending[app.Name]++
+			dependents[dep] = append(dependents[dep], app)
+		}
+	}
+
+	var (
+		stages [][]*App
+		stage  []*App
+		placed int
+	)
+	for _, app := range w.Apps {
+		if pending[app.Name] == 0 {
+			stage = append(stage, app)
+		}
+	}
+	for len(stage) > 0 {
+		slices.SortFunc(stage, func(a, b *App) int { return strings.Compare(a.Name, b.Name) })
+		stages = append(stages, stage)
+		placed += len(stage)
+
+		var next []*App
+		for _, app := range stage {
+			for _, dependent := range dependents[app.Name] {
+				if pending[dependent.Name]--; pending[dependent.Name] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		stage = next
+	}
+
+	if placed != len(w.Apps) {
+		var cycle []string
+		for _, app := range w.Apps {
+			if pending[app.Name] > 0 {
+				cycle = append(cycle, app.Name)
+			}
+		}
+		slices.Sort(cycle)
+
+		return nil, errors.New("workspace apps have circular dependencies: " + strings.Join(cycle, ", "))
+	}
+
+	return stages, nil
+}
@@ -0,0 +1,99 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, rel, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	return path
+}
+
+func stageNames(stages [][]*App) [][]string {
+	var names [][]string
+	for _, stage := range stages {
+		var stageNames []string
+		for _, app := range stage {
+			stageNames = append(stageNames, app.Name)
+		}
+		names = append(names, stageNames)
+	}
+
+	return names
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "api/fly.toml", `app = "acme-api"`)
+	writeFile(t, dir, "web/fly.toml", `app = "acme-web"`)
+	writeFile(t, dir, "worker/fly.toml", ``)
+	path := writeFile(t, dir, DefaultFileName, `
+[[apps]]
+  config = "web/fly.toml"
+  depends_on = ["acme-api"]
+
+[[apps]]
+  config = "api/fly.toml"
+
+[[apps]]
+  name = "acme-worker"
+  config = "worker/fly.toml"
+  context = "."
+  depends_on = ["acme-api"]
+`)
+
+	w, err := Load(path, "")
+	require.NoError(t, err)
+	require.Len(t, w.Apps, 3)
+
+	web := w.Apps[0]
+	assert.Equal(t, "acme-web", web.Name)
+	assert.Equal(t, filepath.Join(dir, "web", "fly.toml"), web.ConfigPath)
+	assert.Equal(t, filepath.Join(dir, "web"), web.Context)
+	assert.Equal(t, "acme-web", web.Config().AppName)
+
+	worker := w.Apps[2]
+	assert.Equal(t, dir, worker.Context)
+	assert.Equal(t, "acme-worker", worker.Config().AppName)
+
+	stages, err := w.Stages()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"acme-api"}, {"acme-web", "acme-worker"}}, stageNames(stages))
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "fly.toml", ``)
+
+	_, err := Load(writeFile(t, dir, "empty.toml", ``), "")
+	assert.ErrorContains(t, err, "has no apps")
+
+	_, err = Load(writeFile(t, dir, "unnamed.toml", "[[apps]]\nconfig = \"fly.toml\"\n"), "")
+	assert.ErrorContains(t, err, "has no name")
+
+	_, err = Load(writeFile(t, dir, "missing.toml", "[[apps]]\nconfig = \"nope/fly.toml\"\n"), "")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestStagesErrors(t *testing.T) {
+	w := &Workspace{Apps: []*App{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}, {Name: "c"}}}
+	_, err := w.Stages()
+	assert.EqualError(t, err, "workspace apps have circular dependencies: a, b")
+
+	w = &Workspace{Apps: []*App{{Name: "a", DependsOn: []string{"z"}}}}
+	_, err = w.Stages()
+	assert.EqualError(t, err, "app a depends on z, which isn't in the workspace")
+
+	w = &Workspace{Apps: []*App{{Name: "a"}, {Name: "a"}}}
+	_, err = w.Stages()
+	assert.EqualError(t, err, "app a is listed more than once in the workspace")
+}
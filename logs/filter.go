@@ -0,0 +1,95 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Filter selects the log entries a stream emits. Its zero value selects every entry.
+type Filter struct {
+	// Since and Until bound the time of entries, when not zero. Entries whose time
+	// can't be parsed are kept.
+	Since, Until time.Time
+	// Level is the lowest level of the entries to keep.
+	Level string
+	// Grep matches the messages of entries to keep.
+	Grep *regexp.Regexp
+	// Instances are the IDs of the machines whose entries to keep, when not nil.
+	Instances map[string]bool
+}
+
+// levels rank the log levels of the platform and of common loggers, from the lowest.
+var levels = map[string]int{
+	"trace":    0,
+	"debug":    1,
+	"info":     2,
+	"notice":   2,
+	"warn":     3,
+	"warning":  3,
+	"error":    4,
+	"fatal":    5,
+	"critical": 5,
+	"panic":    5,
+}
+
+// ValidateLevel returns an error if level isn't one a Filter can keep entries from.
+func ValidateLevel(level string) error {
+	if _, ok := levels[strings.ToLower(level)]; !ok {
+		return fmt.Errorf("unknown log level %q, use one of trace, debug, info, warn, error or fatal", level)
+	}
+
+	return nil
+}
+
+// Match tells whether the filter keeps entry. A nil filter keeps every entry.
+func (f *Filter) Match(entry LogEntry) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.Instances != nil && !f.Instances[entry.Instance] {
+		return false
+	}
+
+	if f.Level != "" {
+		// entries of unknown levels, such as those of apps that don't log levels, are
+		// only kept when they match exactly
+		rank, known := levels[strings.ToLower(entry.Level)]
+		if !known && !strings.EqualFold(entry.Level, f.Level) || known && rank < levels[strings.ToLower(f.Level)] {
+			return false
+		}
+	}
+
+	if f.Grep != nil && !f.Grep.MatchString(entry.Message) {
+		return false
+	}
+
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil {
+			return true
+		}
+		if !f.Since.IsZero() && ts.Before(f.Since) || !f.Until.IsZero() && ts.After(f.Until) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseTime parses the bound of a time range: either a time in RFC 3339 format, or a
+// duration, such as 1h30m, meaning that long before now.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a duration, such as 1h, nor a time, such as 2006-01-02T15:04:05Z", s)
+	}
+
+	return t, nil
+}
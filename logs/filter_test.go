@@ -0,0 +1,59 @@
+package logs
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterMatch(t *testing.T) {
+	entry := LogEntry{
+		Instance:  "m1",
+		Level:     "warn",
+		Message:   "connection reset by peer",
+		Timestamp: "2025-01-02T15:04:05.123Z",
+	}
+
+	var nilFilter *Filter
+	assert.True(t, nilFilter.Match(entry))
+	assert.True(t, (&Filter{}).Match(entry))
+
+	assert.True(t, (&Filter{Level: "info"}).Match(entry))
+	assert.True(t, (&Filter{Level: "WARNING"}).Match(entry))
+	assert.False(t, (&Filter{Level: "error"}).Match(entry))
+	assert.False(t, (&Filter{Level: "error"}).Match(LogEntry{Level: "custom"}))
+	assert.True(t, (&Filter{Level: "custom"}).Match(LogEntry{Level: "custom"}))
+
+	assert.True(t, (&Filter{Grep: regexp.MustCompile(`reset|refused`)}).Match(entry))
+	assert.False(t, (&Filter{Grep: regexp.MustCompile(`^reset`)}).Match(entry))
+
+	assert.True(t, (&Filter{Instances: map[string]bool{"m1": true}}).Match(entry))
+	assert.False(t, (&Filter{Instances: map[string]bool{"m2": true}}).Match(entry))
+
+	ts := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	assert.True(t, (&Filter{Since: ts, Until: ts.Add(time.Second)}).Match(entry))
+	assert.False(t, (&Filter{Since: ts.Add(time.Second)}).Match(entry))
+	assert.False(t, (&Filter{Until: ts}).Match(entry))
+	assert.True(t, (&Filter{Until: ts}).Match(LogEntry{Timestamp: "garbage"}))
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	got, err := ParseTime("90m", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-90*time.Minute), got)
+
+	got, err = ParseTime("2025-01-01T00:00:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), got)
+
+	_, err = ParseTime("yesterday", now)
+	assert.Error(t, err)
+
+	assert.NoError(t, ValidateLevel("Error"))
+	assert.Error(t, ValidateLevel("loud"))
+}
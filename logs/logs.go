@@ -18,6 +18,8 @@ type LogOptions struct {
 	VMID       string
 	RegionCode string
 	NoTail     bool
+	// Filter, when set, selects the entries streams emit.
+	Filter *Filter
 }
 
 type WebClient interface {
@@ -0,0 +1,108 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// lokiSink pushes entries to Loki, in a stream per region and instance.
+type lokiSink struct {
+	endpoint *url.URL
+	appName  string
+	client   *http.Client
+}
+
+func newLokiSink(endpoint *url.URL, appName string) *lokiSink {
+	return &lokiSink{
+		endpoint: endpoint,
+		appName:  appName,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (s *lokiSink) Send(ctx context.Context, entries []LogEntry) error {
+	type key struct{ region, instance string }
+
+	var (
+		streams []*lokiStream
+		byKey   = map[key]*lokiStream{}
+	)
+	for _, entry := range entries {
+		ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil {
+			ts = time.Now()
+		}
+
+		k := key{entry.Region, entry.Instance}
+		stream := byKey[k]
+		if stream == nil {
+			stream = &lokiStream{Stream: map[string]string{
+				"app":      s.appName,
+				"region":   entry.Region,
+				"instance": entry.Instance,
+			}}
+			byKey[k] = stream
+			streams = append(streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(ts.UnixNano(), 10), entry.Message})
+	}
+
+	body, err := json.Marshal(map[string]any{"streams": streams})
+	if err != nil {
+		return err
+	}
+
+	return postBatch(ctx, s.client, s.endpoint, "application/json", body)
+}
+
+func (s *lokiSink) Close() error {
+	return nil
+}
+
+// postBatch posts body to endpoint, with the basic auth of its user if any.
+func postBatch(ctx context.Context, client *http.Client, endpoint *url.URL, contentType string, body []byte) error {
+	target := *endpoint
+	target.User = nil
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if user := endpoint.User; user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("%s responded %s: %s", target.Host, resp.Status, bytes.TrimSpace(msg))
+
+		// client errors won't go away by sending the batch again, except for timeouts and rate limits
+		if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return &RejectedError{Err: err}
+		}
+
+		return err
+	}
+
+	return nil
+}
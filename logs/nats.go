@@ -108,7 +108,7 @@ func fromNats(ctx context.Context, out chan<- LogEntry, nc *nats.Conn, opts *Log
 			break
 		}
 
-		out <- LogEntry{
+		entry := LogEntry{
 			Instance:  log.Fly.App.Instance,
 			Level:     log.Log.Level,
 			Message:   log.Message,
@@ -120,6 +120,9 @@ func fromNats(ctx context.Context, out chan<- LogEntry, nc *nats.Conn, opts *Log
 				Event:    struct{ Provider string }{log.Event.Provider},
 			},
 		}
+		if opts.Filter.Match(entry) {
+			out <- entry
+		}
 	}
 
 	return
@@ -0,0 +1,92 @@
+package logs
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpSink exports entries to an OTLP/HTTP logs endpoint, with a resource per region
+// and instance.
+type otlpSink struct {
+	endpoint *url.URL
+	appName  string
+	client   *http.Client
+}
+
+func newOTLPSink(endpoint *url.URL, appName string) *otlpSink {
+	return &otlpSink{
+		endpoint: endpoint,
+		appName:  appName,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// otlpSeverities are the OTLP severity numbers of the ranks of levels.
+var otlpSeverities = []logspb.SeverityNumber{
+	logspb.SeverityNumber_SEVERITY_NUMBER_TRACE,
+	logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+	logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+	logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+	logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+}
+
+func (s *otlpSink) Send(ctx context.Context, entries []LogEntry) error {
+	type key struct{ region, instance string }
+
+	var (
+		request = &collogspb.ExportLogsServiceRequest{}
+		byKey   = map[key]*logspb.ScopeLogs{}
+		now     = uint64(time.Now().UnixNano())
+	)
+	for _, entry := range entries {
+		k := key{entry.Region, entry.Instance}
+		scope := byKey[k]
+		if scope == nil {
+			scope = &logspb.ScopeLogs{}
+			byKey[k] = scope
+			request.ResourceLogs = append(request.ResourceLogs, &logspb.ResourceLogs{
+				Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+					stringAttribute("service.name", s.appName),
+					stringAttribute("cloud.region", entry.Region),
+					stringAttribute("service.instance.id", entry.Instance),
+				}},
+				ScopeLogs: []*logspb.ScopeLogs{scope},
+			})
+		}
+
+		record := &logspb.LogRecord{
+			ObservedTimeUnixNano: now,
+			SeverityText:         entry.Level,
+			SeverityNumber:       otlpSeverities[severity(entry)],
+			Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: entry.Message}},
+		}
+		if ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+			record.TimeUnixNano = uint64(ts.UnixNano())
+		}
+		scope.LogRecords = append(scope.LogRecords, record)
+	}
+
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	return postBatch(ctx, s.client, s.endpoint, "application/x-protobuf", body)
+}
+
+func (s *otlpSink) Close() error {
+	return nil
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
@@ -82,7 +82,7 @@ func Poll(ctx context.Context, out chan<- LogEntry, client WebClient, opts *LogO
 		}
 
 		for _, entry := range entries {
-			out <- LogEntry{
+			entry := LogEntry{
 				Instance:  entry.Instance,
 				Level:     entry.Level,
 				Message:   entry.Message,
@@ -90,6 +90,9 @@ func Poll(ctx context.Context, out chan<- LogEntry, client WebClient, opts *LogO
 				Timestamp: entry.Timestamp,
 				Meta:      entry.Meta,
 			}
+			if opts.Filter.Match(entry) {
+				out <- entry
+			}
 		}
 
 		if opts.NoTail {
@@ -0,0 +1,265 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/azazeal/pause"
+)
+
+// Sink receives the log entries a Shipper forwards.
+type Sink interface {
+	// Send delivers entries, all or none of them as far as the Shipper knows: it sends
+	// them again when Send fails, unless with a RejectedError.
+	Send(ctx context.Context, entries []LogEntry) error
+	Close() error
+}
+
+// RejectedError is returned by sinks for batches they won't ever accept, such as malformed
+// ones or ones they aren't authorized for. The Shipper drops them instead of retrying.
+type RejectedError struct {
+	Err error
+}
+
+func (e *RejectedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RejectedError) Unwrap() error {
+	return e.Err
+}
+
+// NewSink returns the sink of the given URL, labelling entries with appName:
+//
+//	loki://host:port, lokis://host:port    Loki push API, over HTTP or HTTPS
+//	otlp://host:port, otlps://host:port    OTLP/HTTP logs endpoint, over HTTP or HTTPS
+//	syslog+tcp://host:port                 RFC 5424 syslog over TCP, octet-counted
+//	syslog+tls://host:port                 same, over TLS
+//	syslog+udp://host:port                 RFC 5424 syslog over UDP
+//
+// The user and password of loki and otlp URLs are sent as basic auth.
+func NewSink(rawURL, appName string) (Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sink URL: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("sink URL %s has no host", rawURL)
+	}
+
+	switch u.Scheme {
+	case "loki", "lokis":
+		return newLokiSink(httpEndpoint(u, "/loki/api/v1/push"), appName), nil
+	case "otlp", "otlps":
+		return newOTLPSink(httpEndpoint(u, "/v1/logs"), appName), nil
+	case "syslog+tcp", "syslog+tls", "syslog+udp":
+		return newSyslogSink(strings.TrimPrefix(u.Scheme, "syslog+"), u.Host, appName), nil
+	default:
+		return nil, fmt.Errorf("unsupported sink %q, use loki, lokis, otlp, otlps, syslog+tcp, syslog+tls or syslog+udp", u.Scheme)
+	}
+}
+
+// httpEndpoint returns the HTTP URL of u, defaulting its path to defaultPath.
+func httpEndpoint(u *url.URL, defaultPath string) *url.URL {
+	endpoint := *u
+	endpoint.Scheme = "http"
+	if strings.HasSuffix(u.Scheme, "s") {
+		endpoint.Scheme = "https"
+	}
+	if endpoint.Path == "" || endpoint.Path == "/" {
+		endpoint.Path = defaultPath
+	}
+
+	return &endpoint
+}
+
+const (
+	defaultShipBatchSize     = 500
+	defaultShipFlushInterval = time.Second
+	defaultShipMaxBackoff    = time.Minute
+	defaultShipCheckpointLag = time.Minute
+)
+
+// Shipper forwards log entries to a Sink in batches.
+type Shipper struct {
+	Sink Sink
+	// BatchSize is the most entries sent at once, 500 by default.
+	BatchSize int
+	// FlushInterval is how long entries wait for a batch to fill up, 1s by default.
+	FlushInterval time.Duration
+	// MaxBackoff caps the wait between attempts at sending a batch, 1m by default.
+	MaxBackoff time.Duration
+	// Checkpoint, when set, records how far the entries sent go, CheckpointLag behind the
+	// newest one.
+	Checkpoint *Checkpoint
+	// CheckpointLag is how much older than the newest entry sent an entry may still arrive,
+	// 1m by default. Streams are merged out of order and each machine's entries come on
+	// their own timing, so the checkpoint trails by that much for late entries to be
+	// shipped again after a restart.
+	CheckpointLag time.Duration
+	// OnRetry, when set, is called when a batch fails to send, before waiting to retry.
+	OnRetry func(err error, entries int, wait time.Duration)
+	// OnDrop, when set, is called when the sink rejects a batch for good.
+	OnDrop func(err error, entries int)
+
+	newest, checkpointed time.Time
+}
+
+// Ship forwards the entries of in until it closes or ctx is done. Batches the sink
+// fails to accept are retried until they are, those it rejects are dropped.
+func (s *Shipper) Ship(ctx context.Context, in <-chan LogEntry) error {
+	var (
+		batchSize     = s.BatchSize
+		flushInterval = s.FlushInterval
+		batch         []LogEntry
+	)
+	if batchSize <= 0 {
+		batchSize = defaultShipBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = defaultShipFlushInterval
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case entry, ok := <-in:
+			if !ok {
+				return s.flush(ctx, batch)
+			}
+
+			if batch = append(batch, entry); len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+
+		if err := s.flush(ctx, batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+	}
+}
+
+// flush sends batch, retrying with backoff, then moves the checkpoint forward. A rejected
+// batch moves it too, so that a restarted shipper doesn't send it again.
+func (s *Shipper) flush(ctx context.Context, batch []LogEntry) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	maxBackoff := s.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultShipMaxBackoff
+	}
+
+	for wait := min(time.Second, maxBackoff); ; wait = min(backoff(wait, maxBackoff), maxBackoff) {
+		err := s.Sink.Send(ctx, batch)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if rejected := (*RejectedError)(nil); errors.As(err, &rejected) {
+			if s.OnDrop != nil {
+				s.OnDrop(err, len(batch))
+			}
+
+			break
+		}
+
+		if s.OnRetry != nil {
+			s.OnRetry(err, len(batch), wait)
+		}
+		pause.For(ctx, wait)
+	}
+
+	// streams may interleave, so the last entry isn't always the newest
+	for _, entry := range batch {
+		if t, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil && t.After(s.newest) {
+			s.newest = t
+		}
+	}
+	if s.Checkpoint == nil || s.newest.IsZero() {
+		return nil
+	}
+
+	lag := s.CheckpointLag
+	if lag <= 0 {
+		lag = defaultShipCheckpointLag
+	}
+	watermark := s.newest.Add(-lag)
+	if !watermark.After(s.checkpointed) {
+		return nil
+	}
+	if err := s.Checkpoint.Save(watermark); err != nil {
+		return err
+	}
+	s.checkpointed = watermark
+
+	return nil
+}
+
+// Checkpoint is a file recording the time up to which log entries were shipped, so that
+// a restarted shipper resumes from there. Entries of that very time are shipped again,
+// as others may have shared it.
+type Checkpoint struct {
+	Path string
+}
+
+// Load returns the time of the checkpoint, or the zero time when there is none yet.
+func (c *Checkpoint) Load() (time.Time, error) {
+	data, err := os.ReadFile(c.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return time.Time{}, nil
+	case err != nil:
+		return time.Time{}, fmt.Errorf("failed reading checkpoint: %w", err)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed parsing checkpoint %s: %w", c.Path, err)
+	}
+
+	return t, nil
+}
+
+// Save records t.
+func (c *Checkpoint) Save(t time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o700); err != nil {
+		return fmt.Errorf("failed saving checkpoint: %w", err)
+	}
+
+	// written aside then renamed, so that a crash can't leave a truncated checkpoint
+	tmp := c.Path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(t.Format(time.RFC3339Nano)+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed saving checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, c.Path); err != nil {
+		return fmt.Errorf("failed saving checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// severity ranks the level of entry like levels do, defaulting to info.
+func severity(entry LogEntry) int {
+	if rank, ok := levels[strings.ToLower(entry.Level)]; ok {
+		return rank
+	}
+
+	return levels["info"]
+}
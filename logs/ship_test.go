@@ -0,0 +1,285 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+var shipEntries = []LogEntry{
+	{Timestamp: "2025-01-02T15:04:05.000000001Z", Level: "info", Region: "ams", Instance: "m1", Message: "started"},
+	{Timestamp: "2025-01-02T15:04:06Z", Level: "error", Region: "ams", Instance: "m1", Message: "crashed"},
+	{Timestamp: "2025-01-02T15:04:07Z", Level: "info", Region: "ord", Instance: "m2", Message: "listening"},
+}
+
+func TestNewSink(t *testing.T) {
+	for _, rawURL := range []string{"loki://localhost:3100", "lokis://u:p@logs.example.com", "otlp://localhost:4318", "syslog+tcp://localhost:514", "syslog+udp://localhost:514", "syslog+tls://localhost:6514"} {
+		sink, err := NewSink(rawURL, "my-app")
+		require.NoError(t, err, rawURL)
+		require.NoError(t, sink.Close())
+	}
+
+	for _, rawURL := range []string{"kafka://localhost:9092", "loki://", "::"} {
+		_, err := NewSink(rawURL, "my-app")
+		assert.Error(t, err, rawURL)
+	}
+}
+
+func TestLokiSink(t *testing.T) {
+	var body struct {
+		Streams []lokiStream `json:"streams"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user:secret", user+":"+password)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink, err := NewSink(strings.Replace(srv.URL, "http://", "loki://user:secret@", 1), "my-app")
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(context.Background(), shipEntries))
+
+	require.Len(t, body.Streams, 2)
+	assert.Equal(t, map[string]string{"app": "my-app", "region": "ams", "instance": "m1"}, body.Streams[0].Stream)
+	assert.Equal(t, [][2]string{{"1735830245000000001", "started"}, {"1735830246000000000", "crashed"}}, body.Streams[0].Values)
+	assert.Equal(t, "ord", body.Streams[1].Stream["region"])
+}
+
+func TestOTLPSink(t *testing.T) {
+	var request collogspb.ExportLogsServiceRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, proto.Unmarshal(data, &request))
+	}))
+	defer srv.Close()
+
+	sink, err := NewSink(strings.Replace(srv.URL, "http://", "otlp://", 1), "my-app")
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(context.Background(), shipEntries))
+
+	require.Len(t, request.ResourceLogs, 2)
+	attributes := map[string]string{}
+	for _, kv := range request.ResourceLogs[0].Resource.Attributes {
+		attributes[kv.Key] = kv.Value.GetStringValue()
+	}
+	assert.Equal(t, map[string]string{"service.name": "my-app", "cloud.region": "ams", "service.instance.id": "m1"}, attributes)
+
+	records := request.ResourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, records, 2)
+	assert.Equal(t, "crashed", records[1].Body.GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, records[1].SeverityNumber)
+	assert.Equal(t, uint64(1735830246000000000), records[1].TimeUnixNano)
+}
+
+func TestSyslogSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	received := make(chan string, len(shipEntries))
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for {
+			length, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(length))
+			if err != nil {
+				return
+			}
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	sink, err := NewSink("syslog+tcp://"+ln.Addr().String(), "my-app")
+	require.NoError(t, err)
+	defer sink.Close()
+	require.NoError(t, sink.Send(context.Background(), shipEntries[:2]))
+
+	assert.Equal(t, `<14>1 2025-01-02T15:04:05.000000001Z m1 my-app - - [fly region="ams"] started`, <-received)
+	assert.Equal(t, `<11>1 2025-01-02T15:04:06Z m1 my-app - - [fly region="ams"] crashed`, <-received)
+}
+
+type fakeSink struct {
+	failures atomic.Int32
+	sent     [][]LogEntry
+}
+
+func (s *fakeSink) Send(_ context.Context, entries []LogEntry) error {
+	if s.failures.Add(-1) >= 0 {
+		return errors.New("unavailable")
+	}
+	s.sent = append(s.sent, append([]LogEntry(nil), entries...))
+
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	return nil
+}
+
+func TestShipper(t *testing.T) {
+	sink := &fakeSink{}
+	sink.failures.Store(1)
+
+	checkpoint := &Checkpoint{Path: filepath.Join(t.TempDir(), "ship", "app.checkpoint")}
+	since, err := checkpoint.Load()
+	require.NoError(t, err)
+	assert.True(t, since.IsZero())
+
+	var retries int
+	shipper := &Shipper{
+		Sink:          sink,
+		BatchSize:     2,
+		MaxBackoff:    time.Millisecond,
+		Checkpoint:    checkpoint,
+		CheckpointLag: time.Second,
+		OnRetry:       func(error, int, time.Duration) { retries++ },
+	}
+
+	in := make(chan LogEntry)
+	go func() {
+		defer close(in)
+		// out of order, as interleaved streams may be
+		for _, entry := range []LogEntry{shipEntries[1], shipEntries[0], shipEntries[2]} {
+			in <- entry
+		}
+	}()
+
+	require.NoError(t, shipper.Ship(context.Background(), in))
+
+	assert.Equal(t, 1, retries)
+	require.Len(t, sink.sent, 2)
+	assert.Len(t, sink.sent[0], 2)
+	assert.Len(t, sink.sent[1], 1)
+
+	since, err = checkpoint.Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 2, 15, 4, 6, 0, time.UTC), since)
+}
+
+func TestShipperDropsRejectedBatches(t *testing.T) {
+	statuses := []int{http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusNoContent, http.StatusBadRequest}
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[min(int(requests.Add(1))-1, len(statuses)-1)])
+	}))
+	defer srv.Close()
+
+	sink, err := NewSink(strings.Replace(srv.URL, "http://", "loki://", 1), "my-app")
+	require.NoError(t, err)
+
+	var (
+		retries int
+		dropped []int
+	)
+	checkpoint := &Checkpoint{Path: filepath.Join(t.TempDir(), "app.checkpoint")}
+	shipper := &Shipper{
+		Sink:          sink,
+		BatchSize:     2,
+		MaxBackoff:    time.Millisecond,
+		Checkpoint:    checkpoint,
+		CheckpointLag: time.Second,
+		OnRetry:       func(error, int, time.Duration) { retries++ },
+		OnDrop: func(err error, entries int) {
+			assert.ErrorContains(t, err, "400 Bad Request")
+			dropped = append(dropped, entries)
+		},
+	}
+
+	in := make(chan LogEntry, len(shipEntries))
+	for _, entry := range shipEntries {
+		in <- entry
+	}
+	close(in)
+
+	require.NoError(t, shipper.Ship(context.Background(), in))
+
+	assert.Equal(t, []int{1}, dropped)
+	assert.Equal(t, 2, retries, "timeouts and rate limits are retried")
+	assert.EqualValues(t, 4, requests.Load())
+
+	// the dropped batch advanced the checkpoint, a second behind its entry
+	since, err := checkpoint.Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 2, 15, 4, 6, 0, time.UTC), since)
+}
+
+// checkpointingSink records the checkpoint each batch finds when it's sent.
+type checkpointingSink struct {
+	checkpoint  *Checkpoint
+	checkpoints []time.Time
+}
+
+func (s *checkpointingSink) Send(_ context.Context, entries []LogEntry) error {
+	since, err := s.checkpoint.Load()
+	s.checkpoints = append(s.checkpoints, since)
+
+	return err
+}
+
+func (s *checkpointingSink) Close() error {
+	return nil
+}
+
+func TestShipperCheckpointTrailsLateEntries(t *testing.T) {
+	at := func(sec int) LogEntry {
+		return LogEntry{Timestamp: time.Date(2025, 1, 2, 15, 4, sec, 0, time.UTC).Format(time.RFC3339Nano), Instance: "m" + strconv.Itoa(sec)}
+	}
+
+	checkpoint := &Checkpoint{Path: filepath.Join(t.TempDir(), "app.checkpoint")}
+	sink := &checkpointingSink{checkpoint: checkpoint}
+	shipper := &Shipper{Sink: sink, BatchSize: 2, Checkpoint: checkpoint, CheckpointLag: 5 * time.Second}
+
+	// m1's entry arrives after newer ones of other machines were sent
+	in := make(chan LogEntry, 6)
+	for _, entry := range []LogEntry{at(5), at(3), at(1), at(6), at(12), at(11)} {
+		in <- entry
+	}
+	close(in)
+	require.NoError(t, shipper.Ship(context.Background(), in))
+
+	require.Len(t, sink.checkpoints, 3)
+	// a restart after the first batch still ships the late entry of the second
+	assert.False(t, sink.checkpoints[1].After(time.Date(2025, 1, 2, 15, 4, 1, 0, time.UTC)), sink.checkpoints[1])
+	assert.Equal(t, time.Date(2025, 1, 2, 15, 4, 1, 0, time.UTC), sink.checkpoints[2])
+
+	since, err := checkpoint.Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 2, 15, 4, 7, 0, time.UTC), since)
+}
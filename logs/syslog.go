@@ -0,0 +1,129 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// syslogSink sends entries as RFC 5424 messages of the user facility, from the app as
+// APP-NAME and the instance as HOSTNAME, with the region as structured data. Messages
+// are octet-counted over TCP and TLS, and sent one per datagram over UDP.
+type syslogSink struct {
+	network string
+	addr    string
+	appName string
+
+	conn net.Conn
+}
+
+func newSyslogSink(network, addr, appName string) *syslogSink {
+	return &syslogSink{network: network, addr: addr, appName: appName}
+}
+
+// syslogSeverities are the syslog severities of the ranks of levels.
+var syslogSeverities = []int{7, 7, 6, 4, 3, 2}
+
+const syslogFacilityUser = 1
+
+func (s *syslogSink) Send(ctx context.Context, entries []LogEntry) error {
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline) //nolint:errcheck
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		msg := s.format(entry)
+
+		if s.network == "udp" {
+			if _, err := s.conn.Write([]byte(msg)); err != nil {
+				return s.fail(err)
+			}
+
+			continue
+		}
+		fmt.Fprintf(&buf, "%d %s", len(msg), msg)
+	}
+
+	if buf.Len() > 0 {
+		if _, err := s.conn.Write(buf.Bytes()); err != nil {
+			return s.fail(err)
+		}
+	}
+
+	return nil
+}
+
+func (s *syslogSink) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	if s.network == "tls" {
+		host, _, _ := net.SplitHostPort(s.addr)
+
+		return (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", s.addr)
+	}
+
+	return dialer.DialContext(ctx, s.network, s.addr)
+}
+
+// fail closes the connection err broke, for the next Send to dial again.
+func (s *syslogSink) fail(err error) error {
+	s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+func (s *syslogSink) format(entry LogEntry) string {
+	pri := syslogFacilityUser*8 + syslogSeverities[severity(entry)]
+
+	timestamp := entry.Timestamp
+	if _, err := time.Parse(time.RFC3339Nano, timestamp); err != nil {
+		timestamp = "-"
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s - - [fly region=\"%s\"] %s",
+		pri, timestamp, syslogHeaderField(entry.Instance), syslogHeaderField(s.appName), syslogParamValue(entry.Region), entry.Message)
+}
+
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+
+	return s.conn.Close()
+}
+
+// syslogHeaderField returns s as a header field: printable ASCII without spaces, or -.
+func syslogHeaderField(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return -1
+		}
+
+		return r
+	}, s)
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+var syslogParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func syslogParamValue(s string) string {
+	return syslogParamEscaper.Replace(s)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"time"
 
 	"github.com/superfly/flyctl/agent"
 	"github.com/superfly/flyctl/internal/flyutil"
@@ -23,11 +24,24 @@ type ConnectParams struct {
 	PromptInstance   bool
 	DisableSpinner   bool
 	Network          string
+	// UDP relays datagrams from a local UDP socket instead of TCP connections.
+	UDP bool
+	// UDPIdleTimeout is the IdleTimeout of the UDPServer, when UDP is set.
+	UDPIdleTimeout time.Duration
 }
 
 // Binds to a local port and runs a proxy to a remote address over Wireguard.
 // Blocks until context is cancelled.
 func Connect(ctx context.Context, p *ConnectParams) (err error) {
+	if p.UDP {
+		server, err := NewUDPServer(ctx, p)
+		if err != nil {
+			return err
+		}
+
+		return server.Serve(ctx)
+	}
+
 	server, err := NewServer(ctx, p)
 	if err != nil {
 		return err
@@ -55,46 +69,15 @@ func Start(ctx context.Context, p *ConnectParams) error {
 func NewServer(ctx context.Context, p *ConnectParams) (*Server, error) {
 	var (
 		io            = iostreams.FromContext(ctx)
-		client        = flyutil.ClientFromContext(ctx)
-		orgSlug       = p.OrganizationSlug
 		localBindAddr = p.BindAddr
 		localPort     = p.Ports[0]
-		remotePort    = localPort
-		remoteAddr    string
 	)
 
-	if len(p.Ports) > 1 {
-		remotePort = p.Ports[1]
-	}
-
-	agentclient, err := agent.Establish(ctx, client)
+	remoteAddr, err := resolveRemoteAddr(ctx, p)
 	if err != nil {
 		return nil, err
 	}
 
-	// Prompt for a specific instance and set it as the remote target
-	if p.PromptInstance {
-		instance, err := selectInstance(ctx, p.OrganizationSlug, p.AppName, agentclient)
-		if err != nil {
-			return nil, err
-		}
-
-		remoteAddr = fmt.Sprintf("[%s]:%s", instance, remotePort)
-	}
-
-	if remoteAddr == "" && p.RemoteHost != "" {
-
-		// If a host is specified that isn't an IpV6 address, assume it's a DNS entry and wait for that
-		// entry to resolve
-		if !ip.IsV6(p.RemoteHost) {
-			if err := agentclient.WaitForDNS(ctx, p.Dialer, orgSlug, p.RemoteHost, p.Network); err != nil {
-				return nil, fmt.Errorf("%s: %w", p.RemoteHost, err)
-			}
-		}
-
-		remoteAddr = fmt.Sprintf("[%s]:%s", p.RemoteHost, remotePort)
-	}
-
 	var listener net.Listener
 
 	if _, err := strconv.Atoi(localPort); err == nil {
@@ -134,6 +117,85 @@ func NewServer(ctx context.Context, p *ConnectParams) (*Server, error) {
 	}, nil
 }
 
+// NewUDPServer binds a local UDP socket to relay datagrams to a remote address over
+// Wireguard.
+func NewUDPServer(ctx context.Context, p *ConnectParams) (*UDPServer, error) {
+	var (
+		io        = iostreams.FromContext(ctx)
+		localPort = p.Ports[0]
+	)
+
+	if _, err := strconv.Atoi(localPort); err != nil {
+		return nil, fmt.Errorf("invalid local UDP port %q", localPort)
+	}
+
+	remoteAddr, err := resolveRemoteAddr(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenPacket("udp", net.JoinHostPort(p.BindAddr, localPort))
+	if err != nil {
+		return nil, err
+	}
+
+	if localPort == "0" {
+		localPort = strconv.Itoa(conn.LocalAddr().(*net.UDPAddr).Port)
+	}
+
+	fmt.Fprintf(io.Out, "Proxying UDP localhost:%s to remote %s\n", localPort, remoteAddr)
+
+	return &UDPServer{
+		Addr:        remoteAddr,
+		PacketConn:  conn,
+		Dial:        p.Dialer.DialContext,
+		IdleTimeout: p.UDPIdleTimeout,
+	}, nil
+}
+
+// resolveRemoteAddr returns the address to proxy to: the instance the user selects, or
+// the remote host once it resolves.
+func resolveRemoteAddr(ctx context.Context, p *ConnectParams) (string, error) {
+	var (
+		client     = flyutil.ClientFromContext(ctx)
+		orgSlug    = p.OrganizationSlug
+		remotePort = p.Ports[0]
+	)
+
+	if len(p.Ports) > 1 {
+		remotePort = p.Ports[1]
+	}
+
+	agentclient, err := agent.Establish(ctx, client)
+	if err != nil {
+		return "", err
+	}
+
+	// Prompt for a specific instance and set it as the remote target
+	if p.PromptInstance {
+		instance, err := selectInstance(ctx, p.OrganizationSlug, p.AppName, agentclient)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("[%s]:%s", instance, remotePort), nil
+	}
+
+	if p.RemoteHost == "" {
+		return "", nil
+	}
+
+	// If a host is specified that isn't an IpV6 address, assume it's a DNS entry and wait for that
+	// entry to resolve
+	if !ip.IsV6(p.RemoteHost) {
+		if err := agentclient.WaitForDNS(ctx, p.Dialer, orgSlug, p.RemoteHost, p.Network); err != nil {
+			return "", fmt.Errorf("%s: %w", p.RemoteHost, err)
+		}
+	}
+
+	return fmt.Sprintf("[%s]:%s", p.RemoteHost, remotePort), nil
+}
+
 func selectInstance(ctx context.Context, org, app string, c *agent.Client) (instance string, err error) {
 	instances, err := c.Instances(ctx, org, app)
 	if err != nil {
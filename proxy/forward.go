@@ -0,0 +1,275 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/superfly/flyctl/terminal"
+)
+
+// Protocols a ForwardProxy speaks with its clients.
+const (
+	ProtocolSOCKS5 = "socks5"
+	ProtocolHTTP   = "http"
+)
+
+// ForwardProxy is a SOCKS5 or HTTP CONNECT proxy: unlike Server, which forwards a local
+// port to a single remote address, its clients pick the host they reach with each
+// connection, so any number of private hosts can be reached through one listener.
+type ForwardProxy struct {
+	Protocol string
+	Listener net.Listener
+	// Resolve turns a host:port into an address Dial can reach.
+	Resolve func(ctx context.Context, addr string) (string, error)
+	Dial    func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// Serve accepts connections until ctx is cancelled.
+func (p *ForwardProxy) Serve(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		p.Listener.Close() //skipcq: GO-S2307
+	}()
+
+	for {
+		source, err := p.Listener.Accept()
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			return err
+		}
+		terminal.Debug("accepted new connection from: ", source.RemoteAddr())
+
+		wg.Go(func() {
+			defer source.Close() //skipcq: GO-S2307
+
+			if err := p.serveConn(ctx, source); err != nil {
+				terminal.Debug("proxied connection failed: ", err)
+			}
+		})
+	}
+}
+
+func (p *ForwardProxy) serveConn(ctx context.Context, source net.Conn) error {
+	// the reader may buffer bytes the client sent past the handshake
+	r := bufio.NewReader(source)
+
+	var (
+		target net.Conn
+		err    error
+	)
+	switch p.Protocol {
+	case ProtocolSOCKS5:
+		target, err = p.socks5Handshake(ctx, r, source)
+	case ProtocolHTTP:
+		target, err = p.httpConnectHandshake(ctx, r, source)
+	default:
+		err = fmt.Errorf("unsupported proxy protocol %q", p.Protocol)
+	}
+	if err != nil {
+		return err
+	}
+	defer target.Close() //skipcq: GO-S2307
+
+	// Serve waits for its connections, don't let the open ones hold it once ctx is done
+	stop := context.AfterFunc(ctx, func() {
+		source.Close() //skipcq: GO-S2307
+		target.Close() //skipcq: GO-S2307
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		io.Copy(target, r)
+		if conn, ok := target.(ClosableWrite); ok {
+			conn.CloseWrite()
+		}
+	})
+	wg.Go(func() {
+		io.Copy(source, target)
+		if conn, ok := source.(ClosableWrite); ok {
+			conn.CloseWrite()
+		}
+	})
+	wg.Wait()
+
+	terminal.Debug("connection closed")
+
+	return nil
+}
+
+// dial resolves and dials the host:port a client asked for.
+func (p *ForwardProxy) dial(ctx context.Context, addr string) (net.Conn, error) {
+	resolved, err := p.Resolve(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving %s: %w", addr, err)
+	}
+
+	conn, err := p.Dial(ctx, "tcp", resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to %s: %w", addr, err)
+	}
+
+	return conn, nil
+}
+
+// SOCKS5 protocol values, see RFC 1928.
+const (
+	socks5Version = 0x05
+
+	socks5NoAuth       = 0x00
+	socks5NoAcceptable = 0xff
+
+	socks5Connect = 0x01
+
+	socks5IPv4   = 0x01
+	socks5Domain = 0x03
+	socks5IPv6   = 0x04
+
+	socks5Succeeded          = 0x00
+	socks5HostUnreachable    = 0x04
+	socks5CommandUnsupported = 0x07
+	socks5AddressUnsupported = 0x08
+)
+
+// socks5BoundAddr is the address replies report the proxy bound to, 0.0.0.0:0: clients
+// have no use for it.
+var socks5BoundAddr = []byte{socks5IPv4, 0, 0, 0, 0, 0, 0}
+
+var errSOCKS5Version = errors.New("not a SOCKS5 client")
+
+func (p *ForwardProxy) socks5Handshake(ctx context.Context, r *bufio.Reader, w io.Writer) (net.Conn, error) {
+	// Greeting: version, then the authentication methods the client supports
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[0] != socks5Version {
+		return nil, errSOCKS5Version
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(r, methods); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(methods, socks5NoAuth) {
+		w.Write([]byte{socks5Version, socks5NoAcceptable})
+
+		return nil, errors.New("SOCKS5 client doesn't support connecting without authentication")
+	}
+	if _, err := w.Write([]byte{socks5Version, socks5NoAuth}); err != nil {
+		return nil, err
+	}
+
+	// Request: version, command, reserved, then the destination
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(r, request); err != nil {
+		return nil, err
+	}
+	if request[0] != socks5Version {
+		return nil, errSOCKS5Version
+	}
+
+	var host string
+	switch request[3] {
+	case socks5IPv4, socks5IPv6:
+		ip := make(net.IP, net.IPv4len)
+		if request[3] == socks5IPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return nil, err
+		}
+		host = ip.String()
+	case socks5Domain:
+		length, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		domain := make([]byte, length)
+		if _, err := io.ReadFull(r, domain); err != nil {
+			return nil, err
+		}
+		host = string(domain)
+	default:
+		socks5Reply(w, socks5AddressUnsupported)
+
+		return nil, fmt.Errorf("unsupported SOCKS5 address type %d", request[3])
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return nil, err
+	}
+
+	if request[1] != socks5Connect {
+		socks5Reply(w, socks5CommandUnsupported)
+
+		return nil, fmt.Errorf("unsupported SOCKS5 command %d, only CONNECT is", request[1])
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+	target, err := p.dial(ctx, addr)
+	if err != nil {
+		socks5Reply(w, socks5HostUnreachable)
+
+		return nil, err
+	}
+
+	if err := socks5Reply(w, socks5Succeeded); err != nil {
+		target.Close() //skipcq: GO-S2307
+
+		return nil, err
+	}
+
+	return target, nil
+}
+
+func socks5Reply(w io.Writer, status byte) error {
+	_, err := w.Write(append([]byte{socks5Version, status, 0x00}, socks5BoundAddr...))
+
+	return err
+}
+
+func (p *ForwardProxy) httpConnectHandshake(ctx context.Context, r *bufio.Reader, w io.Writer) (net.Conn, error) {
+	req, err := http.ReadRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Method != http.MethodConnect {
+		fmt.Fprintf(w, "HTTP/1.1 %d %s\r\nAllow: CONNECT\r\nConnection: close\r\n\r\n", http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+
+		return nil, fmt.Errorf("unsupported HTTP proxy method %s, only CONNECT is", req.Method)
+	}
+
+	target, err := p.dial(ctx, req.Host)
+	if err != nil {
+		fmt.Fprintf(w, "HTTP/1.1 %d %s\r\nConnection: close\r\n\r\n", http.StatusBadGateway, http.StatusText(http.StatusBadGateway))
+
+		return nil, err
+	}
+
+	if _, err := io.WriteString(w, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		target.Close() //skipcq: GO-S2307
+
+		return nil, err
+	}
+
+	return target, nil
+}
@@ -0,0 +1,135 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startForwardProxy runs a proxy in front of an echo server reachable as echo.internal:80.
+func startForwardProxy(t *testing.T, protocol string) string {
+	t.Helper()
+
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { echo.Close() })
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	p := &ForwardProxy{
+		Protocol: protocol,
+		Listener: listener,
+		Resolve: func(_ context.Context, addr string) (string, error) {
+			if addr != "echo.internal:80" {
+				return "", errors.New("no such host")
+			}
+
+			return echo.Addr().String(), nil
+		},
+		Dial: (&net.Dialer{}).DialContext,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- p.Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	return listener.Addr().String()
+}
+
+func assertEchoes(t *testing.T, conn net.Conn, r io.Reader) {
+	t.Helper()
+
+	_, err := conn.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(r, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+}
+
+func TestForwardProxySOCKS5(t *testing.T) {
+	addr := startForwardProxy(t, ProtocolSOCKS5)
+
+	connect := func(host string) (net.Conn, []byte) {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		_, err = conn.Write([]byte{0x05, 0x01, 0x00})
+		require.NoError(t, err)
+		greeting := make([]byte, 2)
+		_, err = io.ReadFull(conn, greeting)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x05, 0x00}, greeting)
+
+		request := append([]byte{0x05, 0x01, 0x00, 0x03, byte(len(host))}, host...)
+		_, err = conn.Write(append(request, 0x00, 80))
+		require.NoError(t, err)
+		reply := make([]byte, 10)
+		_, err = io.ReadFull(conn, reply)
+		require.NoError(t, err)
+
+		return conn, reply
+	}
+
+	conn, reply := connect("echo.internal")
+	assert.Equal(t, byte(0x00), reply[1])
+	assertEchoes(t, conn, conn)
+
+	_, reply = connect("nope.internal")
+	assert.Equal(t, byte(0x04), reply[1])
+}
+
+func TestForwardProxyHTTPConnect(t *testing.T) {
+	addr := startForwardProxy(t, ProtocolHTTP)
+
+	connect := func(method, host string) (net.Conn, *bufio.Reader, *http.Response) {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		_, err = conn.Write([]byte(method + " " + host + " HTTP/1.1\r\nHost: " + host + "\r\n\r\n"))
+		require.NoError(t, err)
+
+		r := bufio.NewReader(conn)
+		res, err := http.ReadResponse(r, nil)
+		require.NoError(t, err)
+
+		return conn, r, res
+	}
+
+	conn, r, res := connect(http.MethodConnect, "echo.internal:80")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assertEchoes(t, conn, r)
+
+	_, _, res = connect(http.MethodConnect, "nope.internal:80")
+	assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+
+	_, _, res = connect(http.MethodGet, "echo.internal:80")
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+}
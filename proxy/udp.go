@@ -0,0 +1,169 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/superfly/flyctl/terminal"
+)
+
+// DefaultUDPIdleTimeout is how long a UDPServer keeps the connection of a silent client.
+const DefaultUDPIdleTimeout = 2 * time.Minute
+
+// UDPServer relays datagrams between the clients of a local UDP socket and a remote
+// address. Each client gets its own connection to the remote address, so replies reach
+// the client they answer, and the connection closes once it has been idle for IdleTimeout.
+type UDPServer struct {
+	Addr       string
+	PacketConn net.PacketConn
+	Dial       func(ctx context.Context, network, addr string) (net.Conn, error)
+	// IdleTimeout defaults to DefaultUDPIdleTimeout.
+	IdleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*udpSession
+}
+
+type udpSession struct {
+	client net.Addr
+	conn   net.Conn
+	// lastActive is when the session last relayed a datagram, in Unix nanoseconds.
+	lastActive atomic.Int64
+}
+
+func (s *udpSession) touch() {
+	s.lastActive.Store(time.Now().UnixNano())
+}
+
+// Serve relays datagrams until ctx is cancelled.
+func (srv *UDPServer) Serve(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	idleTimeout := srv.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultUDPIdleTimeout
+	}
+
+	srv.mu.Lock()
+	srv.sessions = map[string]*udpSession{}
+	srv.mu.Unlock()
+
+	wg.Go(func() {
+		ticker := time.NewTicker(idleTimeout / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				srv.PacketConn.Close() //skipcq: GO-S2307
+				srv.closeSessions(func(*udpSession) bool { return true })
+
+				return
+			case now := <-ticker.C:
+				srv.closeSessions(func(s *udpSession) bool {
+					return now.Sub(time.Unix(0, s.lastActive.Load())) >= idleTimeout
+				})
+			}
+		}
+	})
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, client, err := srv.PacketConn.ReadFrom(buf)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		s := srv.session(client.String())
+		if s == nil {
+			// datagrams of other clients wait for the dial, which only happens once per client
+			if s, err = srv.open(ctx, client); err != nil {
+				terminal.Debug("failed to connect to target: ", err)
+
+				continue
+			}
+
+			wg.Go(func() {
+				srv.relayReplies(s)
+			})
+		}
+
+		s.touch()
+		if _, err := s.conn.Write(buf[:n]); err != nil {
+			terminal.Debug("failed relaying datagram: ", err)
+			srv.closeSession(s)
+		}
+	}
+}
+
+func (srv *UDPServer) session(key string) *udpSession {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return srv.sessions[key]
+}
+
+func (srv *UDPServer) open(ctx context.Context, client net.Addr) (*udpSession, error) {
+	conn, err := srv.Dial(ctx, "udp", srv.Addr)
+	if err != nil {
+		return nil, err
+	}
+	terminal.Debug("opened UDP session for: ", client)
+
+	s := &udpSession{client: client, conn: conn}
+	s.touch()
+
+	srv.mu.Lock()
+	srv.sessions[client.String()] = s
+	srv.mu.Unlock()
+
+	return s, nil
+}
+
+// relayReplies writes the datagrams the remote address sends back to the session's client,
+// until the session closes.
+func (srv *UDPServer) relayReplies(s *udpSession) {
+	defer srv.closeSession(s)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := s.conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		s.touch()
+		if _, err := srv.PacketConn.WriteTo(buf[:n], s.client); err != nil {
+			terminal.Debug("failed relaying reply: ", err)
+
+			return
+		}
+	}
+}
+
+func (srv *UDPServer) closeSession(s *udpSession) {
+	srv.closeSessions(func(other *udpSession) bool { return other == s })
+}
+
+func (srv *UDPServer) closeSessions(match func(*udpSession) bool) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for key, s := range srv.sessions {
+		if match(s) {
+			delete(srv.sessions, key)
+			s.conn.Close() //skipcq: GO-S2307
+			terminal.Debug("closed UDP session for: ", s.client)
+		}
+	}
+}
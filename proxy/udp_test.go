@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUDPServer(t *testing.T) {
+	// echo replies with the datagrams it gets, prefixed with the address they came from
+	echo, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { echo.Close() })
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := echo.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			echo.WriteTo(append([]byte(addr.String()+" "), buf[:n]...), addr)
+		}
+	}()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &UDPServer{
+		Addr:        echo.LocalAddr().String(),
+		PacketConn:  conn,
+		Dial:        (&net.Dialer{}).DialContext,
+		IdleTimeout: 200 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- srv.Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	exchange := func(client net.Conn, msg string) string {
+		t.Helper()
+
+		_, err := client.Write([]byte(msg))
+		require.NoError(t, err)
+
+		require.NoError(t, client.SetReadDeadline(time.Now().Add(5*time.Second)))
+		buf := make([]byte, 1500)
+		n, err := client.Read(buf)
+		require.NoError(t, err)
+
+		return string(buf[:n])
+	}
+
+	dial := func() net.Conn {
+		client, err := net.Dial("udp", conn.LocalAddr().String())
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+
+		return client
+	}
+
+	// Each client gets its own session, which keeps its source address
+	first, second := dial(), dial()
+	firstReply := exchange(first, "one")
+	secondReply := exchange(second, "two")
+	assert.Regexp(t, `^127\.0\.0\.1:\d+ one$`, firstReply)
+	assert.Regexp(t, `^127\.0\.0\.1:\d+ two$`, secondReply)
+	assert.NotEqual(t, firstReply[:len(firstReply)-4], secondReply[:len(secondReply)-4])
+	assert.Equal(t, firstReply[:len(firstReply)-4]+" again", exchange(first, "again"))
+
+	srv.mu.Lock()
+	assert.Len(t, srv.sessions, 2)
+	srv.mu.Unlock()
+
+	// Idle sessions close
+	assert.Eventually(t, func() bool {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+
+		return len(srv.sessions) == 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	assert.Regexp(t, `^127\.0\.0\.1:\d+ back$`, exchange(first, "back"))
+}
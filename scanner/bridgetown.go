@@ -1,6 +1,10 @@
 package scanner
 
-import "github.com/pkg/errors"
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
 
 func configureBridgetown(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 	if !checksPass(sourceDir, dirContains("Gemfile", "bridgetown")) {
@@ -18,7 +22,7 @@ func configureBridgetown(sourceDir string, _ *ScannerConfig) (*SourceInfo, error
 		},
 	}
 
-	rubyVersion, err := extractRubyVersion("Gemfile.lock", "Gemfile", ".ruby_version")
+	rubyVersion, err := extractRubyVersion(filepath.Join(sourceDir, "Gemfile.lock"), filepath.Join(sourceDir, "Gemfile"), filepath.Join(sourceDir, ".ruby_version"))
 	if err != nil {
 		return nil, errors.Wrap(err, "failure extracting Ruby version")
 	}
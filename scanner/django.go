@@ -53,7 +53,7 @@ func configureDjango(sourceDir string, config *ScannerConfig) (*SourceInfo, erro
 	pythonLatestSupported := "3.9.0"
 	pythonVersion := "3.12"
 
-	pythonFullVersion, pinned, err := extractPythonVersion()
+	pythonFullVersion, pinned, err := extractPythonVersion(sourceDir)
 
 	if err == nil && pythonFullVersion != "" {
 		if pinned {
@@ -103,7 +103,7 @@ Make sure to update the Dockerfile to use an image that is compatible with the P
 		vars["poetry"] = true
 	}
 
-	wsgiFiles, err := zglob.Glob(`./**/wsgi.py`)
+	wsgiFiles, err := globSource(sourceDir, `./**/wsgi.py`)
 
 	if err == nil && len(wsgiFiles) > 0 {
 		var wsgiFilesProject []string
@@ -165,7 +165,7 @@ This module is used on Dockerfile to start the Gunicorn server process.
 		s.ObjectStorageDesired = true
 	}
 
-	asgiFiles, err := zglob.Glob(`./**/asgi.py`)
+	asgiFiles, err := globSource(sourceDir, `./**/asgi.py`)
 
 	if err == nil && len(asgiFiles) > 0 {
 		var asgiFilesProject []string
@@ -200,11 +200,11 @@ This module is used on Dockerfile to start the Daphne server process.
 	}
 
 	// check if settings.py file exists
-	allSettingsFiles, err := zglob.Glob(`./**/settings.py`)
+	allSettingsFiles, err := globSource(sourceDir, `./**/settings.py`)
 
 	if err == nil && len(allSettingsFiles) == 0 {
 		// if no settings.py files are found, check if any *prod*.py (e.g. production.py, prod.py, settings_prod.py) exists in 'settings/' folder
-		allSettingsFiles, err = zglob.Glob(`./**/settings/*prod*.py`)
+		allSettingsFiles, err = globSource(sourceDir, `./**/settings/*prod*.py`)
 	}
 	var settingsFiles []string
 	if err == nil && len(allSettingsFiles) > 0 {
@@ -330,17 +330,18 @@ For detailed documentation, see https://fly.dev/docs/django/
 	}
 
 	// Perform a glob search for */bin/activate
-	path := filepath.Join("*", "bin", "activate")
-	matches, err := filepath.Glob(path)
+	matches, err := filepath.Glob(filepath.Join(sourceDir, "*", "bin", "activate"))
 	if err != nil {
 		return nil, err
 	}
 
 	// If we find a virtual environment, set the venv flag and the venvdir variable
 	if len(matches) == 1 {
-		vars["venv"] = true
-		segments := strings.Split(matches[0], string(os.PathSeparator))
-		vars["venvdir"] = segments[0]
+		if venv, err := filepath.Rel(sourceDir, matches[0]); err == nil {
+			vars["venv"] = true
+			segments := strings.Split(venv, string(os.PathSeparator))
+			vars["venvdir"] = segments[0]
+		}
 	}
 
 	s.Files = templatesExecute("templates/django", vars)
@@ -349,3 +350,18 @@ For detailed documentation, see https://fly.dev/docs/django/
 
 	return s, nil
 }
+
+// globSource returns the paths of sourceDir matching pattern, relative to sourceDir.
+func globSource(sourceDir, pattern string) ([]string, error) {
+	matches, err := zglob.Glob(filepath.Join(sourceDir, pattern))
+	if err != nil {
+		return nil, err
+	}
+	for i, match := range matches {
+		if matches[i], err = filepath.Rel(sourceDir, match); err != nil {
+			return nil, err
+		}
+	}
+
+	return matches, nil
+}
@@ -21,7 +21,7 @@ func configureFlask(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 
 	// Extract Python version
 	// TODO: support pinned versions
-	pythonFullVersion, _, err := extractPythonVersion()
+	pythonFullVersion, _, err := extractPythonVersion(sourceDir)
 	if err != nil {
 		return nil, err
 	} else if pythonFullVersion == "" {
@@ -3,6 +3,7 @@ package scanner
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/superfly/flyctl/internal/command/launch/plan"
 	"github.com/superfly/flyctl/terminal"
@@ -18,12 +19,12 @@ func configureGo(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
 
 	var skipDeploy bool
 
-	if !absFileExists("go.sum") {
+	if !absFileExists(filepath.Join(sourceDir, "go.sum")) {
 		vars["skipGoSum"] = true
 		skipDeploy = true
 	}
 
-	gomod, parseErr := parseModfile()
+	gomod, parseErr := parseModfile(sourceDir)
 
 	version := "1"
 	if parseErr != nil {
@@ -49,8 +50,8 @@ func configureGo(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
 	return s, nil
 }
 
-func parseModfile() (*modfile.File, error) {
-	dat, err := os.ReadFile("go.mod")
+func parseModfile(sourceDir string) (*modfile.File, error) {
+	dat, err := os.ReadFile(filepath.Join(sourceDir, "go.mod"))
 	if err != nil {
 		return nil, fmt.Errorf("could not open go.mod: %w", err)
 	}
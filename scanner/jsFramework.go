@@ -19,9 +19,9 @@ import (
 
 var packageJson map[string]any
 
-// detectPortFromSource scans common entry point files for port definitions
+// detectPortFromSource scans common entry point files of sourceDir for port definitions
 // Returns the detected port or 0 if not found
-func detectPortFromSource() int {
+func detectPortFromSource(sourceDir string) int {
 	// Common entry point files to check
 	entryPoints := []string{"server.js", "index.js", "app.js", "src/server.js", "src/index.js", "src/app.js"}
 
@@ -36,7 +36,7 @@ func detectPortFromSource() int {
 	}
 
 	for _, entryPoint := range entryPoints {
-		data, err := os.ReadFile(entryPoint)
+		data, err := os.ReadFile(filepath.Join(sourceDir, entryPoint))
 		if err != nil {
 			continue
 		}
@@ -70,7 +70,7 @@ func configureJsFramework(sourceDir string, config *ScannerConfig) (*SourceInfo,
 	}
 
 	// ensure package.json has a main, module, or start script
-	data, err := os.ReadFile("package.json")
+	data, err := os.ReadFile(filepath.Join(sourceDir, "package.json"))
 
 	if err != nil {
 		return nil, nil
@@ -115,7 +115,7 @@ func configureJsFramework(sourceDir string, config *ScannerConfig) (*SourceInfo,
 		Callback: JsFrameworkCallback,
 	}
 
-	_, err = os.Stat("bun.lockb")
+	_, err = os.Stat(filepath.Join(sourceDir, "bun.lockb"))
 	if errors.Is(err, fs.ErrNotExist) {
 		// ensure node is in $PATH
 		node, err := exec.LookPath("node")
@@ -130,7 +130,9 @@ func configureJsFramework(sourceDir string, config *ScannerConfig) (*SourceInfo,
 		}
 
 		// ensure node version is at least 16.0.0
-		out, err := exec.Command(node, "-v").Output()
+		cmd := exec.Command(node, "-v")
+		cmd.Dir = sourceDir
+		out, err := cmd.Output()
 		if err != nil {
 			return nil, nil
 		} else {
@@ -167,7 +169,9 @@ func configureJsFramework(sourceDir string, config *ScannerConfig) (*SourceInfo,
 
 		// ensure bun version is at least 0.5.3, as that's when docker images started
 		// getting published: https://hub.docker.com/r/oven/bun/tags
-		out, err := exec.Command(bun, "-v").Output()
+		cmd := exec.Command(bun, "-v")
+		cmd.Dir = sourceDir
+		out, err := cmd.Output()
 		if err != nil {
 			return nil, nil
 		} else {
@@ -241,7 +245,7 @@ func configureJsFramework(sourceDir string, config *ScannerConfig) (*SourceInfo,
 	}
 
 	// etract port from EXPOSE statement in dockerfile
-	dockerfile, err := os.ReadFile("Dockerfile")
+	dockerfile, err := os.ReadFile(filepath.Join(sourceDir, "Dockerfile"))
 	if err == nil {
 		m := portRegex.FindStringSubmatch(string(dockerfile))
 
@@ -299,7 +303,7 @@ func configureJsFramework(sourceDir string, config *ScannerConfig) (*SourceInfo,
 
 	// Try to detect port from source code if not found in Dockerfile
 	if srcInfo.Port == 0 {
-		if detectedPort := detectPortFromSource(); detectedPort != 0 {
+		if detectedPort := detectPortFromSource(sourceDir); detectedPort != 0 {
 			srcInfo.Port = detectedPort
 		}
 	}
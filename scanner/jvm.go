@@ -0,0 +1,269 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/docker/go-units"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/command/launch/plan"
+)
+
+// jvmFramework is a JVM framework the JVM scanner recognizes by the dependencies of
+// a project.
+type jvmFramework struct {
+	family string
+	// marker is the group of the framework's dependencies and plugins
+	marker string
+	// health is the dependency providing a health endpoint, served at healthPath
+	health     string
+	healthPath string
+	// gradleTask builds a runnable jar with Gradle, mavenJar and gradleJar are where
+	// Maven and Gradle put it
+	gradleTask string
+	mavenJar   string
+	gradleJar  string
+}
+
+var jvmFrameworks = []jvmFramework{
+	{
+		family:     "Spring Boot",
+		marker:     "org.springframework.boot",
+		health:     "spring-boot-starter-actuator",
+		healthPath: "/actuator/health",
+		gradleTask: "bootJar",
+		mavenJar:   "target/*.jar",
+		gradleJar:  "build/libs/*.jar",
+	},
+	{
+		// Quarkus builds a directory rather than a jar, see quarkusApp
+		family:     "Quarkus",
+		marker:     "io.quarkus",
+		health:     "quarkus-smallrye-health",
+		healthPath: "/q/health",
+		gradleTask: "quarkusBuild",
+	},
+	{
+		family:     "Micronaut",
+		marker:     "io.micronaut",
+		health:     "micronaut-management",
+		healthPath: "/health",
+		gradleTask: "shadowJar",
+		mavenJar:   "target/*.jar",
+		gradleJar:  "build/libs/*-all.jar",
+	},
+	{
+		family:     "Ktor",
+		marker:     "io.ktor",
+		gradleTask: "buildFatJar",
+		mavenJar:   "target/*-jar-with-dependencies.jar",
+		gradleJar:  "build/libs/*-all.jar",
+	},
+}
+
+var (
+	jvmPostgresPattern = regexp.MustCompile(`postgresql|pg-client`)
+	jvmRedisPattern    = regexp.MustCompile(`redis|jedis|lettuce`)
+
+	mavenJavaVersionPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`<java\.version>\s*(?:1\.)?(\d+)`),
+		regexp.MustCompile(`<maven\.compiler\.(?:release|target|source)>\s*(?:1\.)?(\d+)`),
+		regexp.MustCompile(`<release>\s*(\d+)\s*</release>`),
+	}
+	gradleJavaVersionPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`JavaLanguageVersion\.of\(\s*(\d+)`),
+		regexp.MustCompile(`jvmToolchain\(\s*(\d+)`),
+		regexp.MustCompile(`JavaVersion\.VERSION_(?:1_)?(\d+)`),
+		regexp.MustCompile(`(?:source|target)Compatibility\s*=\s*['"]?(?:1\.)?(\d+)`),
+	}
+)
+
+// defaultJavaVersion is the Java version of projects that don't tell theirs.
+const defaultJavaVersion = "21"
+
+func configureJvm(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
+	var (
+		buildTool, buildFiles string
+		versionPatterns       []*regexp.Regexp
+	)
+
+	switch {
+	case checksPass(sourceDir, fileExists("pom.xml")):
+		buildTool = "maven"
+		buildFiles = readJvmFiles(sourceDir, "pom.xml")
+		versionPatterns = mavenJavaVersionPatterns
+	case checksPass(sourceDir, fileExists("build.gradle", "build.gradle.kts")):
+		buildTool = "gradle"
+		buildFiles = readJvmFiles(sourceDir, "build.gradle", "build.gradle.kts", filepath.Join("gradle", "libs.versions.toml"))
+		versionPatterns = gradleJavaVersionPatterns
+	default:
+		return nil, nil
+	}
+
+	var framework *jvmFramework
+	for i := range jvmFrameworks {
+		if strings.Contains(buildFiles, jvmFrameworks[i].marker) {
+			framework = &jvmFrameworks[i]
+
+			break
+		}
+	}
+	if framework == nil {
+		return nil, nil
+	}
+
+	javaVersion := jvmJavaVersion(sourceDir, buildFiles, versionPatterns)
+
+	vars := map[string]any{
+		"javaVersion": javaVersion,
+		"buildTool":   buildTool,
+	}
+
+	switch buildTool {
+	case "maven":
+		vars["cacheDir"] = "/root/.m2"
+		vars["buildImage"] = "maven:3-eclipse-temurin-${JAVA_VERSION}"
+		vars["buildCommand"] = "mvn -B package -DskipTests"
+		vars["jarGlob"] = framework.mavenJar
+		if checksPass(sourceDir, fileExists("mvnw")) {
+			vars["wrapper"] = true
+			vars["buildTool"] = "./mvnw"
+			vars["buildImage"] = "eclipse-temurin:${JAVA_VERSION}-jdk"
+			vars["buildCommand"] = "./mvnw -B package -DskipTests"
+		}
+		if framework.family == "Quarkus" {
+			vars["quarkusApp"] = "target/quarkus-app/"
+		}
+	case "gradle":
+		vars["cacheDir"] = "/home/gradle/.gradle"
+		vars["buildImage"] = "gradle:jdk${JAVA_VERSION}"
+		vars["buildCommand"] = "gradle --no-daemon " + framework.gradleTask + " -x test"
+		vars["jarGlob"] = framework.gradleJar
+		if checksPass(sourceDir, fileExists("gradlew")) {
+			vars["wrapper"] = true
+			vars["buildTool"] = "./gradlew"
+			vars["cacheDir"] = "/root/.gradle"
+			vars["buildImage"] = "eclipse-temurin:${JAVA_VERSION}-jdk"
+			vars["buildCommand"] = "./gradlew --no-daemon " + framework.gradleTask + " -x test"
+		}
+		if framework.family == "Quarkus" {
+			vars["quarkusApp"] = "build/quarkus-app/"
+		}
+	}
+
+	s := &SourceInfo{
+		Files:  templatesExecute("templates/jvm", vars),
+		Family: framework.family,
+		Port:   8080,
+		Env: map[string]string{
+			"PORT": "8080",
+		},
+		Runtime:  plan.RuntimeStruct{Language: "java", Version: javaVersion},
+		Callback: JvmCallback,
+	}
+
+	if framework.health != "" && strings.Contains(buildFiles, framework.health) {
+		s.HttpCheckPath = framework.healthPath
+	}
+
+	dependencies := strings.ToLower(buildFiles)
+	if jvmPostgresPattern.MatchString(dependencies) {
+		s.DatabaseDesired = DatabaseKindPostgres
+	}
+	if jvmRedisPattern.MatchString(dependencies) {
+		s.RedisDesired = true
+	}
+
+	return s, nil
+}
+
+// readJvmFiles returns the contents of the files of sourceDir that exist, concatenated.
+func readJvmFiles(sourceDir string, names ...string) string {
+	var contents strings.Builder
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(sourceDir, name))
+		if err != nil {
+			continue
+		}
+		contents.Write(data)
+		contents.WriteString("\n")
+	}
+
+	return contents.String()
+}
+
+// jvmJavaVersion returns the major Java version of a project, as of .java-version or
+// its build files.
+func jvmJavaVersion(sourceDir, buildFiles string, patterns []*regexp.Regexp) string {
+	if data, err := os.ReadFile(filepath.Join(sourceDir, ".java-version")); err == nil {
+		version := strings.TrimPrefix(strings.TrimSpace(string(data)), "1.")
+		if major, _, _ := strings.Cut(version, "."); major != "" {
+			return major
+		}
+	}
+
+	for _, pattern := range patterns {
+		if m := pattern.FindStringSubmatch(buildFiles); m != nil {
+			return m[1]
+		}
+	}
+
+	return defaultJavaVersion
+}
+
+// JvmCallback sizes the heap of the JVM to the memory of the machines of the plan.
+func JvmCallback(appName string, srcInfo *SourceInfo, plan *plan.LaunchPlan, flags []string) error {
+	memoryMB := launchPlanMemoryMB(plan)
+	if memoryMB == 0 {
+		return nil
+	}
+
+	if srcInfo.Env == nil {
+		srcInfo.Env = map[string]string{}
+	}
+	srcInfo.Env["JAVA_TOOL_OPTIONS"] = jvmMemoryOptions(memoryMB)
+
+	return nil
+}
+
+// launchPlanMemoryMB returns the memory of the machines of p, or 0 when it can't tell.
+func launchPlanMemoryMB(p *plan.LaunchPlan) int {
+	if len(p.Compute) > 0 {
+		compute := p.Compute[0]
+		if compute.Memory != "" {
+			if mb, err := helpers.ParseSize(compute.Memory, units.RAMInBytes, units.MiB); err == nil && mb > 0 {
+				return mb
+			}
+		}
+		if compute.MachineGuest != nil && compute.MemoryMB > 0 {
+			return compute.MemoryMB
+		}
+		if preset, ok := fly.MachinePresets[compute.Size]; ok {
+			return preset.MemoryMB
+		}
+	}
+
+	return p.MemoryMB
+}
+
+// jvmMemoryOptions returns the JVM flags fitting a JVM in a machine of memoryMB: the
+// heap gets three quarters of it, leaving at least 128MB to metaspace, code cache and
+// thread stacks.
+func jvmMemoryOptions(memoryMB int) string {
+	heapMB := memoryMB - max(memoryMB/4, 128)
+	if heapMB < 64 {
+		heapMB = 64
+	}
+
+	options := fmt.Sprintf("-Xmx%dm -XX:+ExitOnOutOfMemoryError", heapMB)
+	if memoryMB <= 512 {
+		// smaller thread stacks leave room for more threads in small machines
+		options += " -Xss512k"
+	}
+
+	return options
+}
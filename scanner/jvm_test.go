@@ -0,0 +1,165 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command/launch/plan"
+)
+
+func writeJvmProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+
+	return dir
+}
+
+func dockerfileOf(t *testing.T, si *SourceInfo) string {
+	t.Helper()
+
+	for _, f := range si.Files {
+		if f.Path == "Dockerfile" {
+			return string(f.Contents)
+		}
+	}
+	require.Fail(t, "no Dockerfile")
+
+	return ""
+}
+
+func TestConfigureJvmSpringBootMaven(t *testing.T) {
+	dir := writeJvmProject(t, map[string]string{
+		"mvnw": "#!/bin/sh",
+		"pom.xml": `<project>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+  </parent>
+  <properties>
+    <java.version>17</java.version>
+  </properties>
+  <dependencies>
+    <dependency><artifactId>spring-boot-starter-actuator</artifactId></dependency>
+    <dependency><groupId>org.postgresql</groupId><artifactId>postgresql</artifactId></dependency>
+  </dependencies>
+</project>`,
+	})
+
+	si, err := configureJvm(dir, &ScannerConfig{})
+	require.NoError(t, err)
+	require.NotNil(t, si)
+
+	assert.Equal(t, "Spring Boot", si.Family)
+	assert.Equal(t, 8080, si.Port)
+	assert.Equal(t, "/actuator/health", si.HttpCheckPath)
+	assert.Equal(t, DatabaseKindPostgres, si.DatabaseDesired)
+	assert.False(t, si.RedisDesired)
+	assert.Equal(t, plan.RuntimeStruct{Language: "java", Version: "17"}, si.Runtime)
+
+	dockerfile := dockerfileOf(t, si)
+	assert.Contains(t, dockerfile, "ARG JAVA_VERSION=17")
+	assert.Contains(t, dockerfile, "FROM eclipse-temurin:${JAVA_VERSION}-jdk AS build")
+	assert.Contains(t, dockerfile, "./mvnw -B package -DskipTests")
+	assert.Contains(t, dockerfile, `CMD [ "java", "-jar", "app.jar" ]`)
+}
+
+func TestConfigureJvmQuarkusGradleKotlin(t *testing.T) {
+	dir := writeJvmProject(t, map[string]string{
+		"build.gradle.kts": `plugins {
+    id("io.quarkus")
+}
+dependencies {
+    implementation("io.quarkus:quarkus-redis-client")
+}
+java {
+    toolchain { languageVersion.set(JavaLanguageVersion.of(21)) }
+}`,
+	})
+
+	si, err := configureJvm(dir, &ScannerConfig{})
+	require.NoError(t, err)
+	require.NotNil(t, si)
+
+	assert.Equal(t, "Quarkus", si.Family)
+	assert.Empty(t, si.HttpCheckPath)
+	assert.True(t, si.RedisDesired)
+	assert.Equal(t, DatabaseKindNone, si.DatabaseDesired)
+
+	dockerfile := dockerfileOf(t, si)
+	assert.Contains(t, dockerfile, "ARG JAVA_VERSION=21")
+	assert.Contains(t, dockerfile, "FROM gradle:jdk${JAVA_VERSION} AS build")
+	assert.Contains(t, dockerfile, "gradle --no-daemon quarkusBuild -x test")
+	assert.Contains(t, dockerfile, "COPY --from=build /app/build/quarkus-app/ ./")
+	assert.NotContains(t, dockerfile, "app.jar")
+}
+
+func TestConfigureJvmVersionCatalogAndJavaVersionFile(t *testing.T) {
+	dir := writeJvmProject(t, map[string]string{
+		"gradlew":                   "#!/bin/sh",
+		"build.gradle.kts":          `plugins { alias(libs.plugins.ktor) }`,
+		"gradle/libs.versions.toml": `ktor = { id = "io.ktor.plugin", version = "3.0.0" }`,
+		".java-version":             "11.0.2\n",
+	})
+
+	si, err := configureJvm(dir, &ScannerConfig{})
+	require.NoError(t, err)
+	require.NotNil(t, si)
+
+	assert.Equal(t, "Ktor", si.Family)
+	assert.Equal(t, "11", si.Runtime.Version)
+
+	dockerfile := dockerfileOf(t, si)
+	assert.Contains(t, dockerfile, "RUN chmod +x ./gradlew")
+	assert.Contains(t, dockerfile, "./gradlew --no-daemon buildFatJar -x test")
+	assert.Contains(t, dockerfile, "build/libs/*-all.jar")
+}
+
+func TestConfigureJvmSkipsOtherProjects(t *testing.T) {
+	for _, files := range []map[string]string{
+		{"pom.xml": `<project><groupId>com.example</groupId></project>`},
+		{"package.json": `{}`},
+	} {
+		si, err := configureJvm(writeJvmProject(t, files), &ScannerConfig{})
+		require.NoError(t, err)
+		assert.Nil(t, si)
+	}
+}
+
+func TestJvmCallback(t *testing.T) {
+	for _, tc := range []struct {
+		plan *plan.LaunchPlan
+		want string
+	}{
+		{
+			plan: &plan.LaunchPlan{Compute: []*appconfig.Compute{{Memory: "1gb"}}},
+			want: "-Xmx768m -XX:+ExitOnOutOfMemoryError",
+		},
+		{
+			plan: &plan.LaunchPlan{Compute: []*appconfig.Compute{{Size: "shared-cpu-1x"}}},
+			want: "-Xmx128m -XX:+ExitOnOutOfMemoryError -Xss512k",
+		},
+		{
+			plan: &plan.LaunchPlan{MemoryMB: 512},
+			want: "-Xmx384m -XX:+ExitOnOutOfMemoryError -Xss512k",
+		},
+	} {
+		si := &SourceInfo{}
+		require.NoError(t, JvmCallback("app", si, tc.plan, nil))
+		assert.Equal(t, tc.want, si.Env["JAVA_TOOL_OPTIONS"])
+	}
+
+	si := &SourceInfo{}
+	require.NoError(t, JvmCallback("app", si, &plan.LaunchPlan{}, nil))
+	assert.NotContains(t, si.Env, "JAVA_TOOL_OPTIONS")
+}
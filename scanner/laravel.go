@@ -61,7 +61,7 @@ func configureLaravel(sourceDir string, config *ScannerConfig) (*SourceInfo, err
 	}
 
 	// The detected PHP version
-	phpVersion, err := extractPhpVersion()
+	phpVersion, err := extractPhpVersion(sourceDir)
 	if err != nil || phpVersion == "" {
 		// Fallback to 8.0, which has
 		// the broadest compatibility
@@ -85,7 +85,7 @@ func configureLaravel(sourceDir string, config *ScannerConfig) (*SourceInfo, err
 	}
 
 	// Extract DB, Redis config from dotenv
-	db, redis, skipDB := extractConnections(".env")
+	db, redis, skipDB := extractConnections(filepath.Join(sourceDir, ".env"))
 	s.SkipDatabase = skipDB
 	s.RedisDesired = redis
 	if db != 0 {
@@ -209,13 +209,13 @@ Now: run 'fly deploy' to deploy your %s app.
 	return nil
 }
 
-func extractPhpVersion() (string, error) {
+func extractPhpVersion(sourceDir string) (string, error) {
 	/* VIA composer.json file */
 	// Capture major/minor version (leaving out revision version)
 	re := regexp.MustCompile(`([0-9]+\.[0-9]+)`)
 	var match = re.FindStringSubmatch("")
 
-	data, err := os.ReadFile("composer.json")
+	data, err := os.ReadFile(filepath.Join(sourceDir, "composer.json"))
 	if err == nil {
 		var composerJson map[string]any
 		err = json.Unmarshal(data, &composerJson)
@@ -237,6 +237,7 @@ func extractPhpVersion() (string, error) {
 			with Zend OPcache v8.1.8, Copyright (c), by Zend Technologies
 		*/
 		cmd := exec.Command("php", "-v")
+		cmd.Dir = sourceDir
 		out, err := cmd.CombinedOutput()
 		if err != nil {
 			return "", err
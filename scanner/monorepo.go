@@ -0,0 +1,463 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+// Monorepo is a workspace of packages sharing a repository, such as a pnpm, npm or
+// yarn workspace, a Cargo workspace or a Go workspace.
+type Monorepo struct {
+	// Tool is the tool managing the workspace: pnpm, yarn, npm, cargo or go.
+	Tool string
+	// Turbo tells whether the workspace builds with Turborepo.
+	Turbo bool
+	// Root is the directory of the workspace.
+	Root string
+	// Packages are the members of the workspace, sorted by path.
+	Packages []MonorepoPackage
+
+	goVersion string
+}
+
+// MonorepoPackage is a member of a Monorepo.
+type MonorepoPackage struct {
+	// Name is the name of the package in its manifest, or the name of its directory.
+	Name string
+	// Path is the directory of the package, relative to the workspace root, in slash
+	// separated form.
+	Path string
+}
+
+// DetectMonorepo returns the workspace rooted at sourceDir, or nil when sourceDir
+// isn't the root of a workspace of several packages.
+func DetectMonorepo(sourceDir string) (*Monorepo, error) {
+	detectors := []func(string) (*Monorepo, error){
+		detectJsMonorepo,
+		detectCargoMonorepo,
+		detectGoMonorepo,
+	}
+
+	for _, detect := range detectors {
+		m, err := detect(sourceDir)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil && len(m.Packages) > 0 {
+			return m, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Package returns the package of m named name, or whose path is name.
+func (m *Monorepo) Package(name string) (*MonorepoPackage, error) {
+	clean := path.Clean(filepath.ToSlash(name))
+	for i, pkg := range m.Packages {
+		if pkg.Name == name || pkg.Path == clean {
+			return &m.Packages[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s workspace has no package %s, choose one of %s", m.Tool, name, strings.Join(m.PackageNames(), ", "))
+}
+
+// PackageNames returns the names of the packages of m, in order.
+func (m *Monorepo) PackageNames() []string {
+	names := make([]string, 0, len(m.Packages))
+	for _, pkg := range m.Packages {
+		names = append(names, pkg.Name)
+	}
+
+	return names
+}
+
+func detectJsMonorepo(sourceDir string) (*Monorepo, error) {
+	var patterns []string
+	m := &Monorepo{Root: sourceDir}
+
+	if data, err := os.ReadFile(filepath.Join(sourceDir, "pnpm-workspace.yaml")); err == nil {
+		var workspace struct {
+			Packages []string `yaml:"packages"`
+		}
+		if err := yaml.Unmarshal(data, &workspace); err != nil {
+			return nil, fmt.Errorf("failed parsing pnpm-workspace.yaml: %w", err)
+		}
+		m.Tool = "pnpm"
+		patterns = workspace.Packages
+	} else if data, err := os.ReadFile(filepath.Join(sourceDir, "package.json")); err == nil {
+		var manifest struct {
+			// either a list of globs or an object with a packages list of globs
+			Workspaces json.RawMessage `json:"workspaces"`
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("failed parsing package.json: %w", err)
+		}
+		if len(manifest.Workspaces) == 0 {
+			return nil, nil
+		}
+		if err := json.Unmarshal(manifest.Workspaces, &patterns); err != nil {
+			var workspaces struct {
+				Packages []string `json:"packages"`
+			}
+			if err := json.Unmarshal(manifest.Workspaces, &workspaces); err != nil {
+				return nil, fmt.Errorf("failed parsing the workspaces of package.json: %w", err)
+			}
+			patterns = workspaces.Packages
+		}
+
+		m.Tool = "npm"
+		if absFileExists(filepath.Join(sourceDir, "yarn.lock")) {
+			m.Tool = "yarn"
+		}
+	} else {
+		return nil, nil
+	}
+
+	m.Turbo = absFileExists(filepath.Join(sourceDir, "turbo.json"))
+
+	var err error
+	m.Packages, err = globPackages(sourceDir, patterns, "package.json", func(dir string) string {
+		var manifest struct {
+			Name string `json:"name"`
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+			_ = json.Unmarshal(data, &manifest)
+		}
+
+		return manifest.Name
+	})
+
+	return m, err
+}
+
+func detectCargoMonorepo(sourceDir string) (*Monorepo, error) {
+	if !absFileExists(filepath.Join(sourceDir, "Cargo.toml")) {
+		return nil, nil
+	}
+
+	cargo, err := readTomlFile(filepath.Join(sourceDir, "Cargo.toml"))
+	if err != nil {
+		return nil, err
+	}
+	workspace, ok := cargo["workspace"].(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+
+	members := toStrings(workspace["members"])
+	for _, exclude := range toStrings(workspace["exclude"]) {
+		members = append(members, "!"+exclude)
+	}
+
+	m := &Monorepo{Tool: "cargo", Root: sourceDir}
+	m.Packages, err = globPackages(sourceDir, members, "Cargo.toml", func(dir string) string {
+		data, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+		if err != nil {
+			return ""
+		}
+
+		var manifest struct {
+			Package struct {
+				Name string `toml:"name"`
+			} `toml:"package"`
+		}
+		_ = toml.Unmarshal(data, &manifest)
+
+		return manifest.Package.Name
+	})
+
+	return m, err
+}
+
+func detectGoMonorepo(sourceDir string) (*Monorepo, error) {
+	data, err := os.ReadFile(filepath.Join(sourceDir, "go.work"))
+	if err != nil {
+		return nil, nil
+	}
+
+	work, err := modfile.ParseWork("go.work", data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing go.work: %w", err)
+	}
+
+	m := &Monorepo{Tool: "go", Root: sourceDir, goVersion: "1"}
+	if work.Go != nil {
+		m.goVersion = work.Go.Version
+	}
+
+	for _, use := range work.Use {
+		dir := path.Clean(filepath.ToSlash(use.Path))
+		if dir == "." || strings.HasPrefix(dir, "../") || path.IsAbs(dir) {
+			continue
+		}
+
+		gomod, err := os.ReadFile(filepath.Join(sourceDir, filepath.FromSlash(dir), "go.mod"))
+		if err != nil {
+			continue
+		}
+		name := modfile.ModulePath(gomod)
+		if name == "" {
+			name = path.Base(dir)
+		}
+
+		m.Packages = append(m.Packages, MonorepoPackage{Name: name, Path: dir})
+	}
+	slices.SortFunc(m.Packages, func(a, b MonorepoPackage) int { return strings.Compare(a.Path, b.Path) })
+
+	return m, nil
+}
+
+// globPackages returns the directories of root matching patterns that hold manifest,
+// named by nameOf. Patterns are globs relative to root, where a trailing /** matches
+// every directory below, and those starting with ! exclude directories.
+func globPackages(root string, patterns []string, manifest string, nameOf func(dir string) string) ([]MonorepoPackage, error) {
+	var includes, excludes []string
+	for _, pattern := range patterns {
+		if exclude, ok := strings.CutPrefix(pattern, "!"); ok {
+			excludes = append(excludes, path.Clean(strings.TrimPrefix(exclude, "./")))
+		} else {
+			includes = append(includes, path.Clean(strings.TrimPrefix(pattern, "./")))
+		}
+	}
+
+	excluded := func(dir string) bool {
+		return slices.ContainsFunc(excludes, func(pattern string) bool {
+			matched, _ := path.Match(pattern, dir)
+
+			return matched || strings.HasSuffix(pattern, "/**") && strings.HasPrefix(dir, strings.TrimSuffix(pattern, "**"))
+		})
+	}
+
+	var dirs []string
+	for _, pattern := range includes {
+		if base, ok := strings.CutSuffix(pattern, "/**"); ok {
+			err := filepath.WalkDir(filepath.Join(root, filepath.FromSlash(base)), func(p string, d fs.DirEntry, err error) error {
+				switch {
+				case err != nil:
+					return nil
+				case !d.IsDir():
+					return nil
+				case d.Name() == "node_modules" || d.Name() == "target" || strings.HasPrefix(d.Name(), "."):
+					return filepath.SkipDir
+				}
+				dirs = append(dirs, p)
+
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace pattern %q: %w", pattern, err)
+		}
+		dirs = append(dirs, matches...)
+	}
+
+	var packages []MonorepoPackage
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == "." || excluded(rel) || !absFileExists(filepath.Join(dir, manifest)) {
+			continue
+		}
+		if slices.ContainsFunc(packages, func(pkg MonorepoPackage) bool { return pkg.Path == rel }) {
+			continue
+		}
+
+		name := nameOf(dir)
+		if name == "" {
+			name = path.Base(rel)
+		}
+		packages = append(packages, MonorepoPackage{Name: name, Path: rel})
+	}
+	slices.SortFunc(packages, func(a, b MonorepoPackage) int { return strings.Compare(a.Path, b.Path) })
+
+	return packages, nil
+}
+
+func toStrings(v any) []string {
+	items, _ := v.([]any)
+
+	var strs []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+
+	return strs
+}
+
+var monorepoFamilies = map[string]string{
+	"pnpm":  "NodeJS",
+	"yarn":  "NodeJS",
+	"npm":   "NodeJS",
+	"cargo": "Rust",
+	"go":    "Go",
+}
+
+// ScanMonorepoPackage scans the package pkg of m like Scan scans an app. The files it
+// generates go to the directory of the package, and unless the package has its own
+// Dockerfile, its Dockerfile builds it with the root of the workspace as build context,
+// so that the workspace packages it depends on are part of the build.
+func ScanMonorepoPackage(m *Monorepo, pkg *MonorepoPackage, config *ScannerConfig) (*SourceInfo, error) {
+	pkgDir := filepath.Join(m.Root, filepath.FromSlash(pkg.Path))
+
+	si, err := Scan(pkgDir, config)
+	if err != nil {
+		return nil, err
+	}
+	if si == nil {
+		// scanners may miss a package whose lockfile is at the root of the workspace,
+		// such as the crates of a Cargo workspace
+		si = &SourceInfo{
+			Family: monorepoFamilies[m.Tool],
+			Port:   8080,
+			Env: map[string]string{
+				"PORT": "8080",
+			},
+		}
+	}
+
+	si.PackagePath = pkg.Path
+	notice := fmt.Sprintf("%s builds from the root of its workspace: deploy it from there with fly deploy --config %s", pkg.Name, path.Join(pkg.Path, "fly.toml"))
+	if si.Notice != "" {
+		notice = si.Notice + "\n" + notice
+	}
+	si.Notice = notice
+
+	// a Dockerfile of the package is left for it to build as it means to
+	if absFileExists(filepath.Join(pkgDir, "Dockerfile")) {
+		return si, nil
+	}
+
+	dockerfile, err := monorepoDockerfile(m, pkg, si)
+	if err != nil {
+		return nil, err
+	}
+
+	// generators of framework scanners would write Dockerfiles that only see the package
+	si.Callback = nil
+	si.InitCommands = nil
+	si.PostInitCallback = nil
+	si.MergeConfig = nil
+	si.DockerfileAppendix = nil
+
+	dockerignore, err := fs.ReadFile(content, "templates/monorepo/.dockerignore")
+	if err != nil {
+		return nil, err
+	}
+
+	files := []SourceFile{
+		{Path: path.Join(pkg.Path, "Dockerfile"), Contents: dockerfile},
+		{Path: path.Join(pkg.Path, ".dockerignore"), Contents: dockerignore},
+	}
+	for _, f := range si.Files {
+		if f.Path != "Dockerfile" && f.Path != ".dockerignore" {
+			files = append(files, SourceFile{Path: path.Join(pkg.Path, filepath.ToSlash(f.Path)), Contents: f.Contents})
+		}
+	}
+	si.Files = files
+
+	return si, nil
+}
+
+// monorepoDockerfile returns the Dockerfile building pkg from the root of m.
+func monorepoDockerfile(m *Monorepo, pkg *MonorepoPackage, si *SourceInfo) ([]byte, error) {
+	vars := map[string]any{
+		"tool": m.Tool,
+		"name": pkg.Name,
+		"path": pkg.Path,
+		"port": si.Port,
+	}
+	if si.Port == 0 {
+		vars["port"] = 8080
+	}
+
+	var name string
+	switch m.Tool {
+	case "pnpm", "yarn", "npm":
+		name = "node.Dockerfile"
+		vars["turbo"] = m.Turbo
+		vars["nodeVersion"] = "lts"
+		if si.Runtime.Language == "node" && si.Runtime.Version != "" {
+			vars["nodeVersion"] = si.Runtime.Version
+		}
+
+		hasBuild := packageHasScript(filepath.Join(m.Root, filepath.FromSlash(pkg.Path)), "build")
+		switch m.Tool {
+		case "pnpm":
+			vars["install"] = "pnpm install --frozen-lockfile"
+			// the trailing ... also builds the workspace packages pkg depends on
+			vars["build"] = fmt.Sprintf("pnpm --filter %q run --if-present build", pkg.Name+"...")
+		case "yarn":
+			vars["install"] = "yarn install"
+			if hasBuild {
+				vars["build"] = fmt.Sprintf("yarn workspace %s run build", pkg.Name)
+			}
+		default:
+			vars["install"] = "npm ci"
+			vars["build"] = fmt.Sprintf("npm run build --workspace=%s --if-present", pkg.Path)
+		}
+	case "cargo":
+		name = "cargo.Dockerfile"
+	case "go":
+		name = "go.Dockerfile"
+		vars["goVersion"] = m.goVersion
+	default:
+		return nil, fmt.Errorf("unsupported workspace tool %s", m.Tool)
+	}
+
+	data, err := fs.ReadFile(content, path.Join("templates/monorepo", name))
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var dockerfile strings.Builder
+	if err := tmpl.Execute(&dockerfile, vars); err != nil {
+		return nil, err
+	}
+
+	return []byte(dockerfile.String()), nil
+}
+
+// packageHasScript tells whether the package.json of dir has the given script.
+func packageHasScript(dir, script string) bool {
+	var manifest struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil || json.Unmarshal(data, &manifest) != nil {
+		return false
+	}
+
+	_, ok := manifest.Scripts[script]
+
+	return ok
+}
@@ -0,0 +1,205 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMonorepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+
+	return dir
+}
+
+func TestDetectMonorepo(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		files    map[string]string
+		tool     string
+		turbo    bool
+		packages []MonorepoPackage
+	}{
+		{
+			name: "pnpm",
+			files: map[string]string{
+				"pnpm-workspace.yaml":                     "packages:\n  - apps/*\n  - packages/**\n  - '!packages/internal/**'\n",
+				"apps/web/package.json":                   `{"name": "@acme/web"}`,
+				"apps/docs/README.md":                     ``,
+				"packages/ui/package.json":                `{"name": "@acme/ui"}`,
+				"packages/internal/tools/package.json":    `{"name": "@acme/tools"}`,
+				"packages/ui/node_modules/x/package.json": `{"name": "x"}`,
+			},
+			tool: "pnpm",
+			packages: []MonorepoPackage{
+				{Name: "@acme/web", Path: "apps/web"},
+				{Name: "@acme/ui", Path: "packages/ui"},
+			},
+		},
+		{
+			name: "yarn with turbo",
+			files: map[string]string{
+				"package.json":          `{"workspaces": {"packages": ["apps/*"]}}`,
+				"yarn.lock":             ``,
+				"turbo.json":            `{}`,
+				"apps/api/package.json": `{}`,
+			},
+			tool:     "yarn",
+			turbo:    true,
+			packages: []MonorepoPackage{{Name: "api", Path: "apps/api"}},
+		},
+		{
+			name: "npm",
+			files: map[string]string{
+				"package.json":         `{"workspaces": ["./server"]}`,
+				"server/package.json":  `{"name": "server"}`,
+				"unrelated/index.html": ``,
+			},
+			tool:     "npm",
+			packages: []MonorepoPackage{{Name: "server", Path: "server"}},
+		},
+		{
+			name: "cargo",
+			files: map[string]string{
+				"Cargo.toml":             "[workspace]\nmembers = [\"crates/*\"]\nexclude = [\"crates/old\"]\n",
+				"crates/api/Cargo.toml":  "[package]\nname = \"acme-api\"\n",
+				"crates/core/Cargo.toml": "[package]\nname = \"acme-core\"\n",
+				"crates/old/Cargo.toml":  "[package]\nname = \"acme-old\"\n",
+			},
+			tool: "cargo",
+			packages: []MonorepoPackage{
+				{Name: "acme-api", Path: "crates/api"},
+				{Name: "acme-core", Path: "crates/core"},
+			},
+		},
+		{
+			name: "go",
+			files: map[string]string{
+				"go.work":        "go 1.24\n\nuse (\n\t./api\n\t./lib\n)\n",
+				"api/go.mod":     "module example.com/api\n",
+				"lib/go.mod":     "module example.com/lib\n",
+				"other/go.mod":   "module example.com/other\n",
+				"api/main.go":    "package main\n",
+				"lib/lib.go":     "package lib\n",
+				"other/other.go": "package other\n",
+			},
+			tool: "go",
+			packages: []MonorepoPackage{
+				{Name: "example.com/api", Path: "api"},
+				{Name: "example.com/lib", Path: "lib"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := DetectMonorepo(writeMonorepo(t, tc.files))
+			require.NoError(t, err)
+			require.NotNil(t, m)
+
+			assert.Equal(t, tc.tool, m.Tool)
+			assert.Equal(t, tc.turbo, m.Turbo)
+			assert.Equal(t, tc.packages, m.Packages)
+		})
+	}
+
+	for _, files := range []map[string]string{
+		{"package.json": `{"name": "app"}`},
+		{"Cargo.toml": "[package]\nname = \"app\"\n"},
+		{"go.mod": "module example.com/app\n"},
+	} {
+		m, err := DetectMonorepo(writeMonorepo(t, files))
+		require.NoError(t, err)
+		assert.Nil(t, m)
+	}
+}
+
+func TestMonorepoPackage(t *testing.T) {
+	m := &Monorepo{
+		Tool:     "pnpm",
+		Packages: []MonorepoPackage{{Name: "@acme/web", Path: "apps/web"}},
+	}
+
+	pkg, err := m.Package("@acme/web")
+	require.NoError(t, err)
+	assert.Equal(t, "apps/web", pkg.Path)
+
+	pkg, err = m.Package("./apps/web/")
+	require.NoError(t, err)
+	assert.Equal(t, "@acme/web", pkg.Name)
+
+	_, err = m.Package("api")
+	assert.ErrorContains(t, err, "choose one of @acme/web")
+}
+
+func TestScanMonorepoPackage(t *testing.T) {
+	t.Setenv("OPT_OUT_GITHUB_ACTIONS", "1")
+
+	dir := writeMonorepo(t, map[string]string{
+		"Cargo.toml":             "[workspace]\nmembers = [\"crates/*\"]\n",
+		"Cargo.lock":             ``,
+		"crates/api/Cargo.toml":  "[package]\nname = \"acme-api\"\n\n[dependencies]\naxum = \"0.8\"\n",
+		"crates/core/Cargo.toml": "[package]\nname = \"acme-core\"\n",
+	})
+	m, err := DetectMonorepo(dir)
+	require.NoError(t, err)
+	pkg, err := m.Package("acme-api")
+	require.NoError(t, err)
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	si, err := ScanMonorepoPackage(m, pkg, &ScannerConfig{})
+	require.NoError(t, err)
+	require.NotNil(t, si)
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, wd, cwd, "the working directory is restored")
+
+	assert.Equal(t, "Axum", si.Family)
+	assert.Equal(t, "crates/api", si.PackagePath)
+	assert.Contains(t, si.Notice, "fly deploy --config crates/api/fly.toml")
+
+	paths := make(map[string]string)
+	for _, f := range si.Files {
+		paths[f.Path] = string(f.Contents)
+	}
+	require.Contains(t, paths, "crates/api/Dockerfile")
+	assert.Contains(t, paths, "crates/api/.dockerignore")
+	assert.Contains(t, paths["crates/api/Dockerfile"], "cargo build --release --package acme-api")
+	assert.Contains(t, paths["crates/api/Dockerfile"], "COPY --from=build /app/target/release/acme-api /usr/local/bin/acme-api")
+}
+
+func TestMonorepoDockerfile(t *testing.T) {
+	dir := writeMonorepo(t, map[string]string{
+		"apps/web/package.json": `{"name": "@acme/web", "scripts": {"build": "vite build"}}`,
+	})
+	pkg := &MonorepoPackage{Name: "@acme/web", Path: "apps/web"}
+	si := &SourceInfo{Port: 3000}
+
+	dockerfile, err := monorepoDockerfile(&Monorepo{Tool: "pnpm", Root: dir}, pkg, si)
+	require.NoError(t, err)
+	assert.Contains(t, string(dockerfile), `RUN pnpm --filter "@acme/web..." run --if-present build`)
+	assert.Contains(t, string(dockerfile), "WORKDIR /app/apps/web")
+	assert.Contains(t, string(dockerfile), "EXPOSE 3000")
+	assert.NotContains(t, string(dockerfile), "turbo")
+
+	dockerfile, err = monorepoDockerfile(&Monorepo{Tool: "yarn", Turbo: true, Root: dir}, pkg, si)
+	require.NoError(t, err)
+	assert.Contains(t, string(dockerfile), "RUN turbo prune @acme/web --docker")
+	assert.Contains(t, string(dockerfile), "RUN npx turbo run build --filter=@acme/web")
+
+	dockerfile, err = monorepoDockerfile(&Monorepo{Tool: "go", Root: dir, goVersion: "1.24"}, &MonorepoPackage{Name: "example.com/api", Path: "api"}, si)
+	require.NoError(t, err)
+	assert.Contains(t, string(dockerfile), "ARG GO_VERSION=1.24")
+	assert.Contains(t, string(dockerfile), "RUN go build -v -o /run-app ./api")
+}
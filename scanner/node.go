@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"golang.org/x/mod/semver"
@@ -22,7 +23,7 @@ func configureNode(sourceDir string, config *ScannerConfig) (*SourceInfo, error)
 	remix := checksPass(sourceDir, dirContains("package.json", "remix"))
 	prisma := checksPass(sourceDir, dirContains("package.json", "prisma"))
 
-	data, err := os.ReadFile("package.json")
+	data, err := os.ReadFile(filepath.Join(sourceDir, "package.json"))
 	if err != nil {
 		return nil, nil
 	}
@@ -59,7 +60,9 @@ func configureNode(sourceDir string, config *ScannerConfig) (*SourceInfo, error)
 	var nodeLtsVersion = "18.16.0"
 	var nodeVersion = nodeLtsVersion
 
-	out, err := exec.Command("node", "-v").Output()
+	nodeCmd := exec.Command("node", "-v")
+	nodeCmd.Dir = sourceDir
+	out, err := nodeCmd.Output()
 
 	if err == nil {
 		versionWithV := strings.TrimSpace(string(out))
@@ -77,7 +80,9 @@ func configureNode(sourceDir string, config *ScannerConfig) (*SourceInfo, error)
 		}
 	}
 
-	out, err = exec.Command("yarn", "-v").Output()
+	yarnCmd := exec.Command("yarn", "-v")
+	yarnCmd.Dir = sourceDir
+	out, err = yarnCmd.Output()
 
 	if err == nil {
 		yarnVersion = strings.TrimSpace(string(out))
@@ -85,7 +90,7 @@ func configureNode(sourceDir string, config *ScannerConfig) (*SourceInfo, error)
 
 	package_files := []string{"package.json"}
 
-	_, err = os.Stat("yarn.lock")
+	_, err = os.Stat(filepath.Join(sourceDir, "yarn.lock"))
 	// install yarn if there's a yarn.lock and if nodejs version is under 18
 	yarnLockExists := !os.IsNotExist(err)
 	shouldInstallYarn := false
@@ -100,7 +105,7 @@ func configureNode(sourceDir string, config *ScannerConfig) (*SourceInfo, error)
 	if os.IsNotExist(err) {
 		vars["packager"] = "npm"
 
-		_, err = os.Stat("package-lock.json")
+		_, err = os.Stat(filepath.Join(sourceDir, "package-lock.json"))
 		if !os.IsNotExist(err) {
 			package_files = append(package_files, "package-lock.json")
 		}
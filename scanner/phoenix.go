@@ -88,6 +88,7 @@ func configurePhoenix(sourceDir string, config *ScannerConfig) (*SourceInfo, err
 		var err error
 		for range 2 {
 			cmd = exec.Command("asdf", "install")
+			cmd.Dir = sourceDir
 			cmd.Stdout = os.Stdout
 			cmd.Stderr = os.Stderr
 			err = cmd.Run()
@@ -105,6 +106,7 @@ func configurePhoenix(sourceDir string, config *ScannerConfig) (*SourceInfo, err
 		}
 
 		cmd = exec.Command("mix", "local.hex", "--force")
+		cmd.Dir = sourceDir
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		err = cmd.Run()
@@ -113,6 +115,7 @@ func configurePhoenix(sourceDir string, config *ScannerConfig) (*SourceInfo, err
 		}
 
 		cmd = exec.Command("mix", "local.rebar", "--force")
+		cmd.Dir = sourceDir
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		err = cmd.Run()
@@ -123,6 +126,7 @@ func configurePhoenix(sourceDir string, config *ScannerConfig) (*SourceInfo, err
 
 	// We found Phoenix, so check if the project compiles.
 	cmd := exec.Command("mix", "do", "deps.get,", "compile")
+	cmd.Dir = sourceDir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	err := cmd.Run()
@@ -132,6 +136,7 @@ func configurePhoenix(sourceDir string, config *ScannerConfig) (*SourceInfo, err
 
 	// We found Phoenix, so lets check if its a recent version.
 	releaseCmd := exec.Command("mix", "run", "-e", "true = Code.ensure_loaded?(Mix.Tasks.Phx.Gen.Release)")
+	releaseCmd.Dir = sourceDir
 	releaseCmd.Stdout = os.Stdout
 	releaseCmd.Stderr = os.Stderr
 	err = releaseCmd.Run()
@@ -0,0 +1,206 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/helpers"
+)
+
+const (
+	// pluginPrefix is the prefix of the names of scanner plugins.
+	pluginPrefix = "fly-scanner-"
+
+	pluginTimeout = 30 * time.Second
+)
+
+// pluginSourceInfo is the part of SourceInfo scanner plugins may print, as JSON, to
+// describe the source directory they're given.
+type pluginSourceInfo struct {
+	Family        string            `json:"family"`
+	Version       string            `json:"version,omitempty"`
+	Port          int               `json:"port,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+	Processes     map[string]string `json:"processes,omitempty"`
+	ReleaseCmd    string            `json:"release_command,omitempty"`
+	HttpCheckPath string            `json:"http_check_path,omitempty"`
+	Files         []pluginFile      `json:"files,omitempty"`
+	Secrets       []pluginSecret    `json:"secrets,omitempty"`
+	Database      string            `json:"database,omitempty"`
+	Redis         bool              `json:"redis,omitempty"`
+	ObjectStorage bool              `json:"object_storage,omitempty"`
+	SkipDeploy    bool              `json:"skip_deploy,omitempty"`
+	Notice        string            `json:"notice,omitempty"`
+}
+
+type pluginFile struct {
+	Path     string `json:"path"`
+	Contents string `json:"contents"`
+}
+
+type pluginSecret struct {
+	Key   string `json:"key"`
+	Help  string `json:"help,omitempty"`
+	Value string `json:"value,omitempty"`
+	// Generate asks for a random value, when Value is empty.
+	Generate bool `json:"generate,omitempty"`
+}
+
+var pluginDatabases = map[string]DatabaseKind{
+	"":         DatabaseKindNone,
+	"postgres": DatabaseKindPostgres,
+	"mysql":    DatabaseKindMySQL,
+	"sqlite":   DatabaseKindSqlite,
+}
+
+// findPlugins returns the paths of the scanner plugins, the executables named
+// fly-scanner-* of dir and of the PATH. Plugins are sorted by name within each
+// directory, and of plugins sharing a name the first found is kept.
+func findPlugins(dir string) []string {
+	dirs := filepath.SplitList(os.Getenv("PATH"))
+	if dir != "" {
+		dirs = append([]string{dir}, dirs...)
+	}
+
+	var (
+		plugins []string
+		names   []string
+	)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, pluginPrefix) || slices.Contains(names, name) {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil || info.IsDir() || !isExecutable(name, info.Mode()) {
+				continue
+			}
+
+			names = append(names, name)
+			plugins = append(plugins, filepath.Join(dir, name))
+		}
+	}
+
+	return plugins
+}
+
+func isExecutable(name string, mode os.FileMode) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(name), ".exe")
+	}
+
+	return mode&0o111 != 0
+}
+
+// pluginScanner returns the scanner running the plugin at path. The plugin is run in
+// the source directory, which is also its only argument, and prints the JSON of a
+// pluginSourceInfo if it recognizes the source, or nothing, or null, if it doesn't.
+func pluginScanner(path string) sourceScanner {
+	return func(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+		defer cancel()
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, path, sourceDir)
+		cmd.Dir = sourceDir
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		cmd.Env = append(os.Environ(), "FLY_SCANNER_MODE="+config.Mode)
+
+		name := filepath.Base(path)
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("scanner plugin %s failed: %w: %s", name, err, msg)
+			}
+
+			return nil, fmt.Errorf("scanner plugin %s failed: %w", name, err)
+		}
+
+		output := bytes.TrimSpace(stdout.Bytes())
+		if len(output) == 0 || bytes.Equal(output, []byte("null")) {
+			return nil, nil
+		}
+
+		var info pluginSourceInfo
+		if err := json.Unmarshal(output, &info); err != nil {
+			return nil, fmt.Errorf("scanner plugin %s printed invalid JSON: %w", name, err)
+		}
+
+		si, err := info.sourceInfo()
+		if err != nil {
+			return nil, fmt.Errorf("scanner plugin %s: %w", name, err)
+		}
+
+		return si, nil
+	}
+}
+
+// sourceInfo returns the SourceInfo described by info.
+func (info *pluginSourceInfo) sourceInfo() (*SourceInfo, error) {
+	if info.Family == "" {
+		return nil, errors.New("no family given")
+	}
+
+	database, ok := pluginDatabases[info.Database]
+	if !ok {
+		return nil, fmt.Errorf("unknown database %q, use postgres, mysql or sqlite", info.Database)
+	}
+
+	si := &SourceInfo{
+		Family:               info.Family,
+		Version:              info.Version,
+		Port:                 info.Port,
+		Env:                  info.Env,
+		Processes:            info.Processes,
+		ReleaseCmd:           info.ReleaseCmd,
+		HttpCheckPath:        info.HttpCheckPath,
+		DatabaseDesired:      database,
+		RedisDesired:         info.Redis,
+		ObjectStorageDesired: info.ObjectStorage,
+		SkipDeploy:           info.SkipDeploy,
+		Notice:               info.Notice,
+	}
+
+	for _, f := range info.Files {
+		// files are written to the source directory, and nowhere else
+		clean := filepath.Clean(filepath.FromSlash(f.Path))
+		if f.Path == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("file path %q isn't within the source directory", f.Path)
+		}
+
+		si.Files = append(si.Files, SourceFile{Path: clean, Contents: []byte(f.Contents)})
+	}
+
+	for _, secret := range info.Secrets {
+		if secret.Key == "" {
+			return nil, errors.New("a secret has no key")
+		}
+
+		s := Secret{Key: secret.Key, Help: secret.Help, Value: secret.Value}
+		if secret.Value == "" && secret.Generate {
+			s.Generate = func() (string, error) {
+				return helpers.RandString(64)
+			}
+		}
+		si.Secrets = append(si.Secrets, s)
+	}
+
+	return si, nil
+}
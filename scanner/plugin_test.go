@@ -0,0 +1,114 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755))
+
+	return path
+}
+
+func TestFindPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+
+	configured, onPath := t.TempDir(), t.TempDir()
+	t.Setenv("PATH", onPath)
+
+	writePlugin(t, configured, "fly-scanner-acme", "")
+	writePlugin(t, onPath, "fly-scanner-acme", "")
+	writePlugin(t, onPath, "fly-scanner-beta", "")
+	writePlugin(t, onPath, "other-tool", "")
+	require.NoError(t, os.WriteFile(filepath.Join(onPath, "fly-scanner-notes"), nil, 0o644))
+
+	assert.Equal(t, []string{
+		filepath.Join(configured, "fly-scanner-acme"),
+		filepath.Join(onPath, "fly-scanner-beta"),
+	}, findPlugins(configured))
+}
+
+func TestScanWithPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("OPT_OUT_GITHUB_ACTIONS", "1")
+
+	plugins := t.TempDir()
+	writePlugin(t, plugins, "fly-scanner-a-skip", `exit 0`)
+	writePlugin(t, plugins, "fly-scanner-b-acme", `
+[ -f "$1/acme.yml" ] || exit 0
+printf '%s' '{
+  "family": "Acme",
+  "port": 9000,
+  "env": {"ACME_ENV": "production"},
+  "processes": {"app": "acme serve", "worker": "acme work"},
+  "files": [{"path": "Dockerfile", "contents": "FROM acme/runtime\n"}],
+  "secrets": [{"key": "ACME_KEY", "generate": true}, {"key": "ACME_LICENSE", "help": "Your license"}],
+  "database": "postgres",
+  "redis": true
+}'`)
+
+	source := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(source, "acme.yml"), nil, 0o644))
+
+	si, err := Scan(source, &ScannerConfig{PluginsDir: plugins})
+	require.NoError(t, err)
+	require.NotNil(t, si)
+
+	assert.Equal(t, "Acme", si.Family)
+	assert.Equal(t, 9000, si.Port)
+	assert.Equal(t, map[string]string{"ACME_ENV": "production"}, si.Env)
+	assert.Equal(t, map[string]string{"app": "acme serve", "worker": "acme work"}, si.Processes)
+	assert.Equal(t, []SourceFile{{Path: "Dockerfile", Contents: []byte("FROM acme/runtime\n")}}, si.Files)
+	assert.Equal(t, DatabaseKindPostgres, si.DatabaseDesired)
+	assert.True(t, si.RedisDesired)
+
+	require.Len(t, si.Secrets, 2)
+	require.NotNil(t, si.Secrets[0].Generate)
+	key, err := si.Secrets[0].Generate()
+	require.NoError(t, err)
+	assert.Len(t, key, 64)
+	assert.Nil(t, si.Secrets[1].Generate)
+	assert.Equal(t, "Your license", si.Secrets[1].Help)
+
+	// sources the plugins don't recognize go on to the built-in scanners
+	si, err = Scan(t.TempDir(), &ScannerConfig{PluginsDir: plugins})
+	require.NoError(t, err)
+	assert.Nil(t, si)
+}
+
+func TestScanWithFailingPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+
+	t.Setenv("PATH", t.TempDir())
+
+	for script, want := range map[string]string{
+		"echo broken >&2; exit 3": "scanner plugin fly-scanner-x failed: exit status 3: broken",
+		"echo '{'":                "printed invalid JSON",
+		`echo '{"port": 80}'`:     "no family given",
+		`echo '{"family": "X", "database": "oracle"}'`:        `unknown database "oracle"`,
+		`echo '{"family": "X", "files": [{"path": "../x"}]}'`: `file path "../x" isn't within the source directory`,
+	} {
+		plugins := t.TempDir()
+		writePlugin(t, plugins, "fly-scanner-x", script)
+
+		_, err := Scan(t.TempDir(), &ScannerConfig{PluginsDir: plugins})
+		assert.ErrorContains(t, err, want, script)
+	}
+}
@@ -76,9 +76,8 @@ type PyCfg struct {
 	depStyle  PyDepStyle
 }
 
-func findEntrypoint(dep string) *os.File {
-	var entrypoint *os.File = nil
-	filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+func findEntrypoint(sourceDir, dep string) (entrypoint string) {
+	filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -93,7 +92,9 @@ func findEntrypoint(dep string) *os.File {
 			for scanner.Scan() {
 				line := scanner.Text()
 				if strings.Contains(line, "import") && strings.Contains(line, dep) {
-					entrypoint = file
+					if rel, err := filepath.Rel(sourceDir, path); err == nil {
+						entrypoint = rel
+					}
 				}
 			}
 
@@ -146,7 +147,7 @@ func readLines(filename string) ([]string, error) {
 	return lines, nil
 }
 
-func intoSource(cfg PyCfg) (*SourceInfo, error) {
+func intoSource(sourceDir string, cfg PyCfg) (*SourceInfo, error) {
 	vars := make(map[string]any)
 	vars["pyVersion"] = cfg.pyVersion
 	vars["appName"] = cfg.appName
@@ -192,11 +193,11 @@ func intoSource(cfg PyCfg) (*SourceInfo, error) {
 		}, nil
 	case Streamlit:
 		vars["streamlit"] = true
-		entrypoint := findEntrypoint("streamlit")
-		if entrypoint == nil {
+		entrypoint := findEntrypoint(sourceDir, "streamlit")
+		if entrypoint == "" {
 			return nil, nil
 		} else {
-			vars["entrypoint"] = entrypoint.Name()
+			vars["entrypoint"] = entrypoint
 		}
 
 		return &SourceInfo{
@@ -216,7 +217,7 @@ func configPoetry(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 		return nil, nil
 	}
 	terminal.Info("Detected Poetry project")
-	doc, err := os.ReadFile("pyproject.toml")
+	doc, err := os.ReadFile(filepath.Join(sourceDir, "pyproject.toml"))
 	if err != nil {
 		return nil, errors.Wrap(err, "Error reading pyproject.toml")
 	}
@@ -241,7 +242,7 @@ func configPoetry(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 	pyVersion = parsePyDep(pyVersion)
 	cfg := PyCfg{pyVersion, appName, depList, Poetry}
 
-	return intoSource(cfg)
+	return intoSource(sourceDir, cfg)
 }
 
 func configPyProject(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
@@ -249,7 +250,7 @@ func configPyProject(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 		return nil, nil
 	}
 	terminal.Info("Detected pyproject.toml")
-	doc, err := os.ReadFile("pyproject.toml")
+	doc, err := os.ReadFile(filepath.Join(sourceDir, "pyproject.toml"))
 	if err != nil {
 		return nil, errors.Wrap(err, "Error reading pyproject.toml")
 	}
@@ -269,7 +270,7 @@ func configPyProject(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 	appName := pyProject.Project.Name
 	pyVersion := pyProject.Project.RequiresPython
 	if pyVersion == "" {
-		extracted, _, err := extractPythonVersion()
+		extracted, _, err := extractPythonVersion(sourceDir)
 		if err != nil {
 			return nil, err
 		}
@@ -282,7 +283,7 @@ func configPyProject(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 
 	cfg := PyCfg{pyVersion, appName, depList, Pep621}
 
-	return intoSource(cfg)
+	return intoSource(sourceDir, cfg)
 }
 
 func configPipfile(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
@@ -290,7 +291,7 @@ func configPipfile(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 		return nil, nil
 	}
 	terminal.Info("Detected Pipfile")
-	doc, err := os.ReadFile("Pipfile")
+	doc, err := os.ReadFile(filepath.Join(sourceDir, "Pipfile"))
 	if err != nil {
 		return nil, errors.Wrap(err, "Error reading Pipfile")
 	}
@@ -308,7 +309,7 @@ func configPipfile(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 		depList = append(depList, dep)
 	}
 
-	pyVersion, _, err := extractPythonVersion()
+	pyVersion, _, err := extractPythonVersion(sourceDir)
 	if err != nil {
 		return nil, err
 	}
@@ -316,21 +317,21 @@ func configPipfile(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 	appName := filepath.Base(sourceDir)
 	cfg := PyCfg{pyVersion, appName, depList, Pipenv}
 
-	return intoSource(cfg)
+	return intoSource(sourceDir, cfg)
 }
 
 func configRequirements(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 	var deps []string
 	if checksPass(sourceDir, fileExists("requirements.txt")) {
 		terminal.Info("Detected requirements.txt")
-		req_deps, err := readLines("requirements.txt")
+		req_deps, err := readLines(filepath.Join(sourceDir, "requirements.txt"))
 		if err != nil {
 			return nil, err
 		}
 		deps = req_deps
 	} else if checksPass(sourceDir, fileExists("requirements.in")) {
 		terminal.Info("Detected requirements.in")
-		req_deps, err := readLines("requirements.in")
+		req_deps, err := readLines(filepath.Join(sourceDir, "requirements.in"))
 		if err != nil {
 			return nil, err
 		}
@@ -346,14 +347,14 @@ func configRequirements(sourceDir string, _ *ScannerConfig) (*SourceInfo, error)
 		dep := parsePyDep(dep)
 		depList = append(depList, dep)
 	}
-	pyVersion, _, err := extractPythonVersion()
+	pyVersion, _, err := extractPythonVersion(sourceDir)
 	if err != nil {
 		return nil, err
 	}
 	appName := filepath.Base(sourceDir)
 	cfg := PyCfg{pyVersion, appName, depList, Pip}
 
-	return intoSource(cfg)
+	return intoSource(sourceDir, cfg)
 }
 
 func configurePython(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
@@ -377,7 +378,7 @@ func configurePython(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 		return nil, nil
 	}
 
-	pythonVersion, _, err := extractPythonVersion()
+	pythonVersion, _, err := extractPythonVersion(sourceDir)
 	if err != nil {
 		return nil, err
 	}
@@ -398,9 +399,9 @@ func configurePython(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 	return s, nil
 }
 
-func extractPythonVersion() (string, bool, error) {
+func extractPythonVersion(sourceDir string) (string, bool, error) {
 	var pipfileLock PipfileLock
-	contents, err := os.ReadFile("Pipfile.lock")
+	contents, err := os.ReadFile(filepath.Join(sourceDir, "Pipfile.lock"))
 	if err == nil {
 		if err := json.Unmarshal(contents, &pipfileLock); err == nil {
 			if pyVersion := pipfileLock.Meta.Requires.PythonVersion; pyVersion != "" {
@@ -416,11 +417,13 @@ func extractPythonVersion() (string, bool, error) {
 	pythonVersionOutput := "Python 3.12.0" // Fallback to 3.12
 
 	cmd := exec.Command("python3", "--version")
+	cmd.Dir = sourceDir
 	out, err := cmd.CombinedOutput()
 	if err == nil {
 		pythonVersionOutput = string(out)
 	} else {
 		cmd := exec.Command("python", "--version")
+		cmd.Dir = sourceDir
 		out, err := cmd.CombinedOutput()
 		if err == nil {
 			pythonVersionOutput = string(out)
@@ -84,7 +84,7 @@ func configureRails(sourceDir string, config *ScannerConfig) (*SourceInfo, error
 	var rubyVersion string
 
 	// add ruby version from Gemfile
-	gemfile, err := os.ReadFile("Gemfile")
+	gemfile, err := os.ReadFile(filepath.Join(sourceDir, "Gemfile"))
 	if err == nil {
 		re := regexp.MustCompile(`(?m)^ruby\s+["'](\d+\.\d+\.\d+)["']`)
 		matches := re.FindStringSubmatch(string(gemfile))
@@ -95,7 +95,7 @@ func configureRails(sourceDir string, config *ScannerConfig) (*SourceInfo, error
 
 	if rubyVersion == "" {
 		// add ruby version from .ruby-version file
-		versionFile, err := os.ReadFile(".ruby-version")
+		versionFile, err := os.ReadFile(filepath.Join(sourceDir, ".ruby-version"))
 		if err == nil {
 			re := regexp.MustCompile(`ruby-(\d+\.\d+\.\d+)`)
 			matches := re.FindStringSubmatch(string(versionFile))
@@ -106,7 +106,9 @@ func configureRails(sourceDir string, config *ScannerConfig) (*SourceInfo, error
 	}
 
 	if rubyVersion == "" {
-		versionOutput, err := exec.Command("ruby", "--version").Output()
+		cmd := exec.Command("ruby", "--version")
+		cmd.Dir = sourceDir
+		versionOutput, err := cmd.Output()
 		if err == nil {
 			re := regexp.MustCompile(`ruby (\d+\.\d+\.\d+)`)
 			matches := re.FindStringSubmatch(string(versionOutput))
@@ -157,13 +159,13 @@ func configureRails(sourceDir string, config *ScannerConfig) (*SourceInfo, error
 
 	// enable redis if there are any action cable / anycable channels
 	redis := false
-	files, err := filepath.Glob("app/channels/*.rb")
+	files, err := filepath.Glob(filepath.Join(sourceDir, "app", "channels", "*.rb"))
 	if err == nil && len(files) > 0 {
 		redis = !checksPass(sourceDir, dirContains("Gemfile", "solid_cable"))
 	}
 
 	if !redis && !checksPass(sourceDir, dirContains("Gemfile", "solid_cable")) {
-		files, err = filepath.Glob("app/views/*")
+		files, err = filepath.Glob(filepath.Join(sourceDir, "app", "views", "*"))
 		if err == nil && len(files) > 0 {
 			for _, file := range files {
 				redis = checksPass(file, dirContains("*.html.erb", "turbo_stream_from"))
@@ -176,7 +178,7 @@ func configureRails(sourceDir string, config *ScannerConfig) (*SourceInfo, error
 
 	// enable redis if redis is used for caching
 	if !redis && !checksPass(sourceDir, dirContains("Gemfile", "solid_queue")) {
-		prodEnv, err := os.ReadFile("config/environments/production.rb")
+		prodEnv, err := os.ReadFile(filepath.Join(sourceDir, "config", "environments", "production.rb"))
 		if err == nil && strings.Contains(string(prodEnv), "redis") {
 			redis = true
 		}
@@ -226,7 +228,7 @@ func configureRails(sourceDir string, config *ScannerConfig) (*SourceInfo, error
 	}
 
 	// extract port from Dockerfile (if present).  This is primarily for thruster.
-	dockerfile, err := os.ReadFile("Dockerfile")
+	dockerfile, err := os.ReadFile(filepath.Join(sourceDir, "Dockerfile"))
 	if err == nil {
 		re := regexp.MustCompile(`(?m)^EXPOSE\s+(?P<port>\d+)`)
 		m := re.FindStringSubmatch(string(dockerfile))
@@ -247,9 +249,9 @@ func configureRails(sourceDir string, config *ScannerConfig) (*SourceInfo, error
 	// support for multi-environment credentials.  Use the Rails searching
 	// sequence for production credentials to determine the RAILS_MASTER_KEY.
 	binrails := filepath.Join("bin", "rails")
-	masterKey, err := os.ReadFile("config/credentials/production.key")
+	masterKey, err := os.ReadFile(filepath.Join(sourceDir, "config", "credentials", "production.key"))
 	if err != nil {
-		masterKey, err = os.ReadFile("config/master.key")
+		masterKey, err = os.ReadFile(filepath.Join(sourceDir, "config", "master.key"))
 	}
 
 	if err == nil {
@@ -261,7 +263,7 @@ func configureRails(sourceDir string, config *ScannerConfig) (*SourceInfo, error
 			},
 		}
 	} else {
-		if _, err = os.Stat(binrails); errors.Is(err, os.ErrNotExist) {
+		if _, err = os.Stat(filepath.Join(sourceDir, binrails)); errors.Is(err, os.ErrNotExist) {
 			// find absolute path to rake executable
 			binrails, err = exec.LookPath("rake")
 			if err != nil {
@@ -326,8 +328,10 @@ Once ready: run 'fly deploy' to deploy your Rails app.
 				return
 			}
 
-			out, err := exec.Command(ruby, binrails, "runner",
-				"puts Rails.application.routes.url_helpers.rails_health_check_path").Output()
+			cmd := exec.Command(ruby, binrails, "runner",
+				"puts Rails.application.routes.url_helpers.rails_health_check_path")
+			cmd.Dir = sourceDir
+			out, err := cmd.Output()
 
 			if err == nil {
 				healthcheck_channel <- strings.TrimSpace(string(out))
@@ -3,6 +3,7 @@ package scanner
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -19,7 +20,7 @@ func configureRuby(sourceDir string, config *ScannerConfig) (*SourceInfo, error)
 		Port:   8080,
 	}
 
-	rubyVersion, err := extractRubyVersion("Gemfile.lock", "Gemfile", ".ruby_version")
+	rubyVersion, err := extractRubyVersion(filepath.Join(sourceDir, "Gemfile.lock"), filepath.Join(sourceDir, "Gemfile"), filepath.Join(sourceDir, ".ruby_version"))
 	if err != nil {
 		return nil, errors.Wrap(err, "failure extracting Ruby version")
 	}
@@ -84,7 +85,9 @@ func extractRubyVersion(lockfilePath string, gemfilePath string, rubyVersionPath
 	}
 
 	if version == "" {
-		out, err := exec.Command("ruby", "-v").Output()
+		cmd := exec.Command("ruby", "-v")
+		cmd.Dir = filepath.Dir(gemfilePath)
+		out, err := cmd.Output()
 		if err == nil {
 
 			versionString := strings.TrimSpace(string(out))
@@ -1,13 +1,16 @@
 package scanner
 
-import "fmt"
+import (
+	"fmt"
+	"path/filepath"
+)
 
 func configureRust(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
 	if !checksPass(sourceDir, fileExists("Cargo.toml", "Cargo.lock")) {
 		return nil, nil
 	}
 
-	cargoData, err := readTomlFile("Cargo.toml")
+	cargoData, err := readTomlFile(filepath.Join(sourceDir, "Cargo.toml"))
 	if err != nil {
 		return nil, err
 	}
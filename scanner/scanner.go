@@ -89,6 +89,10 @@ type SourceInfo struct {
 	FailureCallback                 func(err error) error
 	Runtime                         plan.RuntimeStruct
 	PostInitCallback                func() error
+	// PackagePath is the directory of the workspace package scanned by
+	// ScanMonorepoPackage, relative to the workspace root. The app config goes there,
+	// while the workspace root remains the build context.
+	PackagePath string
 }
 
 type SourceFile struct {
@@ -105,6 +109,8 @@ type ScannerConfig struct {
 	ExistingPort    int
 	Colorize        *iostreams.ColorScheme
 	SkipHealthcheck bool // Skip healthcheck goroutine (primarily for tests)
+	// PluginsDir is a directory of scanner plugins, consulted before those on the PATH
+	PluginsDir string
 }
 
 type GitHubActionsStruct struct {
@@ -114,7 +120,13 @@ type GitHubActionsStruct struct {
 }
 
 func Scan(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
-	scanners := []sourceScanner{
+	var scanners []sourceScanner
+	// plugins are consulted first, so that in-house stacks aren't mistaken for one
+	// the built-in scanners know
+	for _, plugin := range findPlugins(config.PluginsDir) {
+		scanners = append(scanners, pluginScanner(plugin))
+	}
+	scanners = append(scanners,
 		configureDjango,
 		configureLaravel,
 		configurePhoenix,
@@ -133,13 +145,14 @@ func Scan(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
 		configureFlask,
 		configurePython,
 		configureDeno,
+		configureJvm,
 		configureNuxt,
 		configureNextJs,
 		configureNode,
 		configureStatic,
 		configureDotnet,
 		configureRust,
-	}
+	)
 
 	for _, scanner := range scanners {
 		si, err := scanner(sourceDir, config)
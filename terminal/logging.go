@@ -1,6 +1,7 @@
 package terminal
 
 import (
+	"io"
 	"os"
 	"strings"
 
@@ -29,6 +30,17 @@ func init() {
 	DefaultLogger = logger.New(os.Stdout, level, true).AndLogToFile()
 }
 
+// RedirectTo makes DefaultLogger write to w instead of stdout until the returned function
+// is called, for commands whose stdout carries machine-readable output.
+func RedirectTo(w io.Writer, isTerm bool) (restore func()) {
+	previous := DefaultLogger
+	DefaultLogger = logger.New(w, previous.Level(), isTerm).AndLogToFile()
+
+	return func() {
+		DefaultLogger = previous
+	}
+}
+
 func GetLogLevel() logger.Level {
 	return DefaultLogger.Level()
 }
@@ -0,0 +1,80 @@
+package wg
+
+import (
+	"bufio"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transports a tunnel can carry its WireGuard packets over.
+const (
+	TransportUDP       = "udp"
+	TransportWebsocket = "websocket"
+)
+
+// TunnelStats are the live statistics of a tunnel's WireGuard peer.
+type TunnelStats struct {
+	// Endpoint is the address of the gateway the tunnel connects to.
+	Endpoint  string
+	Transport string
+	// LastHandshake is zero until the first handshake completes.
+	LastHandshake time.Time
+	RxBytes       uint64
+	TxBytes       uint64
+}
+
+var errTunnelClosed = errors.New("tunnel is closed")
+
+// Stats returns the live statistics of the tunnel, as reported by its WireGuard device.
+func (t *Tunnel) Stats() (*TunnelStats, error) {
+	if t.dev == nil {
+		return nil, errTunnelClosed
+	}
+
+	ipc, err := t.dev.IpcGet()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := parseIpcStats(ipc)
+	stats.Endpoint = t.endpoint
+	stats.Transport = t.transport
+
+	return stats, nil
+}
+
+// parseIpcStats extracts the peer statistics from the output of the WireGuard UAPI get
+// operation. Tunnels have a single peer.
+func parseIpcStats(ipc string) *TunnelStats {
+	var (
+		stats     TunnelStats
+		sec, nsec int64
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(ipc))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "last_handshake_time_sec":
+			sec, _ = strconv.ParseInt(value, 10, 64)
+		case "last_handshake_time_nsec":
+			nsec, _ = strconv.ParseInt(value, 10, 64)
+		case "rx_bytes":
+			stats.RxBytes, _ = strconv.ParseUint(value, 10, 64)
+		case "tx_bytes":
+			stats.TxBytes, _ = strconv.ParseUint(value, 10, 64)
+		}
+	}
+
+	if sec != 0 || nsec != 0 {
+		stats.LastHandshake = time.Unix(sec, nsec)
+	}
+
+	return &stats
+}
@@ -0,0 +1,18 @@
+package wg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIpcStats(t *testing.T) {
+	stats := parseIpcStats("private_key=abcd\npublic_key=ef01\nendpoint=127.0.0.1:51820\nlast_handshake_time_sec=1700000000\nlast_handshake_time_nsec=500\ntx_bytes=1024\nrx_bytes=2048\npersistent_keepalive_interval=0\n")
+	assert.Equal(t, time.Unix(1700000000, 500), stats.LastHandshake)
+	assert.Equal(t, uint64(2048), stats.RxBytes)
+	assert.Equal(t, uint64(1024), stats.TxBytes)
+
+	stats = parseIpcStats("public_key=ef01\nlast_handshake_time_sec=0\nlast_handshake_time_nsec=0\n")
+	assert.True(t, stats.LastHandshake.IsZero())
+}
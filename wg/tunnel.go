@@ -24,8 +24,10 @@ type Tunnel struct {
 	State  *WireGuardState
 	Config *Config
 
-	wscancel func()
-	resolv   *net.Resolver
+	wscancel  func()
+	resolv    *net.Resolver
+	transport string
+	endpoint  string
 }
 
 func Connect(ctx context.Context, state *WireGuardState) (*Tunnel, error) {
@@ -71,8 +73,11 @@ func doConnect(ctx context.Context, state *WireGuardState, wswg bool) (*Tunnel,
 	endpointIP := endpointIPs[rand.Intn(len(endpointIPs))]
 	endpointAddr := net.JoinHostPort(endpointIP.String(), endpointPort)
 
+	transport := TransportUDP
 	var wscancel context.CancelFunc
 	if wswg {
+		transport = TransportWebsocket
+
 		var lifetimeCtx context.Context
 		lifetimeCtx, wscancel = context.WithCancel(context.Background())
 		port, err := websocketConnect(ctx, lifetimeCtx, endpointHost)
@@ -104,13 +109,15 @@ func doConnect(ctx context.Context, state *WireGuardState, wswg bool) (*Tunnel,
 	wgDev.Up()
 
 	return &Tunnel{
-		dev:      wgDev,
-		tun:      tunDev,
-		net:      gNet,
-		dnsIP:    cfg.DNS,
-		Config:   cfg,
-		State:    state,
-		wscancel: wscancel,
+		dev:       wgDev,
+		tun:       tunDev,
+		net:       gNet,
+		dnsIP:     cfg.DNS,
+		Config:    cfg,
+		State:     state,
+		wscancel:  wscancel,
+		transport: transport,
+		endpoint:  net.JoinHostPort(endpointIP.String(), endpointPort),
 
 		resolv: &net.Resolver{
 			PreferGo: true,